@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -40,6 +42,7 @@ var (
 	globalKubeConfig  string
 	globalKubeContext string
 	globalVerbose     bool
+	globalTimeout     time.Duration
 )
 
 func init() {
@@ -48,6 +51,7 @@ func init() {
 	f.StringVar(&globalKubeConfig, "kubeconfig", "", "The path to a KUBECONFIG file, overrides $KUBECONFIG.")
 	f.StringVar(&globalKubeContext, "kube-context", "", "The name of the kubeconfig context to use.")
 	f.BoolVarP(&globalVerbose, "verbose", "v", false, "Turn on verbose output")
+	f.DurationVar(&globalTimeout, "timeout", 0, "The maximum time a command may take talking to the cluster, 0 means no limit.")
 }
 
 // Root is the root command
@@ -58,6 +62,15 @@ var Root = &cobra.Command{
 	SilenceUsage: true,
 }
 
+// commandContext returns the context for the requests of a command, bounded
+// by --timeout.
+func commandContext() (context.Context, context.CancelFunc) {
+	if globalTimeout > 0 {
+		return context.WithTimeout(context.Background(), globalTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
 // kubeClient returns a Kubernetes clientset.
 func kubeClient() (*kubernetes.Clientset, error) {
 	cfg, err := getKubeConfig()
@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/brigadecore/brigade/pkg/artifacts"
+)
+
+const buildArtifactsUsage = `Manage the artifacts of a build.
+
+Artifacts are the files the jobs of a build upload to the Brigade API server,
+which keeps them until the build is vacuumed. Brig reads them from the API
+server through the Kubernetes API, from the service labelled 'role=api' unless
+--api-service is given.
+`
+
+const buildArtifactsListUsage = `List the artifacts of a build.
+`
+
+const buildArtifactsGetUsage = `Download an artifact of a build.
+
+The artifact is written to the standard output, unless --file is given.
+`
+
+var (
+	apiServiceName string
+	artifactFile   string
+)
+
+func init() {
+	build.AddCommand(buildArtifacts)
+	buildArtifacts.AddCommand(buildArtifactsList, buildArtifactsGet)
+	buildArtifacts.PersistentFlags().StringVar(&apiServiceName, "api-service", "", "name of the service of the Brigade API server")
+	buildArtifactsList.Flags().StringVarP(&output, "output", "o", "", "Return output in another format. Supported formats: json")
+	buildArtifactsGet.Flags().StringVarP(&artifactFile, "file", "f", "", "write the artifact to this file")
+}
+
+var buildArtifacts = &cobra.Command{
+	Use:   "artifacts",
+	Short: "manage the artifacts of a build",
+	Long:  buildArtifactsUsage,
+}
+
+var buildArtifactsList = &cobra.Command{
+	Use:   "list BUILD_ID",
+	Short: "list the artifacts of a build",
+	Long:  buildArtifactsListUsage,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("build ID is a required argument")
+		}
+		return listBuildArtifacts(cmd.OutOrStdout(), args[0])
+	},
+}
+
+var buildArtifactsGet = &cobra.Command{
+	Use:   "get BUILD_ID PATH",
+	Short: "download an artifact of a build",
+	Long:  buildArtifactsGetUsage,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("build ID and artifact path are required arguments")
+		}
+		return getBuildArtifact(cmd.OutOrStdout(), args[0], args[1])
+	},
+}
+
+func listBuildArtifacts(out io.Writer, bid string) error {
+	ctx, cancel := commandContext()
+	defer cancel()
+	r, err := apiGet(ctx, "/v1/build/"+url.PathEscape(bid)+"/artifacts")
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	list := []*artifacts.Artifact{}
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return fmt.Errorf("could not read the artifacts of build %s: %s", bid, err)
+	}
+
+	if output == "json" {
+		b, err := json.MarshalIndent(list, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	}
+	printArtifacts(out, list)
+	return nil
+}
+
+func getBuildArtifact(out io.Writer, bid, path string) error {
+	path, err := artifacts.CleanPath(path)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	r, err := apiGet(ctx, "/v1/build/"+url.PathEscape(bid)+"/artifacts/"+strings.Join(segments, "/"))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if artifactFile != "" {
+		f, err := os.Create(artifactFile)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	_, err = io.Copy(out, r)
+	return err
+}
+
+// printArtifacts prints the artifacts of a build as a table.
+func printArtifacts(out io.Writer, list []*artifacts.Artifact) {
+	table := uitable.New()
+	table.AddRow("PATH", "SIZE", "TYPE", "CREATED", "SHA256")
+	for _, a := range list {
+		table.AddRow(a.Path, strconv.FormatInt(a.Size, 10), a.ContentType, a.Created.Format(time.RFC3339), a.SHA256)
+	}
+	fmt.Fprintln(out, table)
+}
+
+// apiGet gets a path of the Brigade API server through the service proxy of
+// the Kubernetes API.
+func apiGet(ctx context.Context, path string) (io.ReadCloser, error) {
+	c, err := kubeClient()
+	if err != nil {
+		return nil, err
+	}
+	name, port, err := apiService(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return c.CoreV1().Services(globalNamespace).ProxyGet("http", name, port, path, nil).Stream(ctx)
+}
+
+// apiService returns the name and the port of the service of the Brigade API
+// server.
+func apiService(ctx context.Context, c kubernetes.Interface) (string, string, error) {
+	opts := meta.ListOptions{LabelSelector: "role=api"}
+	if apiServiceName != "" {
+		opts = meta.ListOptions{FieldSelector: "metadata.name=" + apiServiceName}
+	}
+	services, err := c.CoreV1().Services(globalNamespace).List(ctx, opts)
+	if err != nil {
+		return "", "", err
+	}
+	for _, s := range services.Items {
+		if len(s.Spec.Ports) > 0 {
+			return s.Name, strconv.Itoa(int(s.Spec.Ports[0].Port)), nil
+		}
+	}
+	return "", "", fmt.Errorf("could not find the service of the Brigade API server in namespace %s, set it with --api-service", globalNamespace)
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/artifacts"
+)
+
+func TestPrintArtifacts(t *testing.T) {
+	created := time.Date(2020, 6, 1, 15, 4, 5, 0, time.UTC)
+	var out bytes.Buffer
+	printArtifacts(&out, []*artifacts.Artifact{
+		{BuildID: stubBuild1ID, Path: "reports/report.html", Size: 1024, ContentType: "text/html", SHA256: "2cf24dba", Created: created},
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a header and a row, got:\n%s", out.String())
+	}
+	for _, expect := range []string{"reports/report.html", "1024", "text/html", "2020-06-01T15:04:05Z", "2cf24dba"} {
+		if !strings.Contains(lines[1], expect) {
+			t.Errorf("expected %q in %q", expect, lines[1])
+		}
+	}
+}
+
+func TestAPIService(t *testing.T) {
+	c := fake.NewSimpleClientset(&v1.Service{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-server-brigade-api", Namespace: globalNamespace, Labels: map[string]string{"role": "api"}},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 7745}}},
+	})
+	name, port, err := apiService(context.TODO(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "brigade-server-brigade-api" || port != "7745" {
+		t.Errorf("unexpected service %s:%s", name, port)
+	}
+	if _, _, err := apiService(context.TODO(), fake.NewSimpleClientset()); err == nil {
+		t.Error("expected an error without an API service")
+	}
+}
@@ -39,7 +39,9 @@ func deleteBuild(out io.Writer, bid string) error {
 	}
 
 	store := kube.New(c, globalNamespace)
-	return store.DeleteBuild(bid, storage.DeleteBuildOptions{
+	ctx, cancel := commandContext()
+	defer cancel()
+	return store.DeleteBuild(ctx, bid, storage.DeleteBuildOptions{
 		SkipRunningBuilds: !forceDeleteRunning,
 	})
 }
@@ -39,20 +39,22 @@ func deleteAllBuilds(out io.Writer, projectID string) error {
 	}
 
 	store := kube.New(c, globalNamespace)
+	ctx, cancel := commandContext()
+	defer cancel()
 
-	proj, err := store.GetProject(projectID)
+	proj, err := store.GetProject(ctx, projectID)
 	if err != nil {
 		return err
 	}
 
-	builds, err := store.GetProjectBuilds(proj)
+	builds, err := store.GetProjectBuilds(ctx, proj)
 	if err != nil {
 		return err
 	}
 
 	var errDeletes error
 	for _, b := range builds {
-		err = store.DeleteBuild(b.ID, storage.DeleteBuildOptions{
+		err = store.DeleteBuild(ctx, b.ID, storage.DeleteBuildOptions{
 			SkipRunningBuilds: !forceDeleteRunningAll,
 		})
 		// loop will continue even if an error is encountered
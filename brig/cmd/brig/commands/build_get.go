@@ -1,25 +1,38 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"time"
 
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/duration"
 
+	"github.com/brigadecore/brigade/pkg/brigade"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
+	"github.com/brigadecore/brigade/pkg/testresults"
 )
 
 const buildGetUsage = `Get details for a build.
 
 Print the attributes of a build.
+
+With --tests, the test results of the build are read from the API server and
+printed as well, which requires the API server to keep artifacts.
 `
 
+var getTests bool
+
 func init() {
 	build.AddCommand(buildGet)
 	buildGet.Flags().StringVarP(&output, "output", "o", "", "Return output in another format. Supported formats: json")
+	buildGet.Flags().BoolVar(&getTests, "tests", false, "Print the test results of the build, read from the API server")
 }
 
 var buildGet = &cobra.Command{
@@ -41,7 +54,9 @@ func getBuild(out io.Writer, bid string) error {
 	}
 
 	store := kube.New(c, globalNamespace)
-	b, err := store.GetBuild(bid)
+	ctx, cancel := commandContext()
+	defer cancel()
+	b, err := store.GetBuild(ctx, bid)
 	if err != nil {
 		return err
 	}
@@ -72,5 +87,71 @@ func getBuild(out io.Writer, bid string) error {
 		fmt.Fprintf(out, "script: |-\n%s\npayload: |- %s\n", script, payload)
 	}
 
+	// The test results are only available through the API server, when it
+	// keeps artifacts.
+	if getTests {
+		report, err := getTestReport(ctx, bid)
+		if err != nil {
+			return fmt.Errorf("could not get the test results of build %s: %s", bid, err)
+		}
+		printTestReport(out, report)
+	}
+
+	events, err := store.GetBuildEvents(ctx, bid)
+	if err != nil {
+		return err
+	}
+	printBuildEvents(out, events)
+
 	return nil
 }
+
+// getTestReport gets the test results of a build from the API server.
+func getTestReport(ctx context.Context, bid string) (*testresults.Report, error) {
+	r, err := apiGet(ctx, "/v1/build/"+url.PathEscape(bid)+"/tests")
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	report := &testresults.Report{}
+	return report, json.NewDecoder(r).Decode(report)
+}
+
+// printTestReport prints the counts and the failures of the test results of a
+// build.
+func printTestReport(out io.Writer, report *testresults.Report) {
+	fmt.Fprintf(out, "Tests: %d passed, %d failed, %d skipped\n", report.Passed, report.Failed, report.Skipped)
+	if report.Failed == 0 {
+		fmt.Fprintln(out)
+		return
+	}
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.AddRow("JOB", "SUITE", "TEST", "MESSAGE")
+	for _, j := range report.Jobs {
+		for _, c := range j.Failures {
+			table.AddRow(j.Job, c.Suite, c.Name, c.Message)
+		}
+	}
+	fmt.Fprintln(out, table)
+	fmt.Fprintln(out)
+}
+
+// printBuildEvents prints the events of a build like `kubectl describe` does.
+func printBuildEvents(out io.Writer, events []*brigade.BuildEvent) {
+	if len(events) == 0 {
+		fmt.Fprintln(out, "Events: <none>")
+		return
+	}
+	table := uitable.New()
+	table.AddRow("TYPE", "REASON", "AGE", "OBJECT", "MESSAGE")
+	for _, e := range events {
+		age := duration.ShortHumanDuration(time.Since(e.LastTime))
+		if e.Count > 1 {
+			age = fmt.Sprintf("%s (x%d over %s)", age, e.Count, duration.ShortHumanDuration(time.Since(e.FirstTime)))
+		}
+		table.AddRow(e.Type, e.Reason, age, e.Object, e.Message)
+	}
+	fmt.Fprintln(out, "Events:")
+	fmt.Fprintln(out, table)
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/testresults"
+)
+
+func TestPrintBuildEvents(t *testing.T) {
+	out := new(bytes.Buffer)
+	printBuildEvents(out, nil)
+	if got := out.String(); got != "Events: <none>\n" {
+		t.Errorf("unexpected output for no events: %q", got)
+	}
+
+	out.Reset()
+	now := time.Now()
+	printBuildEvents(out, []*brigade.BuildEvent{
+		{
+			Type:      "Warning",
+			Reason:    "WorkerCreateFailed",
+			Message:   "Error loading project brigade-123: not found",
+			Object:    "Secret/brigade-worker-01cxmy71nbq7nasvth8pva1s21",
+			Count:     3,
+			FirstTime: now.Add(-2 * time.Minute),
+			LastTime:  now.Add(-time.Minute),
+		},
+	})
+	got := out.String()
+	for _, want := range []string{"Events:", "REASON", "WorkerCreateFailed", "(x3 over 2m)", "Error loading project brigade-123: not found"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrintTestReport(t *testing.T) {
+	out := new(bytes.Buffer)
+	printTestReport(out, &testresults.Report{
+		Counts: testresults.Counts{Total: 3, Passed: 1, Failed: 1, Skipped: 1},
+		Jobs: []testresults.JobReport{{
+			Job:      "unit",
+			Failures: []testresults.Case{{Suite: "example.com/pequod", Name: "TestHunt", Status: testresults.StatusFailed, Message: "the whale got away"}},
+		}},
+	})
+	got := out.String()
+	for _, want := range []string{"Tests: 1 passed, 1 failed, 1 skipped", "MESSAGE", "unit", "TestHunt", "the whale got away"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
@@ -82,17 +82,19 @@ func getBuilds(project string, client kubernetes.Interface, count int) ([]*briga
 	var err error
 
 	store := kube.New(client, globalNamespace)
+	ctx, cancel := commandContext()
+	defer cancel()
 	if project == "" {
-		builds, err = store.GetBuilds()
+		builds, err = store.GetBuilds(ctx)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		proj, err := store.GetProject(project)
+		proj, err := store.GetProject(ctx, project)
 		if err != nil {
 			return nil, err
 		}
-		builds, err = store.GetProjectBuilds(proj)
+		builds, err = store.GetProjectBuilds(ctx, proj)
 		if err != nil {
 			return nil, err
 		}
@@ -1,13 +1,19 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/decolorizer"
+	"github.com/brigadecore/brigade/pkg/script"
 	"github.com/brigadecore/brigade/pkg/storage"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
 )
@@ -18,6 +24,23 @@ const (
 
 Print the logs for a build, and (optionally) the jobs executed as part of the
 build.
+
+The log can be narrowed down on the server, so that only the selected lines
+are downloaded: --tail keeps the last lines, --since and --since-time keep the
+lines logged after a time, and --grep keeps the lines matching a regular
+expression. --grep applies to the lines selected by the other flags.
+
+	$ brig build logs --jobs --since 10m --grep 'error|panic' BUILD_ID
+
+With --all, the logs of the worker and of the jobs are combined in the order
+their lines were logged, every line prefixed with the name of its job.
+
+With --follow, the log of the worker is streamed until the build completes,
+waiting for the worker if the build is still queued, and brig exits with the
+exit code of the worker if the build failed. Adding --jobs or --all streams the
+logs of the jobs too as they start.
+
+	$ brig build logs --follow --all BUILD_ID
 `
 )
 
@@ -25,12 +48,34 @@ var (
 	logsInit bool
 	logsJobs bool
 	logsLast bool
+
+	logsAll        bool
+	logsFollow     bool
+	logsNoColor    bool
+	logsNoProgress bool
+
+	logsTail       int64
+	logsSince      time.Duration
+	logsSinceTime  string
+	logsTimestamps bool
+	logsContainer  string
+	logsGrep       string
 )
 
 func init() {
 	buildLogs.Flags().BoolVarP(&logsInit, "init", "i", false, "Show init container logs as well as the worker log")
 	buildLogs.Flags().BoolVarP(&logsJobs, "jobs", "j", false, "Show job logs as well as the worker log")
 	buildLogs.Flags().BoolVarP(&logsLast, "last", "l", false, "Show last build's log (ignores BUILD_ID)")
+	buildLogs.Flags().BoolVarP(&logsAll, "all", "a", false, "Combine the logs of the worker and of the jobs, every line prefixed with its job")
+	buildLogs.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow the logs until the build completes")
+	buildLogs.Flags().BoolVar(&logsNoColor, "no-color", false, "Remove color codes from log output")
+	buildLogs.Flags().BoolVar(&logsNoProgress, "no-progress", false, "Disable progress meter when following")
+	buildLogs.Flags().Int64Var(&logsTail, "tail", 0, "Only show the last lines of each log")
+	buildLogs.Flags().DurationVar(&logsSince, "since", 0, "Only show the lines logged in the last duration, like 5s, 2m or 3h")
+	buildLogs.Flags().StringVar(&logsSinceTime, "since-time", "", "Only show the lines logged after an RFC3339 time")
+	buildLogs.Flags().BoolVar(&logsTimestamps, "timestamps", false, "Prefix every line with the time it was logged at")
+	buildLogs.Flags().StringVar(&logsContainer, "container", "", "Show the log of this container of the worker")
+	buildLogs.Flags().StringVar(&logsGrep, "grep", "", "Only show the lines matching a regular expression")
 	build.AddCommand(buildLogs)
 }
 
@@ -39,61 +84,172 @@ var buildLogs = &cobra.Command{
 	Short: "show build logs",
 	Long:  buildLogsUsage,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if logsLast {
-			return showBuildLogs(cmd.OutOrStdout(), "")
+		buildID := ""
+		if !logsLast {
+			if len(args) == 0 {
+				return errors.New("either BUILD_ID or --last is required")
+			}
+			buildID = args[0]
 		}
-		if len(args) == 0 {
-			return errors.New("either BUILD_ID or --last is required")
+
+		err := showBuildLogs(cmd.OutOrStdout(), buildID)
+
+		// If err is a BuildFailure, then we don't want Cobra to print the Usage
+		// instructions on failure, since it's a pipeline issue and not a CLI issue.
+		if f, ok := err.(script.BuildFailure); ok {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return BrigError{Code: buildFailureCode(f), cause: err}
 		}
-		return showBuildLogs(cmd.OutOrStdout(), args[0])
+		return err
 	},
 }
 
 func showBuildLogs(out io.Writer, buildID string) error {
+	opts, err := buildLogOptions()
+	if err != nil {
+		return err
+	}
+
+	if logsNoColor {
+		// Pipe the data through a Writer that strips the color codes and then
+		// sends the resulting data to the underlying writer.
+		out = decolorizer.New(out)
+	}
+
 	c, err := kubeClient()
 	if err != nil {
 		return err
 	}
 
 	store := kube.New(c, globalNamespace)
+	ctx, cancel := commandContext()
+	defer cancel()
 	if buildID == "" {
-		buildID, err = lastBuildID(store)
+		buildID, err = lastBuildID(ctx, store)
 		if err != nil {
 			return err
 		}
 	}
 
-	bs, err := store.GetBuild(buildID)
+	if logsFollow {
+		return followBuildLogs(ctx, c, out, buildID, opts)
+	}
+
+	bs, err := store.GetBuild(ctx, buildID)
 	if err != nil {
 		return err
 	}
 
+	if logsAll {
+		return showAllLogs(ctx, out, bs, store, opts)
+	}
+
 	if bs.Worker == nil {
 		return fmt.Errorf("No logs for build %q", buildID)
 	}
 
-	workerLog, err := store.GetWorkerLog(bs.Worker)
+	workerLog, err := store.GetWorkerLogStreamWithOptions(ctx, bs.Worker, opts)
 	if err != nil {
 		return err
 	}
+	defer workerLog.Close()
 
 	fmt.Fprintf(out, logHeader, bs.Worker.ID)
 	if logsInit {
-		initLogs, err := store.GetWorkerInitLog(bs.Worker)
+		initOpts := opts
+		initOpts.Container = "vcs-sidecar"
+		initLogs, err := store.GetWorkerLogStreamWithOptions(ctx, bs.Worker, initOpts)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, initLogs)
+		initLogs.Close()
 		if err != nil {
 			return err
 		}
-		fmt.Fprint(out, initLogs)
 	}
-	fmt.Fprint(out, workerLog)
+	if _, err := io.Copy(out, workerLog); err != nil {
+		return err
+	}
 	if logsJobs {
-		return showJobLogs(out, bs, store)
+		// The container only selects a container of the worker.
+		opts.Container = ""
+		return showJobLogs(ctx, out, bs, store, opts)
 	}
 	return nil
 }
 
-func lastBuildID(store storage.Store) (string, error) {
-	builds, err := store.GetBuilds()
+// followBuildLogs streams the logs of a build until it completes, with the
+// logs of its jobs if --jobs or --all is given.
+func followBuildLogs(ctx context.Context, c kubernetes.Interface, out io.Writer, buildID string, opts storage.LogOptions) error {
+	runner, err := script.NewDelegatedRunner(c, globalNamespace)
+	if err != nil {
+		return err
+	}
+	runner.ScriptLogDestination = out
+	runner.RunnerLogDestination = out
+	runner.NoProgress = logsNoProgress
+	runner.Verbose = globalVerbose
+
+	return runner.FollowBuild(ctx, buildID, logsJobs || logsAll, storage.BuildLogOptions{
+		LogOptions: opts,
+		Color:      !logsNoColor,
+	})
+}
+
+// buildFailureCode returns the exit code of brig for a failed build: the exit
+// code of the worker, or 2 like brig run if it is unknown.
+func buildFailureCode(f script.BuildFailure) int {
+	if f.ExitCode != 0 {
+		return int(f.ExitCode)
+	}
+	return 2
+}
+
+// showAllLogs prints the combined logs of the worker and of the jobs of a
+// build.
+func showAllLogs(ctx context.Context, out io.Writer, build *brigade.Build, store storage.ContextStore, opts storage.LogOptions) error {
+	logs, err := storage.BuildLogs(ctx, store, build, storage.BuildLogOptions{
+		LogOptions: opts,
+		Color:      !logsNoColor,
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+	_, err = io.Copy(out, logs)
+	return err
+}
+
+// buildLogOptions returns the log options selected by the flags.
+func buildLogOptions() (storage.LogOptions, error) {
+	opts := storage.LogOptions{
+		Container:    logsContainer,
+		TailLines:    logsTail,
+		SinceSeconds: int64(math.Ceil(logsSince.Seconds())),
+		Timestamps:   logsTimestamps,
+		Follow:       logsFollow,
+		Grep:         logsGrep,
+	}
+	if logsAll && (logsContainer != "" || logsInit || logsJobs) {
+		return opts, errors.New("--all combines the logs of the worker and of the jobs, it cannot be used with --container, --init or --jobs")
+	}
+	if logsFollow && logsInit {
+		return opts, errors.New("--init cannot be used with --follow, the init container has completed once the worker runs")
+	}
+	if logsSinceTime != "" {
+		t, err := time.Parse(time.RFC3339, logsSinceTime)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --since-time, expected an RFC3339 time like 2020-06-01T15:04:05Z: %s", err)
+		}
+		opts.SinceTime = t
+	}
+	return opts, opts.Validate()
+}
+
+func lastBuildID(ctx context.Context, store storage.ContextStore) (string, error) {
+	builds, err := store.GetBuilds(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -105,26 +261,30 @@ func lastBuildID(store storage.Store) (string, error) {
 	return builds[len(builds)-1].ID, nil
 }
 
-func showJobLogs(out io.Writer, build *brigade.Build, store storage.Store) error {
+func showJobLogs(ctx context.Context, out io.Writer, build *brigade.Build, store storage.ContextStore, opts storage.LogOptions) error {
 	// This indicates that there were no jobs because the worker never
 	// spawned.
 	if build.Worker == nil {
 		return fmt.Errorf("Build %s has no worker", build.ID)
 	}
 
-	jobs, err := store.GetBuildJobs(build)
+	jobs, err := store.GetBuildJobs(ctx, build)
 	if err != nil {
 		return err
 	}
 
 	for _, j := range jobs {
 		fmt.Fprintf(out, logHeader, j.ID)
-		log, err := store.GetJobLog(j)
+		log, err := store.GetJobLogStreamWithOptions(ctx, j, opts)
 		if err != nil {
 			fmt.Fprintf(out, "log not found: %s", err)
 			continue
 		}
-		fmt.Fprint(out, log)
+		_, err = io.Copy(out, log)
+		log.Close()
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
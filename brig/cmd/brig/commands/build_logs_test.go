@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/script"
+)
+
+func TestBuildLogOptions(t *testing.T) {
+	defer func() {
+		logsTail, logsSince, logsSinceTime, logsGrep = 0, 0, "", ""
+		logsAll, logsJobs, logsFollow, logsInit = false, false, false, false
+	}()
+
+	logsTail, logsSince, logsGrep = 20, 90*time.Second+time.Millisecond, "error"
+	opts, err := buildLogOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.TailLines != 20 || opts.SinceSeconds != 91 || opts.Grep != "error" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	logsSince, logsSinceTime = 0, "2020-06-01T15:04:05Z"
+	opts, err = buildLogOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.SinceTime.Equal(time.Date(2020, 6, 1, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected since time %s", opts.SinceTime)
+	}
+
+	logsSinceTime = "yesterday"
+	if _, err := buildLogOptions(); err == nil {
+		t.Error("expected an error for an invalid --since-time")
+	}
+
+	logsSinceTime, logsAll, logsJobs = "", true, true
+	if _, err := buildLogOptions(); err == nil {
+		t.Error("expected an error for --all with --jobs")
+	}
+
+	logsAll, logsJobs, logsFollow, logsInit = false, false, true, true
+	if _, err := buildLogOptions(); err == nil {
+		t.Error("expected an error for --follow with --init")
+	}
+}
+
+func TestBuildFailureCode(t *testing.T) {
+	f := script.NewBuildFailure("build failed")
+	if code := buildFailureCode(f); code != 2 {
+		t.Errorf("expected exit code 2 for an unknown worker exit code, got %d", code)
+	}
+	f.ExitCode = 3
+	if code := buildFailureCode(f); code != 3 {
+		t.Errorf("expected the exit code of the worker, got %d", code)
+	}
+}
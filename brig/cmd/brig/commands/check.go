@@ -19,6 +19,8 @@ const (
 	checkUsage = `Checks the status of your Brigade installation
 
 Specifically, it reports Desired/Current/Running/Up-to-date/Available/Unavailable Pods for the Controller, API Server and Kashti deployments.
+
+It also checks the vacuum, which runs either as a CronJob or as a daemon Deployment.
 `
 )
 
@@ -55,6 +57,7 @@ func checkBrigadeSystem() error {
 	apiDeploymentFound := false
 	controllerDeploymentFound := false
 	kashtiDeploymentFound := false
+	vacuumDeploymentFound := false
 
 	for _, deployment := range deployList.Items {
 		if lbl := deployment.Labels["app.kubernetes.io/name"]; lbl != "" {
@@ -64,13 +67,16 @@ func checkBrigadeSystem() error {
 			} else if strings.HasSuffix(lbl, "-brigade-ctrl") && deployment.Labels["role"] == "controller" {
 				controllerDeploymentFound = true
 				reportDeployStatus(deployment, "Brigade Controller")
+			} else if strings.HasSuffix(lbl, "-brigade-vacuum") && deployment.Labels["role"] == "vacuum" {
+				vacuumDeploymentFound = true
+				reportDeployStatus(deployment, "Brigade Vacuum")
 			}
 		} else if lblApp := deployment.Labels["app"]; lblApp == "kashti" {
 			kashtiDeploymentFound = true
 			reportDeployStatus(deployment, "Kashti")
 		}
 
-		if apiDeploymentFound && controllerDeploymentFound && kashtiDeploymentFound {
+		if apiDeploymentFound && controllerDeploymentFound && kashtiDeploymentFound && vacuumDeploymentFound {
 			break // we're not interested in checking other Deployments
 		}
 	}
@@ -95,7 +101,9 @@ func checkBrigadeSystem() error {
 		if lbl := cronjob.Labels["app.kubernetes.io/name"]; lbl != "" {
 			if strings.HasSuffix(lbl, "-brigade") && cronjob.Labels["role"] == "vacuum" {
 				vacuumCronJobFound = true
-				if *cronjob.Spec.Suspend {
+				if *cronjob.Spec.Suspend && vacuumDeploymentFound {
+					fmt.Println("Info: Vacuum CronJob is suspended, the vacuum runs as a daemon")
+				} else if *cronjob.Spec.Suspend {
 					fmt.Println("Warning: Vacuum CronJob is suspended")
 				} else if cronjob.Spec.Schedule == "" {
 					fmt.Println("Warning: Vacuum has an empty schedule")
@@ -109,6 +117,9 @@ func checkBrigadeSystem() error {
 			break // we're not interested in checking other CronJobs
 		}
 	}
+	if !vacuumCronJobFound && !vacuumDeploymentFound {
+		fmt.Println("Warning: Vacuum not found, old builds are never deleted")
+	}
 
 	return nil
 }
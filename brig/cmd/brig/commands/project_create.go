@@ -11,6 +11,7 @@ import (
 	"gopkg.in/AlecAivazis/survey.v1"
 
 	"github.com/Masterminds/goutils"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
 	"github.com/brigadecore/brigade/pkg/storage"
@@ -108,6 +109,8 @@ func createProject(out io.Writer) error {
 	}
 
 	store := kube.New(c, globalNamespace)
+	ctx, cancel := commandContext()
+	defer cancel()
 
 	// set the default to VCS
 	proj := newProjectVCS()
@@ -119,7 +122,7 @@ func createProject(out io.Writer) error {
 
 	if projectCreateFromProject != "" {
 		var err error
-		proj, err = store.GetProject(projectCreateFromProject)
+		proj, err = store.GetProject(ctx, projectCreateFromProject)
 		if err != nil {
 			return fmt.Errorf("could not load %s: %s", projectCreateFromProject, err)
 		}
@@ -137,6 +140,10 @@ func createProject(out io.Writer) error {
 		proj.Kubernetes.VCSSidecar = ""
 	}
 
+	if err := proj.Validate(); err != nil {
+		return fmt.Errorf(abort, err)
+	}
+
 	secret, err := kube.SecretFromProject(proj)
 	if err != nil {
 		return err
@@ -163,21 +170,21 @@ func createProject(out io.Writer) error {
 
 	// brig project create --replace
 	if projectCreateReplace {
-		if _, err = store.GetProject(proj.ID); err != nil {
+		if _, err = store.GetProject(ctx, proj.ID); err != nil {
 			return fmt.Errorf("project %s could not be found (error: %s). Cannot replace, exiting", proj.Name, err.Error())
 		}
-		return store.ReplaceProject(proj)
+		return store.ReplaceProject(ctx, proj)
 	}
 
 	// brig project create # no replace
-	if _, err = store.GetProject(proj.ID); err == nil {
+	if _, err = store.GetProject(ctx, proj.ID); err == nil {
 		return fmt.Errorf("project %s already exists. Refusing to overwrite", proj.Name)
 	}
 	// Store the project
-	return store.CreateProject(proj)
+	return store.CreateProject(ctx, proj)
 }
 
-func projectCreateVCSOrNoVCS(p *brigade.Project, store storage.Store) error {
+func projectCreateVCSOrNoVCS(p *brigade.Project, store storage.ContextStore) error {
 	var vcsQuestionResult string
 	err := survey.Ask([]*survey.Question{
 		{
@@ -202,7 +209,7 @@ func projectCreateVCSOrNoVCS(p *brigade.Project, store storage.Store) error {
 
 }
 
-func addEditSecrets(p *brigade.Project, store storage.Store) error {
+func addEditSecrets(p *brigade.Project, store storage.ContextStore) error {
 	if len(p.Secrets) > 0 {
 		fmt.Println("The following secrets are already defined:")
 		for k := range p.Secrets {
@@ -253,7 +260,7 @@ func addEditSecrets(p *brigade.Project, store storage.Store) error {
 	return nil
 }
 
-func addGenericGatewaySecret(p *brigade.Project, store storage.Store) error {
+func addGenericGatewaySecret(p *brigade.Project, store storage.ContextStore) error {
 	err := survey.AskOne(&survey.Input{
 		Message: "Secret for the Generic Gateway (alphanumeric characters only). Press Enter if you want it to be auto-generated",
 		Help:    "This is the secret that secures the Generic Gateway. Only alphanumeric characters are accepted. Provide an empty string if you want an auto-generated one",
@@ -275,7 +282,7 @@ func addGenericGatewaySecret(p *brigade.Project, store storage.Store) error {
 }
 
 // advancedQuestionsKubernetes asks Kubernetes related questions
-func advancedQuestionsKubernetes(p *brigade.Project, store storage.Store) ([]*survey.Question, error) {
+func advancedQuestionsKubernetes(p *brigade.Project, store storage.ContextStore) ([]*survey.Question, error) {
 	questions := []*survey.Question{
 		{
 			Name: "buildStorageSize",
@@ -304,7 +311,9 @@ func advancedQuestionsKubernetes(p *brigade.Project, store storage.Store) ([]*su
 	}
 
 	// get the StorageClass.Name for the storage classes in the cluster
-	scn, err := store.GetStorageClassNames()
+	ctx, cancel := commandContext()
+	defer cancel()
+	scn, err := store.GetStorageClassNames(ctx)
 	if err != nil {
 		// this error indicates a cluster communication problem, so exit now since project creation will probably fail as well
 		return nil, err
@@ -352,7 +361,7 @@ func advancedQuestionsKubernetes(p *brigade.Project, store storage.Store) ([]*su
 }
 
 // advancedQuestionsWorker asks Worker related questions
-func advancedQuestionsWorker(p *brigade.Project, store storage.Store) []*survey.Question {
+func advancedQuestionsWorker(p *brigade.Project, store storage.ContextStore) []*survey.Question {
 	return []*survey.Question{
 		{
 			Name: "registry",
@@ -391,11 +400,47 @@ func advancedQuestionsWorker(p *brigade.Project, store storage.Store) []*survey.
 				Default: p.Worker.PullPolicy,
 			},
 		},
+		{
+			Name: "requestsCPU",
+			Prompt: &survey.Input{
+				Message: "Worker CPU requests",
+				Help:    "The amount of CPU requested for the worker, e.g. 500m. If not given the controller's default will be used",
+				Default: p.Worker.RequestsCPU,
+			},
+			Validate: quantityValidator,
+		},
+		{
+			Name: "requestsMemory",
+			Prompt: &survey.Input{
+				Message: "Worker memory requests",
+				Help:    "The amount of memory requested for the worker, e.g. 256Mi. If not given the controller's default will be used",
+				Default: p.Worker.RequestsMemory,
+			},
+			Validate: quantityValidator,
+		},
+		{
+			Name: "limitsCPU",
+			Prompt: &survey.Input{
+				Message: "Worker CPU limits",
+				Help:    "The maximum amount of CPU the worker may use, e.g. 1. If not given the controller's default will be used",
+				Default: p.Worker.LimitsCPU,
+			},
+			Validate: quantityValidator,
+		},
+		{
+			Name: "limitsMemory",
+			Prompt: &survey.Input{
+				Message: "Worker memory limits",
+				Help:    "The maximum amount of memory the worker may use, e.g. 1Gi. If not given the controller's default will be used",
+				Default: p.Worker.LimitsMemory,
+			},
+			Validate: quantityValidator,
+		},
 	}
 }
 
 // advancedQuestionsProject asks Project related questions
-func advancedQuestionsProject(p *brigade.Project, store storage.Store) []*survey.Question {
+func advancedQuestionsProject(p *brigade.Project, store storage.ContextStore) []*survey.Question {
 	return []*survey.Question{
 		{
 			Name: "workerCommand",
@@ -432,7 +477,7 @@ func advancedQuestionsProject(p *brigade.Project, store storage.Store) []*survey
 	}
 }
 
-func addBrigadeJS(p *brigade.Project, store storage.Store) error {
+func addBrigadeJS(p *brigade.Project, store storage.ContextStore) error {
 	err := survey.AskOne(&survey.Input{
 		Message: "Default script ConfigMap name",
 		Help:    "It is possible to store a default script in a ConfigMap. Supply the name of that ConfigMap to use the script.",
@@ -457,7 +502,7 @@ func addBrigadeJS(p *brigade.Project, store storage.Store) error {
 	return nil
 }
 
-func addBrigadeConfig(p *brigade.Project, store storage.Store) error {
+func addBrigadeConfig(p *brigade.Project, store storage.ContextStore) error {
 	err := survey.AskOne(&survey.Input{
 		Message: "Default brigade.json config ConfigMap name",
 		Help:    "It is possible to store a default brigade.json config in a ConfigMap. Supply the name of that ConfigMap to use the config.",
@@ -482,7 +527,7 @@ func addBrigadeConfig(p *brigade.Project, store storage.Store) error {
 	return nil
 }
 
-func setProjectName(p *brigade.Project, store storage.Store, configureVCS bool) error {
+func setProjectName(p *brigade.Project, store storage.ContextStore, configureVCS bool) error {
 	// We always set this to the globalNamespace, otherwise things will break.
 	p.Kubernetes.Namespace = globalNamespace
 
@@ -525,3 +570,14 @@ func genericGatewaySecretValidator(val interface{}) error {
 	}
 	return fmt.Errorf("Generic Gateway secret should only contain alphanumeric characters")
 }
+
+// quantityValidator validates that the provided value is either "" or a Kubernetes resource quantity
+func quantityValidator(val interface{}) error {
+	if val.(string) == "" {
+		return nil
+	}
+	if _, err := resource.ParseQuantity(val.(string)); err != nil {
+		return fmt.Errorf("%q is not a valid quantity, e.g. 500m, 1, 256Mi or 1Gi", val)
+	}
+	return nil
+}
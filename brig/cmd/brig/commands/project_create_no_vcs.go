@@ -13,7 +13,7 @@ import (
 //
 // Default values are read from the given project. Values are then
 // replaced on that object.
-func projectCreatePromptsNoVCS(p *brigade.Project, store storage.Store) error {
+func projectCreatePromptsNoVCS(p *brigade.Project, store storage.ContextStore) error {
 
 	setDefaultValuesNoVCS(p)
 
@@ -64,7 +64,7 @@ func projectCreatePromptsNoVCS(p *brigade.Project, store storage.Store) error {
 	return nil
 }
 
-func projectAdvancedPromptsNoVCS(p *brigade.Project, store storage.Store) error {
+func projectAdvancedPromptsNoVCS(p *brigade.Project, store storage.ContextStore) error {
 	questionsKubernetes, err := advancedQuestionsKubernetes(p, store)
 	if err != nil {
 		return fmt.Errorf(abort, err)
@@ -140,3 +140,16 @@ func TestGenericGatewaySecretValidator(t *testing.T) {
 		t.Fatal("Expected error, got nil")
 	}
 }
+
+func TestQuantityValidator(t *testing.T) {
+	for _, q := range []string{"", "500m", "1", "256Mi", "1Gi"} {
+		if err := quantityValidator(q); err != nil {
+			t.Errorf("Expected nil for %q, got error: %s", q, err)
+		}
+	}
+	for _, q := range []string{"lots", "1 Gi", "1Gb"} {
+		if err := quantityValidator(q); err == nil {
+			t.Errorf("Expected error for %q, got nil", q)
+		}
+	}
+}
@@ -24,7 +24,7 @@ import (
 //
 // Default values are read from the given project. Values are then
 // replaced on that object.
-func projectCreatePromptsVCS(p *brigade.Project, store storage.Store) error {
+func projectCreatePromptsVCS(p *brigade.Project, store storage.ContextStore) error {
 
 	err := setProjectName(p, store, true)
 	if err != nil {
@@ -179,7 +179,7 @@ func projectCreatePromptsVCS(p *brigade.Project, store storage.Store) error {
 	return nil
 }
 
-func projectAdvancedPromptsVCS(p *brigade.Project, store storage.Store) error {
+func projectAdvancedPromptsVCS(p *brigade.Project, store storage.ContextStore) error {
 	questionsKubernetes, err := advancedQuestionsKubernetes(p, store)
 	if err != nil {
 		return fmt.Errorf(abort, err)
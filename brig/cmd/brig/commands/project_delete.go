@@ -53,7 +53,9 @@ func deleteProject(out io.Writer, pid string) error {
 	}
 
 	store := kube.New(c, globalNamespace)
-	p, err := store.GetProject(pid)
+	ctx, cancel := commandContext()
+	defer cancel()
+	p, err := store.GetProject(ctx, pid)
 	if err != nil {
 		// To remain idempotent, we just return nil here.
 		if globalVerbose {
@@ -79,5 +81,5 @@ func deleteProject(out io.Writer, pid string) error {
 	if globalVerbose {
 		fmt.Fprintf(out, "Deleting %s\n", p.ID)
 	}
-	return store.DeleteProject(p.ID)
+	return store.DeleteProject(ctx, p.ID)
 }
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/testresults"
+)
+
+const projectFlakyTestsUsage = `List the flaky tests of a project.
+
+Print the tests that both passed and failed on the same commit, or that keep
+changing from passing to failing, in the last builds of a project. The test
+results are read from the Brigade API server, see 'brig build artifacts --help'.
+
+The PROJECT may either be the ID or the name of a project.
+`
+
+var flakyTestBuilds int
+
+func init() {
+	project.AddCommand(projectFlakyTests)
+	projectFlakyTests.Flags().IntVar(&flakyTestBuilds, "builds", 20, "number of recent builds to look at")
+	projectFlakyTests.Flags().StringVar(&apiServiceName, "api-service", "", "name of the service of the Brigade API server")
+	projectFlakyTests.Flags().StringVarP(&output, "output", "o", "", "Return output in another format. Supported formats: json")
+}
+
+var projectFlakyTests = &cobra.Command{
+	Use:   "flaky-tests PROJECT",
+	Short: "list the flaky tests of a project",
+	Long:  projectFlakyTestsUsage,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("project name is a required argument")
+		}
+		return listFlakyTests(cmd.OutOrStdout(), args[0])
+	},
+}
+
+func listFlakyTests(out io.Writer, name string) error {
+	ctx, cancel := commandContext()
+	defer cancel()
+	// The ID of a project has no slash, unlike its name.
+	r, err := apiGet(ctx, "/v1/project/"+brigade.ProjectID(name)+"/tests/flaky?builds="+strconv.Itoa(flakyTestBuilds))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	flaky := []testresults.FlakyTest{}
+	if err := json.NewDecoder(r).Decode(&flaky); err != nil {
+		return fmt.Errorf("could not read the flaky tests of project %s: %s", name, err)
+	}
+
+	if output == "json" {
+		b, err := json.MarshalIndent(flaky, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	}
+	printFlakyTests(out, flaky)
+	return nil
+}
+
+// printFlakyTests prints the flaky tests of a project as a table.
+func printFlakyTests(out io.Writer, flaky []testresults.FlakyTest) {
+	if len(flaky) == 0 {
+		fmt.Fprintln(out, "No flaky tests")
+		return
+	}
+	table := uitable.New()
+	table.AddRow("JOB", "SUITE", "TEST", "PASSED", "FAILED", "FLIPS", "COMMITS", "LAST FAILURE")
+	for _, f := range flaky {
+		table.AddRow(f.Job, f.Suite, f.Name, f.Passed, f.Failed, f.Flips, strings.Join(f.Commits, ","), f.LastFailure)
+	}
+	fmt.Fprintln(out, table)
+}
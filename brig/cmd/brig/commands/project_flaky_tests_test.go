@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/brigadecore/brigade/pkg/testresults"
+)
+
+func TestPrintFlakyTests(t *testing.T) {
+	var out bytes.Buffer
+	printFlakyTests(&out, nil)
+	if out.String() != "No flaky tests\n" {
+		t.Errorf("unexpected output for no flaky tests: %q", out.String())
+	}
+
+	out.Reset()
+	printFlakyTests(&out, []testresults.FlakyTest{
+		{Job: "unit", Suite: "example.com/pequod", Name: "TestHunt", Passed: 4, Failed: 1, Flips: 2, Commits: []string{"abc123"}, LastFailure: stubBuild2ID},
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a header and a row, got:\n%s", out.String())
+	}
+	for _, expect := range []string{"unit", "example.com/pequod", "TestHunt", "abc123", stubBuild2ID} {
+		if !strings.Contains(lines[1], expect) {
+			t.Errorf("expected %q in %q", expect, lines[1])
+		}
+	}
+}
@@ -43,7 +43,9 @@ func getProject(out io.Writer, name string) error {
 	}
 
 	store := kube.New(c, globalNamespace)
-	p, err := store.GetProject(name)
+	ctx, cancel := commandContext()
+	defer cancel()
+	p, err := store.GetProject(ctx, name)
 	if err != nil {
 		return err
 	}
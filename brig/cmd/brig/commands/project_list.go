@@ -37,7 +37,9 @@ func listProjects(out io.Writer) error {
 	}
 
 	store := kube.New(c, globalNamespace)
-	ps, err := store.GetProjects()
+	ctx, cancel := commandContext()
+	defer cancel()
+	ps, err := store.GetProjects(ctx)
 	if err != nil {
 		return err
 	}
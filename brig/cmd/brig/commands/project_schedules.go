@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+const projectSchedulesUsage = `List the schedules of a project.
+
+Print the cron schedules the brigade-cron-gateway creates builds for, with the
+time of their next run and their last build.
+
+The PROJECT may either be the ID or the name of a project.
+`
+
+func init() {
+	project.AddCommand(projectSchedules)
+	projectSchedules.Flags().StringVarP(&output, "output", "o", "", "Return output in another format. Supported formats: json")
+}
+
+var projectSchedules = &cobra.Command{
+	Use:   "schedules PROJECT",
+	Short: "list the schedules of a project",
+	Long:  projectSchedulesUsage,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("project name is a required argument")
+		}
+		return listProjectSchedules(cmd.OutOrStdout(), args[0])
+	},
+}
+
+// scheduleStatus is a schedule with its next run and last build.
+type scheduleStatus struct {
+	brigade.Schedule
+	NextRun   *time.Time `json:"nextRun,omitempty"`
+	LastBuild string     `json:"lastBuild,omitempty"`
+}
+
+func listProjectSchedules(out io.Writer, name string) error {
+	c, err := kubeClient()
+	if err != nil {
+		return err
+	}
+
+	store := kube.New(c, globalNamespace)
+	ctx, cancel := commandContext()
+	defer cancel()
+	p, err := store.GetProject(ctx, name)
+	if err != nil {
+		return err
+	}
+	builds, err := store.GetProjectBuilds(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	schedules := projectScheduleStatuses(p, builds, time.Now())
+	if output == "json" {
+		b, err := json.MarshalIndent(schedules, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	}
+	printSchedules(out, schedules)
+	return nil
+}
+
+// projectScheduleStatuses returns the schedules of a project with their next
+// run after now, and their last build among the given ones.
+func projectScheduleStatuses(p *brigade.Project, builds []*brigade.Build, now time.Time) []scheduleStatus {
+	schedules := make([]scheduleStatus, 0, len(p.Schedules))
+	for _, s := range p.Schedules {
+		status := scheduleStatus{Schedule: s}
+		if next, err := s.Next(now); err == nil && !next.IsZero() {
+			status.NextRun = &next
+		}
+		for _, b := range builds {
+			// The builds of a schedule are titled with its name.
+			if b.Provider == brigade.ScheduleProvider && b.ShortTitle == s.Name && b.ID > status.LastBuild {
+				status.LastBuild = b.ID
+			}
+		}
+		schedules = append(schedules, status)
+	}
+	return schedules
+}
+
+func printSchedules(out io.Writer, schedules []scheduleStatus) {
+	table := uitable.New()
+	table.AddRow("NAME", "CRON", "TIMEZONE", "EVENT", "MISSED RUNS", "NEXT RUN", "LAST BUILD")
+	for _, s := range schedules {
+		tz, missed, next, last := s.Timezone, s.MissedRuns, "???", s.LastBuild
+		if tz == "" {
+			tz = "UTC"
+		}
+		if missed == "" {
+			missed = brigade.MissedRunsSkip
+		}
+		if s.NextRun != nil {
+			next = s.NextRun.Format(time.RFC3339)
+		}
+		if last == "" {
+			last = "-"
+		}
+		table.AddRow(s.Name, s.Cron, tz, s.Event(), missed, next, last)
+	}
+	fmt.Fprintln(out, table)
+}
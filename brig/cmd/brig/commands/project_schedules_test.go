@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+func TestProjectScheduleStatuses(t *testing.T) {
+	p := &brigade.Project{
+		Schedules: []brigade.Schedule{
+			{Name: "nightly", Cron: "0 3 * * *", MissedRuns: brigade.MissedRunsCatchUp},
+			{Name: "hourly", Cron: "@hourly", EventType: "tick"},
+		},
+	}
+	builds := []*brigade.Build{
+		{ID: stubBuild1ID, Provider: brigade.ScheduleProvider, ShortTitle: "nightly"},
+		{ID: stubBuild2ID, Provider: brigade.ScheduleProvider, ShortTitle: "nightly"},
+		{ID: stubBuild3ID, Provider: "github", ShortTitle: "hourly"},
+	}
+	now := time.Date(2020, 6, 1, 15, 4, 5, 0, time.UTC)
+	schedules := projectScheduleStatuses(p, builds, now)
+
+	if len(schedules) != 2 {
+		t.Fatalf("expected 2 schedules, got %d", len(schedules))
+	}
+	if schedules[0].LastBuild != stubBuild2ID || schedules[1].LastBuild != "" {
+		t.Errorf("unexpected last builds %q and %q", schedules[0].LastBuild, schedules[1].LastBuild)
+	}
+	if next := schedules[0].NextRun; next == nil || !next.Equal(time.Date(2020, 6, 2, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next run %v", next)
+	}
+
+	var out bytes.Buffer
+	printSchedules(&out, schedules)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got:\n%s", out.String())
+	}
+	for _, expect := range []string{"hourly", "@hourly", "UTC", "tick", "skip", "2020-06-01T16:00:00Z", "-"} {
+		if !strings.Contains(lines[2], expect) {
+			t.Errorf("expected %q in %q", expect, lines[2])
+		}
+	}
+}
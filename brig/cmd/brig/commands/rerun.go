@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -74,12 +75,14 @@ var rerun = &cobra.Command{
 
 		updateRunner(a)
 
-		build, err := getUpdatedBuild(a, bid)
+		ctx, cancel := commandContext()
+		defer cancel()
+		build, err := getUpdatedBuild(ctx, a, bid)
 		if err != nil {
 			return err
 		}
 
-		err = a.SendBuild(build)
+		err = a.SendBuild(ctx, build)
 
 		// If err is a BuildFailure, then we don't want Cobra to print the Usage
 		// instructions on failure, since it's a pipeline issue and not a CLI issue.
@@ -108,8 +111,8 @@ func updateRunner(r *script.Runner) {
 	r.Verbose = globalVerbose
 }
 
-func getUpdatedBuild(r *script.Runner, bid string) (*brigade.Build, error) {
-	build, err := r.GetBuild(bid)
+func getUpdatedBuild(ctx context.Context, r *script.Runner, bid string) (*brigade.Build, error) {
+	build, err := r.GetBuild(ctx, bid)
 	if err != nil {
 		return build, err
 	}
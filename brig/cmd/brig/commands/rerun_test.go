@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"testing"
 
 	"github.com/brigadecore/brigade/pkg/decolorizer"
@@ -120,7 +121,7 @@ func TestRerun_getUpdatedBuild(t *testing.T) {
 				rerunEvent = tc.Type
 			}
 
-			build, err := getUpdatedBuild(r, stubBuild1ID)
+			build, err := getUpdatedBuild(context.TODO(), r, stubBuild1ID)
 			if err != nil {
 				t.Errorf("expected err to be nil, was: %s", err.Error())
 			}
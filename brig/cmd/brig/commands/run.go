@@ -133,7 +133,9 @@ var run = &cobra.Command{
 		runner.Background = runBackground
 		runner.Verbose = globalVerbose
 
-		err = runner.SendScript(proj, scr, config, runEvent, runCommitish, runRef, payload, runLogLevel)
+		ctx, cancel := commandContext()
+		defer cancel()
+		err = runner.SendScript(ctx, proj, scr, config, runEvent, runCommitish, runRef, payload, runLogLevel)
 		if err == nil {
 			return nil
 		}
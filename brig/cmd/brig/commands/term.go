@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/brigadecore/brigade/pkg/storage"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
 )
 
@@ -43,7 +44,7 @@ func runTerm() error {
 	}
 
 	store := kube.New(client, globalNamespace)
-	svc := brigade.NewService(store)
+	svc := brigade.NewService(storage.WithoutContext(store))
 	uictrl := controller.NewController(svc)
 	app := tview.NewApplication()
 	t, err := time.ParseDuration(reloadInterval)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	// Validating schedules resolves their time zones, which are embedded since
+	// the image has no zoneinfo.
+	_ "time/tzdata"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+)
+
+var (
+	port                       string
+	tlsCert                    string
+	tlsKey                     string
+	projectServiceAccountRegex string
+	logLevel                   string
+	logFormat                  string
+)
+
+func init() {
+	flag.StringVar(&port, "port", defaultPort(), "TCP port to use for brigade-admission-webhook")
+	flag.StringVar(&tlsCert, "tls-cert", defaultTLSCert(), "path to the TLS certificate served to the Kubernetes API server")
+	flag.StringVar(&tlsKey, "tls-key", defaultTLSKey(), "path to the TLS private key")
+	flag.StringVar(&projectServiceAccountRegex, "project-service-account-regex", os.Getenv("BRIGADE_SERVICE_ACCOUNT_REGEX"), "regex that project service accounts must match, if empty any service account is accepted")
+	flag.StringVar(&logLevel, "log-level", logging.DefaultLevel(), "minimum level of the log lines: debug, info, warn or error")
+	flag.StringVar(&logFormat, "log-format", logging.DefaultFormat(), "format of the log lines: text or json")
+}
+
+func main() {
+	flag.Parse()
+	if err := logging.Configure(logLevel, logFormat); err != nil {
+		logging.Fatalf("%s", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/validate", &projectValidator{serviceAccountRegex: projectServiceAccountRegex})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("\"OK\""))
+	})
+
+	logging.Infof("Validating Brigade projects on port %s", port)
+	logging.Fatalf("%s", http.ListenAndServeTLS(":"+port, tlsCert, tlsKey, mux))
+}
+
+func defaultPort() string {
+	if port, ok := os.LookupEnv("BRIGADE_WEBHOOK_PORT"); ok {
+		return port
+	}
+	return "8443"
+}
+
+func defaultTLSCert() string {
+	if cert, ok := os.LookupEnv("BRIGADE_WEBHOOK_TLS_CERT"); ok {
+		return cert
+	}
+	return "/etc/brigade-admission-webhook/tls.crt"
+}
+
+func defaultTLSKey() string {
+	if key, ok := os.LookupEnv("BRIGADE_WEBHOOK_TLS_KEY"); ok {
+		return key
+	}
+	return "/etc/brigade-admission-webhook/tls.key"
+}
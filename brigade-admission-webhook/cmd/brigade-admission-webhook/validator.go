@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+const secretTypeProject = "brigade.sh/project"
+
+// projectValidator is a validating admission webhook for Brigade project secrets.
+type projectValidator struct {
+	serviceAccountRegex string
+}
+
+func (pv *projectValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	review := admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("could not decode admission review: %s", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "admission review has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = pv.review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		logging.Errorf("error writing admission response: %s", err)
+	}
+}
+
+// review admits any object that is not a project secret, and project secrets
+// that pass validation.
+func (pv *projectValidator) review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Kind != "Secret" || req.Operation == admissionv1.Delete {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	secret := v1.Secret{}
+	if err := json.Unmarshal(req.Object.Raw, &secret); err != nil {
+		return deny(fmt.Sprintf("could not decode secret: %s", err))
+	}
+	if secret.Type != secretTypeProject {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	// The API server merges stringData into data before admission, but be
+	// lenient with objects that still carry it.
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for k, v := range secret.StringData {
+		secret.Data[k] = []byte(v)
+	}
+	if secret.Name == "" {
+		secret.Name = req.Name
+	}
+
+	proj, err := kube.NewProjectFromSecret(&secret, req.Namespace)
+	if err != nil {
+		return deny(err.Error())
+	}
+	if err := proj.Validate(); err != nil {
+		logging.With(logging.ProjectIDKey, proj.ID).Infof("rejecting project %s: %s", proj.Name, err)
+		return deny(err.Error())
+	}
+	if err := proj.ValidateServiceAccount(pv.serviceAccountRegex); err != nil {
+		logging.With(logging.ProjectIDKey, proj.ID).Infof("rejecting project %s: %s", proj.Name, err)
+		return deny(err.Error())
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func deny(msg string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: msg,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func projectSecret(data map[string]string) *v1.Secret {
+	secret := &v1.Secret{
+		TypeMeta: metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "brigade-830c16d4aaf6f5490937ad719afd8490a5bcbef064d397411043ac",
+			Namespace:   "default",
+			Annotations: map[string]string{"projectName": "brigadecore/empty-testbed"},
+		},
+		Type: secretTypeProject,
+		Data: map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func admit(t *testing.T, pv *projectValidator, obj interface{}) *admissionv1.AdmissionResponse {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "f0b1a7a4-0cc1-4a8b-8ef8-1a1a2f0d6f0a",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Secret"},
+			Namespace: "default",
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rw := httptest.NewRecorder()
+	pv.ServeHTTP(rw, httptest.NewRequest("POST", "/validate", bytes.NewBuffer(body)))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rw.Code, rw.Body.String())
+	}
+
+	got := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Response == nil {
+		t.Fatal("expected a response in the admission review")
+	}
+	if got.Response.UID != review.Request.UID {
+		t.Errorf("expected UID %q, got %q", review.Request.UID, got.Response.UID)
+	}
+	return got.Response
+}
+
+func TestProjectValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		regex   string
+		obj     interface{}
+		allowed bool
+		message string
+	}{
+		{"valid project", "", projectSecret(map[string]string{"vcsSidecar": "brigadecore/git-sidecar:latest"}), true, ""},
+		{"not a project", "", &v1.Secret{TypeMeta: metav1.TypeMeta{Kind: "Secret"}, Type: v1.SecretTypeOpaque}, true, ""},
+		{"absolute brigadejsPath", "", projectSecret(map[string]string{"brigadejsPath": "/brigade.js"}), false, "brigadejsPath"},
+		{"bad sidecar resources", "", projectSecret(map[string]string{"vcsSidecarResources.limits.cpu": "one"}), false, "vcsSidecarResources.limits.cpu"},
+		{"bad allowSecretKeyRef", "", projectSecret(map[string]string{"kubernetes.allowSecretKeyRef": "maybe"}), false, "allowSecretKeyRef"},
+		{"service account mismatch", "^brigade-.*$", projectSecret(map[string]string{"serviceAccount": "default"}), false, "serviceAccount"},
+		{"service account match", "^brigade-.*$", projectSecret(map[string]string{"serviceAccount": "brigade-worker"}), true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := admit(t, &projectValidator{serviceAccountRegex: tt.regex}, tt.obj)
+			if resp.Allowed != tt.allowed {
+				t.Fatalf("expected allowed to be %t, got %t (%v)", tt.allowed, resp.Allowed, resp.Result)
+			}
+			if !tt.allowed && !strings.Contains(resp.Result.Message, tt.message) {
+				t.Errorf("expected message to mention %q, got %q", tt.message, resp.Result.Message)
+			}
+		})
+	}
+}
+
+func TestProjectValidatorBadRequest(t *testing.T) {
+	pv := &projectValidator{}
+
+	rw := httptest.NewRecorder()
+	pv.ServeHTTP(rw, httptest.NewRequest("GET", "/validate", nil))
+	if rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rw.Code)
+	}
+
+	rw = httptest.NewRecorder()
+	pv.ServeHTTP(rw, httptest.NewRequest("POST", "/validate", strings.NewReader("{}")))
+	if rw.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rw.Code)
+	}
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
+	"time"
+	// The project validation resolves the time zones of schedules, which are
+	// embedded since the image has no zoneinfo.
+	_ "time/tzdata"
 
 	"github.com/brigadecore/brigade/pkg/api"
+	"github.com/brigadecore/brigade/pkg/archive"
+	"github.com/brigadecore/brigade/pkg/artifacts"
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
+	"github.com/brigadecore/brigade/pkg/storage/sqlstore"
+	"github.com/brigadecore/brigade/pkg/testresults"
+	"github.com/brigadecore/brigade/pkg/tracing"
 
 	restful "github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
@@ -19,11 +33,22 @@ import (
 )
 
 var (
-	apiPort    string
-	kubeconfig string
-	master     string
-	namespace  string
-	verbose    bool
+	apiPort      string
+	kubeconfig   string
+	master       string
+	namespace    string
+	verbose      bool
+	archiveURL   string
+	artifactsURL string
+	artifactMax  int64
+	buildMax     int64
+	historyURL   string
+	timeout      time.Duration
+	heartbeat    time.Duration
+	logLevel     string
+	logFormat    string
+	otlpEndpoint string
+	saRegex      string
 )
 
 func init() {
@@ -31,6 +56,17 @@ func init() {
 	flag.StringVar(&master, "master", "", "master url")
 	flag.StringVar(&namespace, "namespace", defaultNamespace(), "kubernetes namespace")
 	flag.StringVar(&apiPort, "api-port", defaultAPIPort(), "TCP port to use for brigade-api")
+	flag.StringVar(&archiveURL, "archive-url", os.Getenv("BRIGADE_ARCHIVE_URL"), "archive to read the logs of deleted builds from (file:///path or s3://bucket/prefix)")
+	flag.StringVar(&artifactsURL, "artifacts-url", os.Getenv("BRIGADE_ARTIFACTS_URL"), "store the artifacts and test results jobs upload at this URL (file:///path), disabled if empty")
+	flag.Int64Var(&artifactMax, "artifact-max-size", defaultArtifactMaxSize(), "maximum size of an uploaded artifact, in bytes")
+	flag.Int64Var(&buildMax, "artifacts-max-build-size", defaultArtifactsMaxBuildSize(), "maximum total size of the artifacts and test results of a build, in bytes")
+	flag.StringVar(&historyURL, "history-database", os.Getenv("BRIGADE_HISTORY_DATABASE"), "database to read the history of builds and jobs from (postgres://... or sqlite3:///path)")
+	flag.StringVar(&saRegex, "project-service-account-regex", os.Getenv("BRIGADE_SERVICE_ACCOUNT_REGEX"), "regex that project service accounts must match on validation, if empty any service account is accepted")
+	flag.DurationVar(&timeout, "request-timeout", defaultRequestTimeout(), "maximum duration of a request, log streams are not limited")
+	flag.DurationVar(&heartbeat, "log-heartbeat", defaultLogHeartbeat(), "how long a followed log may stay silent before an empty line keeps the connection open, 0 disables heartbeats")
+	flag.StringVar(&logLevel, "log-level", logging.DefaultLevel(), "minimum level of the log lines: debug, info, warn or error")
+	flag.StringVar(&logFormat, "log-format", logging.DefaultFormat(), "format of the log lines: text or json")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", tracing.DefaultEndpoint(), "OTLP gRPC endpoint to export traces to (e.g. http://otel-collector:4317), disabled if empty")
 	flag.BoolVar(&verbose, "verbose", false, "enables detailed logging of http request matching and filter invocation")
 }
 
@@ -40,10 +76,15 @@ type jobService struct {
 
 type buildService struct {
 	server api.API
+	// artifacts and tests are nil when artifacts are disabled.
+	artifacts *api.Artifacts
+	tests     *api.Tests
 }
 
 type projectService struct {
 	server api.API
+	// tests is nil when artifacts are disabled.
+	tests *api.Tests
 }
 
 type healthService struct {
@@ -68,13 +109,18 @@ func (js jobService) WebService() *restful.WebService {
 		Returns(200, "OK", brigade.Job{}).
 		Returns(404, "Not Found", nil))
 
-	ws.Route(ws.GET("/{id}/logs").To(j.Logs).
+	logs := ws.GET("/{id}/logs").To(j.Logs).
 		Doc("get job logs").
 		Param(ws.PathParameter("id", "identifier of the job").DataType("string")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes([]byte{}). // on the response
 		Returns(200, "OK", []byte{}).
-		Returns(404, "Not Found", nil))
+		Returns(400, "Bad Request", nil).
+		Returns(404, "Not Found", nil)
+	for _, p := range api.LogParameters(ws) {
+		logs.Param(p)
+	}
+	ws.Route(logs)
 
 	return ws
 }
@@ -106,13 +152,75 @@ func (bs buildService) WebService() *restful.WebService {
 		Returns(200, "OK", []brigade.Job{}).
 		Returns(404, "Not Found", nil))
 
-	ws.Route(ws.GET("/{id}/logs").To(b.Logs).
+	logs := ws.GET("/{id}/logs").To(b.Logs).
 		Doc("get logs of a build").
 		Param(ws.PathParameter("id", "id of the build").DataType("string")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes([]byte{}).
 		Returns(200, "OK", []byte{}).
-		Returns(404, "Not Found", nil))
+		Returns(400, "Bad Request", nil).
+		Returns(404, "Not Found", nil)
+	for _, p := range api.BuildLogParameters(ws) {
+		logs.Param(p)
+	}
+	ws.Route(logs)
+
+	if a := bs.artifacts; a != nil {
+		ws.Route(ws.GET("/{id}/artifacts").To(a.List).
+			Doc("list the artifacts of a build").
+			Param(ws.PathParameter("id", "id of the build").DataType("string")).
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			Writes([]artifacts.Artifact{}).
+			Returns(200, "OK", []artifacts.Artifact{}).
+			Returns(404, "Not Found", nil))
+
+		ws.Route(ws.GET("/{id}/artifacts/{path:*}").To(a.Get).
+			Doc("download an artifact of a build").
+			Param(ws.PathParameter("id", "id of the build").DataType("string")).
+			Param(ws.PathParameter("path", "path of the artifact").DataType("string")).
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			Produces("*/*").
+			Writes([]byte{}).
+			Returns(200, "OK", []byte{}).
+			Returns(404, "Not Found", nil))
+
+		ws.Route(ws.PUT("/{id}/artifacts/{path:*}").To(a.Put).
+			Doc("upload an artifact of a running build").
+			Param(ws.PathParameter("id", "id of the build").DataType("string")).
+			Param(ws.PathParameter("path", "path of the artifact").DataType("string")).
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			Consumes("*/*").
+			Writes(artifacts.Artifact{}).
+			Returns(201, "Created", artifacts.Artifact{}).
+			Returns(400, "Bad Request", nil).
+			Returns(404, "Not Found", nil).
+			Returns(409, "Conflict", nil).
+			Returns(413, "Request Entity Too Large", nil))
+	}
+
+	if t := bs.tests; t != nil {
+		ws.Route(ws.GET("/{id}/tests").To(t.Get).
+			Doc("get the test results of a build").
+			Param(ws.PathParameter("id", "id of the build").DataType("string")).
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			Writes(testresults.Report{}).
+			Returns(200, "OK", testresults.Report{}).
+			Returns(404, "Not Found", nil))
+
+		ws.Route(ws.PUT("/{id}/tests/{job}").To(t.Put).
+			Doc("publish the JUnit XML or go test -json report of a job of a running build").
+			Param(ws.PathParameter("id", "id of the build").DataType("string")).
+			Param(ws.PathParameter("job", "name of the job").DataType("string")).
+			Param(ws.QueryParameter("format", "format of the report: junit or test2json, detected if empty").DataType("string")).
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			Consumes("*/*").
+			Writes(testresults.JobReport{}).
+			Returns(201, "Created", testresults.JobReport{}).
+			Returns(400, "Bad Request", nil).
+			Returns(404, "Not Found", nil).
+			Returns(409, "Conflict", nil).
+			Returns(413, "Request Entity Too Large", nil))
+	}
 
 	return ws
 }
@@ -151,6 +259,26 @@ func (ps projectService) WebService() *restful.WebService {
 		Returns(200, "OK", []brigade.Build{}).
 		Returns(404, "Not Found", nil))
 
+	ws.Route(ws.GET("/project/{id}/validate").To(p.Validate).
+		Doc("validate the configuration of a project").
+		Param(ws.PathParameter("id", "id of the project").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]brigade.ValidationError{}).
+		Returns(200, "OK", []brigade.ValidationError{}).
+		Returns(404, "Not Found", nil))
+
+	if t := ps.tests; t != nil {
+		ws.Route(ws.GET("/project/{id}/tests/flaky").To(t.Flaky).
+			Doc("get the flaky tests of the last builds of a project").
+			Param(ws.PathParameter("id", "id of the project").DataType("string")).
+			Param(ws.QueryParameter("builds", "number of builds to look at, 20 by default").DataType("integer")).
+			Metadata(restfulspec.KeyOpenAPITags, tags).
+			Writes([]testresults.FlakyTest{}).
+			Returns(200, "OK", []testresults.FlakyTest{}).
+			Returns(400, "Bad Request", nil).
+			Returns(404, "Not Found", nil))
+	}
+
 	ws.Route(ws.GET("/projects-build").To(p.ListWithLatestBuild).
 		Doc("lists the projects with the latest builds attached.").
 		Metadata(restfulspec.KeyOpenAPITags, tags).
@@ -183,28 +311,72 @@ func (hs healthService) WebService() *restful.WebService {
 
 func main() {
 	flag.Parse()
+	if err := logging.Configure(logLevel, logFormat); err != nil {
+		logging.Fatalf("%s", err)
+	}
+	shutdown, err := tracing.Configure("brigade-api", otlpEndpoint)
+	if err != nil {
+		logging.Fatalf("%s", err)
+	}
+	defer shutdown(context.Background())
 
-	restful.EnableTracing(verbose)
+	restful.SetLogger(log.New(logging.Default().Writer(logging.WarnLevel), "", 0))
+	if verbose {
+		restful.TraceLogger(log.New(logging.Default().Writer(logging.InfoLevel), "", 0))
+	}
+	api.LogHeartbeat = heartbeat
+	api.MaxArtifactSize = artifactMax
+	api.MaxBuildArtifactsSize = buildMax
+	if _, err := regexp.Compile(saRegex); err != nil {
+		logging.Fatalf("invalid --project-service-account-regex: %s", err)
+	}
+	api.ServiceAccountRegex = saRegex
 
 	clientset, err := kube.GetClient(master, kubeconfig)
 	if err != nil {
-		log.Fatalf("error creating kubernetes client (%s)", err)
+		logging.Fatalf("error creating kubernetes client (%s)", err)
 		return
 	}
 
-	storage := kube.New(clientset, namespace)
-	storageServer := api.New(storage)
+	store := kube.New(clientset, namespace)
+	if archiveURL != "" {
+		sink, err := archive.NewSink(archiveURL)
+		if err != nil {
+			logging.Fatalf("error creating the archive (%s)", err)
+		}
+		store = archive.NewStore(store, sink)
+	}
+	if historyURL != "" {
+		history, err := sqlstore.OpenURL(historyURL)
+		if err != nil {
+			logging.Fatalf("error opening the history database (%s)", err)
+		}
+		defer history.Close()
+		store = sqlstore.WithHistory(store, history)
+	}
+	storageServer := api.New(storage.Traced(store))
 
 	j := jobService{server: storageServer}
 	b := buildService{server: storageServer}
 	p := projectService{server: storageServer}
+	if artifactsURL != "" {
+		artifactStore, err := artifacts.NewStore(artifactsURL)
+		if err != nil {
+			logging.Fatalf("error opening the artifacts store (%s)", err)
+		}
+		a := storageServer.Artifacts(artifactStore)
+		t := storageServer.Tests(artifactStore)
+		b.artifacts, b.tests, p.tests = &a, &t, &t
+	}
 	h := healthService{}
 
 	restful.DefaultContainer.Add(j.WebService())
 	restful.DefaultContainer.Add(b.WebService())
 	restful.DefaultContainer.Add(p.WebService())
 	restful.DefaultContainer.Add(h.WebService())
-	restful.DefaultContainer.Filter(NCSACommonLogFormatLogger())
+	restful.DefaultContainer.Filter(requestLogger())
+	restful.DefaultContainer.Filter(requestTracer())
+	restful.DefaultContainer.Filter(api.Timeout(timeout))
 
 	config := restfulspec.Config{
 		WebServices:                   restful.RegisteredWebServices(),
@@ -221,9 +393,9 @@ func main() {
 
 	formattedAPIPort := fmt.Sprintf(":%v", apiPort)
 
-	log.Printf("Get the API using %s/apidocs.json", formattedAPIPort)
+	logging.Infof("Get the API using %s/apidocs.json", formattedAPIPort)
 	hserver := &http.Server{Addr: formattedAPIPort, Handler: restful.DefaultContainer}
-	log.Fatal(hserver.ListenAndServe())
+	logging.Fatalf("%s", hserver.ListenAndServe())
 }
 
 func defaultNamespace() string {
@@ -233,6 +405,34 @@ func defaultNamespace() string {
 	return v1.NamespaceDefault
 }
 
+func defaultRequestTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("BRIGADE_API_REQUEST_TIMEOUT")); err == nil {
+		return d
+	}
+	return time.Minute
+}
+
+func defaultLogHeartbeat() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("BRIGADE_API_LOG_HEARTBEAT")); err == nil {
+		return d
+	}
+	return api.LogHeartbeat
+}
+
+func defaultArtifactMaxSize() int64 {
+	if n, err := strconv.ParseInt(os.Getenv("BRIGADE_ARTIFACT_MAX_SIZE"), 10, 64); err == nil {
+		return n
+	}
+	return api.MaxArtifactSize
+}
+
+func defaultArtifactsMaxBuildSize() int64 {
+	if n, err := strconv.ParseInt(os.Getenv("BRIGADE_ARTIFACTS_MAX_BUILD_SIZE"), 10, 64); err == nil {
+		return n
+	}
+	return api.MaxBuildArtifactsSize
+}
+
 func defaultAPIPort() string {
 	if port, ok := os.LookupEnv("BRIGADE_API_PORT"); ok {
 		return port
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/archive"
+)
+
+// archivedAnnotation is set on build secrets once the build was archived.
+const archivedAnnotation = "archived"
+
+// ArchiveTo makes the controller archive the logs and metadata of every build
+// whose worker completed.
+func (c *Controller) ArchiveTo(sink archive.Sink) {
+	c.archiver = &archive.Archiver{
+		Store: c.snapshots,
+		Sink:  sink,
+	}
+}
+
+// archiveWorker archives the build of a completed worker pod, unless it was
+// already archived.
+func (c *Controller) archiveWorker(pod *v1.Pod) error {
+	if c.archiver == nil || (pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed) {
+		return nil
+	}
+	ctx := context.TODO()
+	secretClient := c.clientset.CoreV1().Secrets(pod.Namespace)
+	build, err := secretClient.Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if build.Annotations[archivedAnnotation] != "" {
+		return nil
+	}
+
+	if err := c.archiver.ArchiveBuild(ctx, build.Labels["build"]); err != nil {
+		return err
+	}
+	buildLogger(build).Infof("Archived build %s", build.Labels["build"])
+
+	buildCopy := build.DeepCopy()
+	if buildCopy.Annotations == nil {
+		buildCopy.Annotations = map[string]string{}
+	}
+	buildCopy.Annotations[archivedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	_, err = secretClient.Update(ctx, buildCopy, metav1.UpdateOptions{})
+	return err
+}
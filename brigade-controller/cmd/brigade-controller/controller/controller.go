@@ -2,7 +2,7 @@ package controller
 
 import (
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -10,7 +10,15 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
+
+	"github.com/brigadecore/brigade/pkg/archive"
+	"github.com/brigadecore/brigade/pkg/commitstatus"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/notify"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
 )
 
 const (
@@ -33,8 +41,13 @@ type Config struct {
 	WorkerRequestsMemory       string
 	WorkerLimitsCPU            string
 	WorkerLimitsMemory         string
+	WorkerMaxCPU               string
+	WorkerMaxMemory            string
+	WorkerTimeout              string
 	DefaultBuildStorageClass   string
 	DefaultCacheStorageClass   string
+	ReconcileInterval          string
+	ReconcilePolicy            string
 }
 
 // Controller listens for new brigade builds and starts the worker pods.
@@ -43,6 +56,20 @@ type Controller struct {
 	indexer  cache.Indexer
 	queue    workqueue.RateLimitingInterface
 	informer cache.Controller
+	recorder record.EventRecorder
+	archiver *archive.Archiver
+	history  storage.HistoryRecorder
+	notifier *notify.Notifier
+	reporter *commitstatus.Reporter
+	// store reads builds and jobs through a cache shared by the controller.
+	store storage.ContextStore
+	// snapshots reads builds and jobs from the API, for the history and the
+	// archive, which must not miss the jobs the cache has not seen yet.
+	snapshots storage.ContextStore
+
+	workerIndexer  cache.Indexer
+	workerQueue    workqueue.RateLimitingInterface
+	workerInformer cache.Controller
 
 	clientset kubernetes.Interface
 }
@@ -53,8 +80,15 @@ func NewController(clientset kubernetes.Interface, config *Config) *Controller {
 		clientset: clientset,
 		Config:    config,
 		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+
+		workerQueue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+
+		recorder:  newEventRecorder(clientset, config.Namespace),
+		store:     kube.New(clientset, config.Namespace),
+		snapshots: kube.NewDirect(clientset, config.Namespace),
 	}
 	c.createIndexerInformer()
+	c.createWorkerInformer()
 	return c
 }
 
@@ -86,9 +120,32 @@ func (c *Controller) processNextItem() bool {
 	return true
 }
 
+func (c *Controller) processNextWorker() bool {
+	key, quit := c.workerQueue.Get()
+	if quit {
+		return false
+	}
+	defer c.workerQueue.Done(key)
+
+	err := c.syncWorker(key.(string))
+	if err == nil {
+		c.workerQueue.Forget(key)
+		return true
+	}
+	if c.workerQueue.NumRequeues(key) < 5 {
+		logging.Errorf("Error syncing worker %v: %v", key, err)
+		c.workerQueue.AddRateLimited(key)
+		return true
+	}
+	c.workerQueue.Forget(key)
+	utilruntime.HandleError(err)
+	logging.Infof("Dropping worker %q out of the queue: %v", key, err)
+	return true
+}
+
 // HasSynced returns true if the controller has synced.
 func (c *Controller) HasSynced() bool {
-	return c.informer.HasSynced()
+	return c.informer.HasSynced() && c.workerInformer.HasSynced()
 }
 
 // sync is the business logic of the controller.
@@ -96,16 +153,20 @@ func (c *Controller) HasSynced() bool {
 func (c *Controller) sync(key string) error {
 	secret, exists, err := c.getSecret(key)
 	if err != nil {
-		log.Printf("Fetching object with key %s from store failed with %v", key, err)
+		logging.Errorf("Fetching object with key %s from store failed with %v", key, err)
 		return err
 	}
 
 	if !exists {
-		fmt.Printf("Secret %s does not exist anymore\n", key)
+		// The build ID is the label of the deleted secret, it can only be
+		// derived from its name.
+		_, name, _ := cache.SplitMetaNamespaceKey(key)
+		logging.With(logging.BuildIDKey, strings.TrimPrefix(name, "brigade-worker-")).
+			Infof("Secret %s does not exist anymore", key)
 	} else {
 		// Note that you also have to check the uid if you have a local controlled resource, which
 		// is dependent on the actual instance, to detect that a Secret was recreated with the same name
-		log.Printf("Executing on Secret: %s\n", secret.GetName())
+		logging.Debugf("Executing on Secret: %s", secret.GetName())
 		return c.syncSecret(secret)
 	}
 	return nil
@@ -123,7 +184,7 @@ func (c *Controller) handleErr(err error, key interface{}) {
 
 	// This controller retries 5 times if something goes wrong. After that, it stops trying.
 	if c.queue.NumRequeues(key) < 5 {
-		log.Printf("Error syncing secret %v: %v", key, err)
+		logging.Errorf("Error syncing secret %v: %v", key, err)
 
 		// Re-enqueue the key rate limited. Based on the rate limiter on the
 		// queue and the re-enqueue history, the key will be processed later again.
@@ -134,7 +195,7 @@ func (c *Controller) handleErr(err error, key interface{}) {
 	c.queue.Forget(key)
 	// Report to an external entity that, even after several retries, we could not successfully process this key
 	utilruntime.HandleError(err)
-	log.Printf("Dropping secret %q out of the queue: %v", key, err)
+	logging.Infof("Dropping secret %q out of the queue: %v", key, err)
 }
 
 // Run executes the controller.
@@ -143,9 +204,11 @@ func (c *Controller) Run(threadiness int, stopCh chan struct{}) {
 
 	// Let the workers stop when we are done
 	defer c.queue.ShutDown()
-	log.Print("Starting Secret controller")
+	defer c.workerQueue.ShutDown()
+	logging.Infof("Starting Secret controller")
 
 	go c.informer.Run(stopCh)
+	go c.workerInformer.Run(stopCh)
 
 	// Wait for all involved caches to be synced, before processing items from the queue is started
 	if !cache.WaitForCacheSync(stopCh, c.HasSynced) {
@@ -156,12 +219,26 @@ func (c *Controller) Run(threadiness int, stopCh chan struct{}) {
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(c.runWorker, time.Second, stopCh)
 	}
+	go wait.Until(c.runWorkerRetries, time.Second, stopCh)
+	if interval, err := time.ParseDuration(c.ReconcileInterval); err == nil && interval > 0 {
+		go wait.Until(c.reconcile, interval, stopCh)
+	}
 
 	<-stopCh
-	log.Print("Stopping Secret controller")
+	logging.Infof("Stopping Secret controller")
 }
 
 func (c *Controller) runWorker() {
 	for c.processNextItem() {
 	}
 }
+
+func (c *Controller) runWorkerRetries() {
+	for c.processNextWorker() {
+	}
+}
+
+// buildLogger returns a logger adding the fields of the build of a secret.
+func buildLogger(build *v1.Secret) *logging.Logger {
+	return logging.WithBuild(kube.NewBuildFromSecret(*build))
+}
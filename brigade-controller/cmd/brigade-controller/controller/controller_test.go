@@ -12,9 +12,11 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
+
+	"github.com/brigadecore/brigade/pkg/storage/kube"
 )
 
-const expectedEnvironmentLength = 20
+const expectedEnvironmentLength = 21
 
 func TestController(t *testing.T) {
 	createdPod := false
@@ -156,6 +158,9 @@ func TestController(t *testing.T) {
 	if sec.Labels["status"] != "accepted" {
 		t.Error("expected label 'status=accepted'")
 	}
+	if len(sec.Data[kube.UploadTokenKey]) == 0 {
+		t.Error("expected an upload token in the build")
+	}
 }
 
 func TestController_WithScript(t *testing.T) {
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+)
+
+// These are the reasons of the events the controller records on build secrets
+// and worker pods.
+const (
+	// ReasonBuildAccepted is recorded when the controller accepted a build.
+	ReasonBuildAccepted = "BuildAccepted"
+	// ReasonWorkerCreated is recorded when the worker pod of a build was created.
+	ReasonWorkerCreated = "WorkerCreated"
+	// ReasonWorkerCreateFailed is recorded when the worker pod of a build could not be created.
+	ReasonWorkerCreateFailed = "WorkerCreateFailed"
+	// ReasonWorkerDelayed is recorded when the worker of a retried build waits for its backoff.
+	ReasonWorkerDelayed = "WorkerDelayed"
+	// ReasonWorkerRetried is recorded when a failed build was retried as a new build.
+	ReasonWorkerRetried = "WorkerRetried"
+	// ReasonBuildTimedOut is recorded when the worker exceeded the worker timeout.
+	ReasonBuildTimedOut = "BuildTimedOut"
+	// ReasonBuildCancelled is recorded when the worker was deleted before it completed.
+	ReasonBuildCancelled = "BuildCancelled"
+	// ReasonNotificationFailed is recorded when the completion of a build could not be notified.
+	ReasonNotificationFailed = "NotificationFailed"
+	// ReasonStatusReportFailed is recorded when the state of a build could not be reported to GitHub.
+	ReasonStatusReportFailed = "StatusReportFailed"
+	// ReasonBuildTriggered is recorded when a build triggered a build of another project.
+	ReasonBuildTriggered = "BuildTriggered"
+	// ReasonTriggerSkipped is recorded when a trigger was not followed because of a loop.
+	ReasonTriggerSkipped = "TriggerSkipped"
+	// ReasonTriggerFailed is recorded when the build of a trigger could not be created.
+	ReasonTriggerFailed = "TriggerFailed"
+	// ReasonWorkerSyncFailed is recorded when a step run on a completed worker failed
+	// and the worker is requeued.
+	ReasonWorkerSyncFailed = "WorkerSyncFailed"
+	// ReasonInconsistent is recorded when the reconciliation found an orphaned build or pod.
+	ReasonInconsistent = "Inconsistent"
+)
+
+const eventSourceComponent = "brigade-controller"
+
+// deadlineExceeded is the reason of pods that ran longer than their active deadline.
+const deadlineExceeded = "DeadlineExceeded"
+
+// newEventRecorder returns an EventRecorder that writes to the Kubernetes API.
+func newEventRecorder(clientset kubernetes.Interface, namespace string) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(logging.Infof)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events(namespace)})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventSourceComponent})
+}
+
+// recordWorkerTimeout records an event when the worker pod exceeds its deadline.
+func (c *Controller) recordWorkerTimeout(old, new *v1.Pod) {
+	if old.Status.Reason == deadlineExceeded || new.Status.Reason != deadlineExceeded {
+		return
+	}
+	msg := fmt.Sprintf("Worker exceeded the timeout of %s", c.WorkerTimeout)
+	c.recorder.Event(new, v1.EventTypeWarning, ReasonBuildTimedOut, msg)
+	if build := c.buildSecret(new); build != nil {
+		c.recorder.Event(build, v1.EventTypeWarning, ReasonBuildTimedOut, msg)
+	}
+}
+
+// recordWorkerCancelled records an event when a worker pod is deleted before it completed.
+func (c *Controller) recordWorkerCancelled(pod *v1.Pod) {
+	if pod.Status.Phase != v1.PodPending && pod.Status.Phase != v1.PodRunning {
+		return
+	}
+	if workerFailureReason(pod) != "" {
+		// Workers stuck on an infrastructure failure are deleted by the retries.
+		return
+	}
+	build := c.buildSecret(pod)
+	msg := fmt.Sprintf("Worker %s was deleted while %s", pod.Name, pod.Status.Phase)
+	c.recorder.Event(pod, v1.EventTypeWarning, ReasonBuildCancelled, msg)
+	if build != nil {
+		c.recorder.Event(build, v1.EventTypeWarning, ReasonBuildCancelled, msg)
+	}
+}
+
+// buildSecret returns the cached build secret of a worker pod, if any.
+func (c *Controller) buildSecret(pod *v1.Pod) *v1.Secret {
+	key, err := cache.MetaNamespaceKeyFunc(pod)
+	if err != nil {
+		return nil
+	}
+	build, exists, err := c.getSecret(key)
+	if err != nil || !exists {
+		return nil
+	}
+	return build
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+// buildEventReasons returns the reasons of the events recorded about the given object.
+func buildEventReasons(t *testing.T, client *fake.Clientset, kind, name string) []string {
+	events, err := client.CoreV1().Events(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := []string{}
+	for _, e := range events.Items {
+		if e.InvolvedObject.Kind == kind && e.InvolvedObject.Name == name {
+			reasons = append(reasons, e.Reason)
+		}
+	}
+	return reasons
+}
+
+func hasReason(reasons []string, reason string) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestController_Events(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	config := &Config{
+		Namespace:        v1.NamespaceDefault,
+		WorkerImage:      "brigadecore/brigade-worker:latest",
+		WorkerPullPolicy: string(v1.PullIfNotPresent),
+	}
+	controller := NewController(client, config)
+
+	build := v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "moby",
+			Namespace: v1.NamespaceDefault,
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "build",
+				"project":   "ahab",
+				"build":     "queequeg",
+			},
+		},
+	}
+	project := v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "ahab",
+			Namespace: v1.NamespaceDefault,
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "project",
+			},
+		},
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go controller.Run(1, stop)
+
+	client.CoreV1().Secrets(v1.NamespaceDefault).Create(context.TODO(), &build, meta.CreateOptions{})
+	client.CoreV1().Secrets(v1.NamespaceDefault).Create(context.TODO(), &project, meta.CreateOptions{})
+
+	err := wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		reasons := buildEventReasons(t, client, "Secret", build.Name)
+		return hasReason(reasons, ReasonWorkerCreated) && hasReason(reasons, ReasonBuildAccepted), nil
+	})
+	if err != nil {
+		t.Errorf("expected %s and %s events on the build, got %v", ReasonWorkerCreated, ReasonBuildAccepted, buildEventReasons(t, client, "Secret", build.Name))
+	}
+
+	err = wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return hasReason(buildEventReasons(t, client, "Pod", build.Name), ReasonWorkerCreated), nil
+	})
+	if err != nil {
+		t.Errorf("expected a %s event on the worker", ReasonWorkerCreated)
+	}
+}
+
+func TestController_EventsWorkerCreateFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(v1.Resource("pods"), "moby", errors.New("service account not allowed"))
+	})
+	config := &Config{
+		Namespace:        v1.NamespaceDefault,
+		WorkerImage:      "brigadecore/brigade-worker:latest",
+		WorkerPullPolicy: string(v1.PullIfNotPresent),
+	}
+	controller := NewController(client, config)
+
+	build := v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "moby",
+			Namespace: v1.NamespaceDefault,
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "build",
+				"project":   "ahab",
+				"build":     "queequeg",
+			},
+		},
+	}
+	project := v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "ahab",
+			Namespace: v1.NamespaceDefault,
+		},
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go controller.Run(1, stop)
+
+	client.CoreV1().Secrets(v1.NamespaceDefault).Create(context.TODO(), &project, meta.CreateOptions{})
+	client.CoreV1().Secrets(v1.NamespaceDefault).Create(context.TODO(), &build, meta.CreateOptions{})
+
+	err := wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return hasReason(buildEventReasons(t, client, "Secret", build.Name), ReasonWorkerCreateFailed), nil
+	})
+	if err != nil {
+		t.Errorf("expected a %s event on the build", ReasonWorkerCreateFailed)
+	}
+}
+
+func TestRecordWorkerTimeoutAndCancelled(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{
+		Config:   &Config{WorkerTimeout: "1h"},
+		recorder: recorder,
+	}
+	c.createIndexerInformer()
+
+	build := &v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "moby", Namespace: v1.NamespaceDefault}}
+	if err := c.indexer.Add(build); err != nil {
+		t.Fatal(err)
+	}
+
+	running := &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "moby", Namespace: v1.NamespaceDefault},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	timedOut := running.DeepCopy()
+	timedOut.Status.Phase = v1.PodFailed
+	timedOut.Status.Reason = deadlineExceeded
+
+	c.recordWorkerTimeout(running, timedOut)
+	// A second update of a timed out pod must not record the timeout again.
+	c.recordWorkerTimeout(timedOut, timedOut)
+	c.recordWorkerCancelled(timedOut)
+	c.recordWorkerCancelled(running)
+
+	expected := []string{
+		ReasonBuildTimedOut + " Worker exceeded the timeout of 1h",
+		ReasonBuildTimedOut + " Worker exceeded the timeout of 1h",
+		ReasonBuildCancelled + " Worker moby was deleted while Running",
+		ReasonBuildCancelled + " Worker moby was deleted while Running",
+	}
+	for _, want := range expected {
+		select {
+		case got := <-recorder.Events:
+			if !strings.HasSuffix(got, want) {
+				t.Errorf("expected event %q, got %q", want, got)
+			}
+		default:
+			t.Fatalf("expected event %q, got none", want)
+		}
+	}
+	select {
+	case got := <-recorder.Events:
+		t.Errorf("unexpected event %q", got)
+	default:
+	}
+}
@@ -2,20 +2,28 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"log"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apiresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
 
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
+	"github.com/brigadecore/brigade/pkg/tracing"
 )
 
 var (
@@ -35,16 +43,35 @@ func (c *Controller) syncSecret(build *v1.Secret) error {
 	// the system. A build ID should be a ULID.
 	if bid, ok := build.Labels["build"]; !ok || len(bid) == 0 {
 		// Alternately, we could add a build ID and then re-save the secret.
-		log.Printf("syncSecret: secret %s/%s has no build ID. Discarding.", build.Namespace, build.Name)
+		logging.Infof("syncSecret: secret %s/%s has no build ID. Discarding.", build.Namespace, build.Name)
 		return ErrNoBuildID
 	}
+
+	logger := buildLogger(build)
+
+	// Retried builds wait for their backoff to expire before starting.
+	if d := notBefore(build); d > 0 {
+		if key, err := cache.MetaNamespaceKeyFunc(build); err == nil {
+			logger.Infof("syncSecret: delaying build %s by %s", build.Labels["build"], d)
+			c.recorder.Eventf(build, v1.EventTypeNormal, ReasonWorkerDelayed, "Waiting %s before starting the worker of this retry", d.Round(time.Second))
+			c.queue.AddAfter(key, d)
+			return nil
+		}
+	}
 	data := build.Data
 
-	log.Printf("EventHandler: type=%s provider=%s commit=%s", data["event_type"], data["event_provider"], data["commit_id"])
+	logger.Infof("EventHandler: type=%s provider=%s commit=%s", data["event_type"], data["event_provider"], data["commit_id"])
+
+	// Continue the trace of the request that created the build, if any.
+	ctx, span := tracing.Start(tracing.FromAnnotations(context.Background(), build.Annotations), "SyncBuild", trace.WithAttributes(
+		attribute.String(logging.ProjectIDKey, build.Labels["project"]),
+		attribute.String(logging.BuildIDKey, build.Labels["build"]),
+	))
+	defer span.End()
 
 	podClient := c.clientset.CoreV1().Pods(build.Namespace)
 
-	if _, err := podClient.Get(context.TODO(), build.Name, metav1.GetOptions{}); err != nil {
+	if _, err := podClient.Get(ctx, build.Name, metav1.GetOptions{}); err != nil {
 		if !apierrors.IsNotFound(err) {
 			return err
 		}
@@ -55,31 +82,74 @@ func (c *Controller) syncSecret(build *v1.Secret) error {
 		}
 
 		secretClient := c.clientset.CoreV1().Secrets(build.Namespace)
-		project, err := secretClient.Get(context.TODO(), pid, metav1.GetOptions{})
+		project, err := secretClient.Get(ctx, pid, metav1.GetOptions{})
 		if err != nil {
+			c.recorder.Eventf(build, v1.EventTypeWarning, ReasonWorkerCreateFailed, "Error loading project %s: %s", pid, err)
+			span.SetStatus(codes.Error, err.Error())
 			return err
 		}
 
-		pod := NewWorkerPod(build, project, c.Config)
-		if _, err := podClient.Create(context.TODO(), &pod, metav1.CreateOptions{}); err != nil {
+		// The token must be in the build before the worker reads it.
+		if build, err = c.ensureUploadToken(ctx, build); err != nil {
+			c.recorder.Eventf(build, v1.EventTypeWarning, ReasonWorkerCreateFailed, "Error generating the upload token: %s", err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		pod := NewWorkerPod(ctx, build, project, c.Config)
+		created, err := podClient.Create(ctx, &pod, metav1.CreateOptions{})
+		if err != nil {
+			c.recorder.Eventf(build, v1.EventTypeWarning, ReasonWorkerCreateFailed, "Error creating worker pod %s: %s", pod.Name, err)
+			span.SetStatus(codes.Error, err.Error())
 			return err
 		}
-		log.Printf("Started %s for %q [%s] at %d", pod.Name, data["event_type"], data["commit_id"], pod.CreationTimestamp.Unix())
+		logger.Infof("Started %s for %q [%s] at %d", pod.Name, data["event_type"], data["commit_id"], pod.CreationTimestamp.Unix())
+		c.recorder.Eventf(build, v1.EventTypeNormal, ReasonWorkerCreated, "Created worker pod %s", pod.Name)
+		c.recorder.Eventf(created, v1.EventTypeNormal, ReasonWorkerCreated, "Created worker for build %s", build.Labels["build"])
+		c.reportPending(ctx, build, project)
 	}
 
-	return c.updateBuildStatus(build)
+	if err := c.updateBuildStatus(ctx, build); err != nil {
+		return err
+	}
+	c.recorder.Eventf(build, v1.EventTypeNormal, ReasonBuildAccepted, "Build %s accepted", build.Labels["build"])
+	return nil
+}
+
+// ensureUploadToken generates the token the jobs of the build upload artifacts
+// and test results to the API with, and returns the updated build. Builds that
+// already have one are returned as is.
+func (c *Controller) ensureUploadToken(ctx context.Context, build *v1.Secret) (*v1.Secret, error) {
+	if len(build.Data[kube.UploadTokenKey]) > 0 {
+		return build, nil
+	}
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return build, err
+	}
+	buildCopy := build.DeepCopy()
+	if buildCopy.Data == nil {
+		buildCopy.Data = map[string][]byte{}
+	}
+	buildCopy.Data[kube.UploadTokenKey] = []byte(hex.EncodeToString(token))
+	updated, err := c.clientset.CoreV1().Secrets(build.Namespace).Update(ctx, buildCopy, metav1.UpdateOptions{})
+	if err != nil {
+		return build, err
+	}
+	return updated, nil
 }
 
-func (c *Controller) updateBuildStatus(build *v1.Secret) error {
+func (c *Controller) updateBuildStatus(ctx context.Context, build *v1.Secret) error {
 	buildCopy := build.DeepCopy()
 	buildCopy.Labels["status"] = "accepted"
-	_, err := c.clientset.CoreV1().Secrets(build.Namespace).Update(context.TODO(), buildCopy, metav1.UpdateOptions{})
+	_, err := c.clientset.CoreV1().Secrets(build.Namespace).Update(ctx, buildCopy, metav1.UpdateOptions{})
 	return err
 }
 
-// NewWorkerPod returns pod context to create a worker pod
-func NewWorkerPod(build, project *v1.Secret, config *Config) v1.Pod {
-	env := workerEnv(project, build, config)
+// NewWorkerPod returns pod context to create a worker pod. The worker continues
+// the trace of ctx.
+func NewWorkerPod(ctx context.Context, build, project *v1.Secret, config *Config) v1.Pod {
+	env := workerEnv(ctx, project, build, config)
 
 	cmd := []string{"yarn", "-s", "start"}
 	if config.WorkerCommand != "" {
@@ -157,7 +227,7 @@ func NewWorkerPod(build, project *v1.Secret, config *Config) v1.Pod {
 			Command:         cmd,
 			VolumeMounts:    volumeMounts,
 			Env:             env,
-			Resources:       workerResources(config),
+			Resources:       workerResources(project, config),
 		}},
 		InitContainers: initContainers,
 		Volumes:        volumes,
@@ -182,6 +252,11 @@ func NewWorkerPod(build, project *v1.Secret, config *Config) v1.Pod {
 		spec.ImagePullSecrets = refs
 	}
 
+	if d, err := time.ParseDuration(config.WorkerTimeout); err == nil && d > 0 {
+		deadline := int64(d.Seconds())
+		spec.ActiveDeadlineSeconds = &deadline
+	}
+
 	return v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   build.Name,
@@ -229,7 +304,7 @@ func workerImageConfig(project *v1.Secret, config *Config) (string, string) {
 	return image, pullPolicy
 }
 
-func workerEnv(project, build *v1.Secret, config *Config) []v1.EnvVar {
+func workerEnv(ctx context.Context, project, build *v1.Secret, config *Config) []v1.EnvVar {
 	allowSecretKeyRef := false
 	// older projects won't have allowSecretKeyRef set so just check for it
 	if string(project.Data["kubernetes.allowSecretKeyRef"]) != "" {
@@ -237,7 +312,7 @@ func workerEnv(project, build *v1.Secret, config *Config) []v1.EnvVar {
 		allowSecretKeyRef, err = strconv.ParseBool(string(project.Data["kubernetes.allowSecretKeyRef"]))
 		if err != nil {
 			// if we errored parsing the bool something is wrong so just log it and ignore what the project set
-			log.Printf("error parsing allowSecretKeyRef in project %s: %s", project.Annotations["projectName"], err)
+			logging.Errorf("error parsing allowSecretKeyRef in project %s: %s", project.Annotations["projectName"], err)
 		}
 	}
 
@@ -290,6 +365,10 @@ func workerEnv(project, build *v1.Secret, config *Config) []v1.EnvVar {
 			Name:      "BRIGADE_REPO_AUTH_TOKEN",
 			ValueFrom: secretRef("github.token", project),
 		},
+		{
+			Name:      "BRIGADE_UPLOAD_TOKEN",
+			ValueFrom: secretRef(kube.UploadTokenKey, build),
+		},
 		{Name: "BRIGADE_DEFAULT_BUILD_STORAGE_CLASS", Value: config.DefaultBuildStorageClass},
 		{Name: "BRIGADE_DEFAULT_CACHE_STORAGE_CLASS", Value: config.DefaultCacheStorageClass},
 	}
@@ -298,10 +377,18 @@ func workerEnv(project, build *v1.Secret, config *Config) []v1.EnvVar {
 		envs = append(envs, v1.EnvVar{Name: "BRIGADE_SERVICE_ACCOUNT_REGEX", Value: config.ProjectServiceAccountRegex})
 	}
 
+	// TRACEPARENT and TRACESTATE let the worker continue the trace of the build.
+	traceEnv := tracing.Env(ctx)
+	for _, name := range []string{"TRACEPARENT", "TRACESTATE"} {
+		if value, ok := traceEnv[name]; ok {
+			envs = append(envs, v1.EnvVar{Name: name, Value: value})
+		}
+	}
+
 	brigadejsPath := psv.String("brigadejsPath")
 	if brigadejsPath != "" {
 		if filepath.IsAbs(brigadejsPath) {
-			log.Printf("Warning: 'brigadejsPath' is set on Project Secret but will be ignored because provided path '%s' is an absolute path", brigadejsPath)
+			logging.Warnf("'brigadejsPath' is set on Project Secret but will be ignored because provided path '%s' is an absolute path", brigadejsPath)
 		} else {
 			envs = append(envs, v1.EnvVar{Name: "BRIGADE_SCRIPT", Value: filepath.Join("/vcs", brigadejsPath)})
 		}
@@ -310,7 +397,7 @@ func workerEnv(project, build *v1.Secret, config *Config) []v1.EnvVar {
 	brigadeConfigPath := psv.String("brigadeConfigPath")
 	if brigadeConfigPath != "" {
 		if filepath.IsAbs(brigadeConfigPath) {
-			log.Printf("Warning: 'brigadeConfigPath' is set on Project Secret but will be ignored because provided path '%s' is an absolute path", brigadeConfigPath)
+			logging.Warnf("'brigadeConfigPath' is set on Project Secret but will be ignored because provided path '%s' is an absolute path", brigadeConfigPath)
 		} else {
 			envs = append(envs, v1.EnvVar{Name: "BRIGADE_CONFIG", Value: filepath.Join("/vcs", brigadeConfigPath)})
 		}
@@ -319,9 +406,12 @@ func workerEnv(project, build *v1.Secret, config *Config) []v1.EnvVar {
 	return envs
 }
 
-// workerResources generates the resources for the worker, given in the configuration
-// If the value is not given, or it's wrong, empty resources gill be returned
-func workerResources(config *Config) v1.ResourceRequirements {
+// workerResources generates the resources for the worker.
+// Values set on the project take precedence over the ones given in the configuration.
+// Project values that exceed the configured maximum are capped to that maximum,
+// and requests that exceed the resulting limits are capped to those limits.
+// If the value is not given, or it's wrong, empty resources will be returned
+func workerResources(project *v1.Secret, config *Config) v1.ResourceRequirements {
 	resources := v1.ResourceRequirements{
 		Limits:   v1.ResourceList{},
 		Requests: v1.ResourceList{},
@@ -340,9 +430,42 @@ func workerResources(config *Config) v1.ResourceRequirements {
 		resources.Requests[v1.ResourceMemory] = v
 	}
 
+	projectResource(project, "worker.limits.cpu", config.WorkerMaxCPU, v1.ResourceCPU, resources.Limits)
+	projectResource(project, "worker.limits.memory", config.WorkerMaxMemory, v1.ResourceMemory, resources.Limits)
+	projectResource(project, "worker.requests.cpu", config.WorkerMaxCPU, v1.ResourceCPU, resources.Requests)
+	projectResource(project, "worker.requests.memory", config.WorkerMaxMemory, v1.ResourceMemory, resources.Requests)
+
+	// Kubernetes rejects pods whose requests exceed their limits, so requests are
+	// capped to the effective limit.
+	for name, limit := range resources.Limits {
+		if request, ok := resources.Requests[name]; ok && request.Cmp(limit) > 0 {
+			logging.Warnf("worker request of %s %s in project %s exceeds the limit of %s, using the limit", name, request.String(), project.Annotations["projectName"], limit.String())
+			resources.Requests[name] = limit
+		}
+	}
+
 	return resources
 }
 
+// projectResource sets the quantity stored under key in the project on the given list.
+// If max can be parsed and the project quantity is greater, max is used instead.
+func projectResource(project *v1.Secret, key, max string, name v1.ResourceName, list v1.ResourceList) {
+	givenValue, ok := project.Data[key]
+	if !ok || len(givenValue) == 0 {
+		return
+	}
+	v, err := apiresource.ParseQuantity(string(givenValue))
+	if err != nil {
+		logging.Errorf("error parsing %s in project %s: %s", key, project.Annotations["projectName"], err)
+		return
+	}
+	if m, err := apiresource.ParseQuantity(max); err == nil && v.Cmp(m) > 0 {
+		logging.Warnf("%s %s in project %s exceeds the maximum of %s, using the maximum", key, v.String(), project.Annotations["projectName"], m.String())
+		v = m
+	}
+	list[name] = v
+}
+
 // vcsSidecarResources generates the resources for the init-container in the worker
 // If the value is not given, or it's wrong, empty resources gill be returned
 func vcsSidecarResources(project *v1.Secret) v1.ResourceRequirements {
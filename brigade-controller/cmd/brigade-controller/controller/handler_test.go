@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"context"
 	"strings"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/tracing"
 )
 
 func TestNewWorkerPod_Defaults(t *testing.T) {
@@ -18,7 +23,7 @@ func TestNewWorkerPod_Defaults(t *testing.T) {
 		Namespace: v1.NamespaceDefault,
 	}
 
-	pod := NewWorkerPod(build, proj, config)
+	pod := NewWorkerPod(context.Background(), build, proj, config)
 
 	spec := pod.Spec
 	if spec.NodeSelector["beta.kubernetes.io/os"] != "linux" {
@@ -76,7 +81,7 @@ func TestNewWorkerPod_NoSidecar(t *testing.T) {
 		Namespace: v1.NamespaceDefault,
 	}
 
-	pod := NewWorkerPod(build, proj, config)
+	pod := NewWorkerPod(context.Background(), build, proj, config)
 
 	spec := pod.Spec
 	sidecarVolumeExists := false
@@ -154,7 +159,7 @@ func TestNewWorkerPod_WorkerEnv_ServiceAccount(t *testing.T) {
 			}
 			config := &tc.config
 
-			pod := NewWorkerPod(build, proj, config)
+			pod := NewWorkerPod(context.Background(), build, proj, config)
 
 			spec := pod.Spec
 			saEnvFound := false
@@ -188,3 +193,150 @@ func TestNewWorkerPod_WorkerEnv_ServiceAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestNewWorkerPod_ProjectWorkerResources(t *testing.T) {
+	testcases := []struct {
+		name         string
+		data         map[string][]byte
+		config       Config
+		wantRequests v1.ResourceList
+		wantLimits   v1.ResourceList
+	}{
+		{"controller defaults",
+			map[string][]byte{},
+			Config{
+				WorkerRequestsCPU:    "100m",
+				WorkerRequestsMemory: "100Mi",
+				WorkerLimitsCPU:      "200m",
+				WorkerLimitsMemory:   "200Mi",
+			},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("100Mi")},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m"), v1.ResourceMemory: resource.MustParse("200Mi")},
+		},
+		{"project override",
+			map[string][]byte{
+				"worker.requests.cpu":    []byte("500m"),
+				"worker.requests.memory": []byte("500Mi"),
+				"worker.limits.cpu":      []byte("1"),
+				"worker.limits.memory":   []byte("1Gi"),
+			},
+			Config{
+				WorkerRequestsCPU:    "100m",
+				WorkerRequestsMemory: "100Mi",
+				WorkerLimitsCPU:      "200m",
+				WorkerLimitsMemory:   "200Mi",
+			},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("500Mi")},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		{"partial project override with invalid value",
+			map[string][]byte{
+				"worker.requests.cpu":    []byte("not-a-quantity"),
+				"worker.requests.memory": []byte("500Mi"),
+			},
+			Config{
+				WorkerRequestsCPU: "100m",
+			},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("500Mi")},
+			v1.ResourceList{},
+		},
+		{"project values capped to maximum",
+			map[string][]byte{
+				"worker.requests.cpu":    []byte("500m"),
+				"worker.requests.memory": []byte("500Mi"),
+				"worker.limits.cpu":      []byte("4"),
+				"worker.limits.memory":   []byte("8Gi"),
+			},
+			Config{
+				WorkerMaxCPU:    "2",
+				WorkerMaxMemory: "2Gi",
+			},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("500Mi")},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("2Gi")},
+		},
+		{"project requests capped to controller limits",
+			map[string][]byte{
+				"worker.requests.cpu":    []byte("500m"),
+				"worker.requests.memory": []byte("100Mi"),
+			},
+			Config{
+				WorkerLimitsCPU:    "200m",
+				WorkerLimitsMemory: "200Mi",
+			},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m"), v1.ResourceMemory: resource.MustParse("100Mi")},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m"), v1.ResourceMemory: resource.MustParse("200Mi")},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			build := &v1.Secret{}
+			proj := &v1.Secret{
+				Data: tc.data,
+			}
+
+			pod := NewWorkerPod(context.Background(), build, proj, &tc.config)
+
+			resources := pod.Spec.Containers[0].Resources
+			assertResourceList(t, "requests", resources.Requests, tc.wantRequests)
+			assertResourceList(t, "limits", resources.Limits, tc.wantLimits)
+		})
+	}
+}
+
+func TestNewWorkerPod_WorkerTimeout(t *testing.T) {
+	build := &v1.Secret{}
+	proj := &v1.Secret{}
+
+	pod := NewWorkerPod(context.Background(), build, proj, &Config{})
+	if pod.Spec.ActiveDeadlineSeconds != nil {
+		t.Errorf("expected no deadline, got %d", *pod.Spec.ActiveDeadlineSeconds)
+	}
+
+	pod = NewWorkerPod(context.Background(), build, proj, &Config{WorkerTimeout: "1h30m"})
+	if d := pod.Spec.ActiveDeadlineSeconds; d == nil || *d != 5400 {
+		t.Errorf("expected a deadline of 5400 seconds, got %v", d)
+	}
+}
+
+func assertResourceList(t *testing.T, kind string, got, want v1.ResourceList) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("expected %d %s, got %d", len(want), kind, len(got))
+	}
+	for name, w := range want {
+		if g, ok := got[name]; !ok || g.Cmp(w) != 0 {
+			t.Errorf("expected %s %s to be %s, got %s", kind, name, w.String(), g.String())
+		}
+	}
+}
+
+func TestNewWorkerPod_TraceParent(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	build := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"brigade.sh/traceparent": traceparent},
+		},
+	}
+	proj := &v1.Secret{}
+
+	pod := NewWorkerPod(context.Background(), build, proj, &Config{})
+	if env := workerEnvVar(pod, "TRACEPARENT"); env != nil {
+		t.Errorf("expected no TRACEPARENT without a trace, got %q", env.Value)
+	}
+
+	ctx := tracing.FromAnnotations(context.Background(), build.Annotations)
+	pod = NewWorkerPod(ctx, build, proj, &Config{})
+	if env := workerEnvVar(pod, "TRACEPARENT"); env == nil || env.Value != traceparent {
+		t.Errorf("expected TRACEPARENT %q, got %v", traceparent, env)
+	}
+}
+
+func workerEnvVar(pod v1.Pod, name string) *v1.EnvVar {
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.Name == name {
+			return &env
+		}
+	}
+	return nil
+}
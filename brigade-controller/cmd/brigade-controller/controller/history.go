@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// RecordHistoryTo makes the controller copy the state of every build and its
+// jobs to a history store whenever its worker changes.
+func (c *Controller) RecordHistoryTo(recorder storage.HistoryRecorder) {
+	c.history = recorder
+}
+
+// recordHistory records the build of a worker pod and its jobs.
+func (c *Controller) recordHistory(pod *v1.Pod) error {
+	if c.history == nil {
+		return nil
+	}
+	ctx := context.TODO()
+	build, err := c.snapshots.GetBuild(ctx, pod.Labels["build"])
+	if build == nil {
+		// The build secret is gone, there is nothing left to record.
+		logging.With(logging.ProjectIDKey, pod.Labels["project"]).
+			With(logging.BuildIDKey, pod.Labels["build"]).
+			Warnf("not recording build %s: %s", pod.Labels["build"], err)
+		return nil
+	}
+	if err := c.history.RecordBuild(ctx, build); err != nil {
+		return err
+	}
+	jobs, err := c.snapshots.GetBuildJobs(ctx, build)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := c.history.RecordJob(ctx, build.ID, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
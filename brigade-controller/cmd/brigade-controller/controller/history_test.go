@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// historyRecorder records the builds and jobs in memory.
+type historyRecorder struct {
+	builds map[string]*brigade.Build
+	jobs   map[string]string
+}
+
+func (r *historyRecorder) RecordBuild(ctx context.Context, build *brigade.Build) error {
+	r.builds[build.ID] = build
+	return nil
+}
+
+func (r *historyRecorder) RecordJob(ctx context.Context, buildID string, job *brigade.Job) error {
+	r.jobs[job.ID] = buildID
+	return nil
+}
+
+// failingRecorder fails to record anything, like a history that is down.
+type failingRecorder struct{}
+
+func (failingRecorder) RecordBuild(ctx context.Context, build *brigade.Build) error {
+	return errors.New("history is down")
+}
+
+func (failingRecorder) RecordJob(ctx context.Context, buildID string, job *brigade.Job) error {
+	return errors.New("history is down")
+}
+
+func TestController_RecordHistory(t *testing.T) {
+	labels := func(component string) map[string]string {
+		return map[string]string{
+			"heritage":  "brigade",
+			"component": component,
+			"project":   "ahab",
+			"build":     "queequeg",
+		}
+	}
+	started := meta.Now()
+	worker := &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels("build")},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "brigade-runner"}}},
+		Status:     v1.PodStatus{Phase: v1.PodSucceeded, StartTime: &started},
+	}
+	client := fake.NewSimpleClientset(
+		&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels("build")}},
+		worker,
+		&v1.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "test-queequeg", Namespace: v1.NamespaceDefault, Labels: labels("job")},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "test", Image: "alpine"}}},
+			Status:     v1.PodStatus{Phase: v1.PodSucceeded, StartTime: &started},
+		},
+	)
+	controller := NewController(client, &Config{Namespace: v1.NamespaceDefault})
+
+	// Nothing is recorded without a history.
+	if err := controller.recordHistory(worker); err != nil {
+		t.Fatal(err)
+	}
+
+	history := &historyRecorder{builds: map[string]*brigade.Build{}, jobs: map[string]string{}}
+	controller.RecordHistoryTo(history)
+	if err := controller.recordHistory(worker); err != nil {
+		t.Fatal(err)
+	}
+	build, ok := history.builds["queequeg"]
+	if !ok {
+		t.Fatal("expected the build to be recorded")
+	}
+	if build.Worker == nil || build.Worker.Status != brigade.JobSucceeded {
+		t.Errorf("expected the worker to be recorded with the build, got %#v", build.Worker)
+	}
+	if history.jobs["test-queequeg"] != "queequeg" {
+		t.Errorf("expected the job to be recorded, got %v", history.jobs)
+	}
+}
@@ -2,13 +2,14 @@ package controller
 
 import (
 	"context"
-	"log"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
+
+	"github.com/brigadecore/brigade/pkg/logging"
 )
 
 func (c *Controller) createIndexerInformer() {
@@ -29,7 +30,7 @@ func (c *Controller) createIndexerInformer() {
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
 				if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
-					log.Println("Adding to workqueue: ", key)
+					logging.Debugf("Adding to workqueue: %s", key)
 					c.queue.Add(key)
 				}
 			},
@@ -37,3 +38,40 @@ func (c *Controller) createIndexerInformer() {
 		cache.Indexers{},
 	)
 }
+
+// createWorkerInformer watches the worker pods so that failed workers can be retried.
+func (c *Controller) createWorkerInformer() {
+	selector := "heritage=brigade,component=build"
+	enqueue := func(obj interface{}) {
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+			c.workerQueue.Add(key)
+		}
+	}
+	c.workerIndexer, c.workerInformer = cache.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = selector
+				return c.clientset.CoreV1().Pods(c.Namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = selector
+				return c.clientset.CoreV1().Pods(c.Namespace).Watch(context.TODO(), options)
+			},
+		},
+		&v1.Pod{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: enqueue,
+			UpdateFunc: func(old, new interface{}) {
+				c.recordWorkerTimeout(old.(*v1.Pod), new.(*v1.Pod))
+				enqueue(new)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if pod, ok := obj.(*v1.Pod); ok {
+					c.recordWorkerCancelled(pod)
+				}
+			},
+		},
+		cache.Indexers{},
+	)
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	reconcileRuns = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "brigade",
+		Subsystem: "controller",
+		Name:      "reconcile_runs_total",
+		Help:      "Number of reconciliation passes run by the controller.",
+	})
+	reconcileLastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "brigade",
+		Subsystem: "controller",
+		Name:      "reconcile_last_run_timestamp_seconds",
+		Help:      "Time of the last reconciliation pass.",
+	})
+	reconcileFound = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "brigade",
+		Subsystem: "controller",
+		Name:      "reconcile_inconsistencies",
+		Help:      "Number of inconsistencies found by the last reconciliation pass, by kind.",
+	}, []string{"kind"})
+	reconcileActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "brigade",
+		Subsystem: "controller",
+		Name:      "reconcile_actions_total",
+		Help:      "Number of actions taken on inconsistencies, by kind and action.",
+	}, []string{"kind", "action"})
+)
+
+func init() {
+	prometheus.MustRegister(reconcileRuns, reconcileLastRun, reconcileFound, reconcileActions)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/notify"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+// notifiedAnnotation is set on build secrets once the completion of the build
+// was notified.
+const notifiedAnnotation = "notified"
+
+// NotifyBuilds makes the controller notify the completion of every build to
+// the sinks of the notification rules of its project. Emails are sent through
+// the given SMTP server. Each notification is given up after the timeout, so
+// that a sink that does not answer does not hold up the other builds.
+func (c *Controller) NotifyBuilds(smtp notify.SMTPConfig, timeout time.Duration) {
+	c.notifier = &notify.Notifier{
+		Store:   c.store,
+		Client:  &http.Client{Timeout: timeout},
+		SMTP:    smtp,
+		Timeout: timeout,
+	}
+}
+
+// notifyWorker notifies the build of a completed worker pod, unless it was
+// already notified or will be retried, in which case its retry is notified.
+//
+// Notifications that fail are logged and recorded as events, but are not
+// attempted again so that the sinks that succeeded are not notified twice.
+func (c *Controller) notifyWorker(pod *v1.Pod) error {
+	if c.notifier == nil || (pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed) {
+		return nil
+	}
+	ctx := context.TODO()
+	secretClient := c.clientset.CoreV1().Secrets(pod.Namespace)
+	build, err := secretClient.Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if build.Annotations[notifiedAnnotation] != "" || build.Annotations[kube.RetriedByAnnotation] != "" {
+		return nil
+	}
+
+	log := buildLogger(build)
+	project, err := secretClient.Get(ctx, build.Labels["project"], metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	proj, err := kube.NewProjectFromSecret(project, pod.Namespace)
+	if err != nil {
+		log.Warnf("not notifying build %s: %s", build.Labels["build"], err)
+		return nil
+	}
+	if len(proj.Notifications) == 0 {
+		return nil
+	}
+	if reason := workerFailureReason(pod); reason != "" && proj.Retry.Retries(reason, buildAttempt(build)) {
+		return nil
+	}
+	b, err := c.store.GetBuild(ctx, build.Labels["build"])
+	if err != nil {
+		return err
+	}
+
+	if err := c.notifier.NotifyBuild(ctx, proj, b); err != nil {
+		log.Errorf("%s", err)
+		c.recorder.Event(build, v1.EventTypeWarning, ReasonNotificationFailed, err.Error())
+	} else {
+		log.Infof("Notified build %s", b.ID)
+	}
+
+	buildCopy := build.DeepCopy()
+	if buildCopy.Annotations == nil {
+		buildCopy.Annotations = map[string]string{}
+	}
+	buildCopy.Annotations[notifiedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	_, err = secretClient.Update(ctx, buildCopy, metav1.UpdateOptions{})
+	return err
+}
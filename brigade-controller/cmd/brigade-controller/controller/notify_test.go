@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/notify"
+)
+
+func TestController_NotifyWorker(t *testing.T) {
+	var notified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notified++
+	}))
+	defer ts.Close()
+
+	labels := func(component string) map[string]string {
+		return map[string]string{
+			"heritage":  "brigade",
+			"component": component,
+			"project":   "ahab",
+			"build":     "queequeg",
+		}
+	}
+	started := meta.Now()
+	worker := &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels("build")},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "brigade-runner"}}},
+		Status:     v1.PodStatus{Phase: v1.PodFailed, StartTime: &started},
+	}
+	client := fake.NewSimpleClientset(
+		&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels("build")}},
+		&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "ahab",
+				Namespace:   v1.NamespaceDefault,
+				Annotations: map[string]string{"projectName": "ahab"},
+				Labels:      map[string]string{"heritage": "brigade", "component": "project"},
+			},
+			Data: map[string][]byte{
+				"notifications": []byte(`[{"webhook": {"url": "` + ts.URL + `"}}]`),
+			},
+		},
+		worker,
+	)
+	controller := NewController(client, &Config{Namespace: v1.NamespaceDefault})
+
+	// Nothing is notified unless notifications are enabled.
+	if err := controller.notifyWorker(worker); err != nil {
+		t.Fatal(err)
+	}
+	if notified != 0 {
+		t.Fatalf("expected no notification, got %d", notified)
+	}
+
+	controller.NotifyBuilds(notify.SMTPConfig{}, notify.DefaultTimeout)
+	for i := 0; i < 2; i++ {
+		if err := controller.notifyWorker(worker); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if notified != 1 {
+		t.Errorf("expected the build to be notified once, got %d", notified)
+	}
+	build, err := client.CoreV1().Secrets(v1.NamespaceDefault).Get(context.TODO(), worker.Name, meta.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.Annotations[notifiedAnnotation] == "" {
+		t.Error("expected the build to be annotated as notified")
+	}
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+// These are the policies the reconciliation pass applies to inconsistencies.
+const (
+	// ReconcilePolicyMark only reports inconsistencies and labels the objects.
+	ReconcilePolicyMark = "mark"
+	// ReconcilePolicyRepair restarts builds without a worker and deletes pods
+	// that cannot make progress anymore.
+	ReconcilePolicyRepair = "repair"
+	// ReconcilePolicyClean deletes every object involved in an inconsistency.
+	ReconcilePolicyClean = "clean"
+)
+
+// ReconcilePolicies lists the supported reconciliation policies.
+var ReconcilePolicies = []string{ReconcilePolicyMark, ReconcilePolicyRepair, ReconcilePolicyClean}
+
+// These are the kinds of inconsistencies found by the reconciliation pass.
+const (
+	inconsistencyBuildWithoutWorker = "build_without_worker"
+	inconsistencyWorkerWithoutBuild = "worker_without_build"
+	inconsistencyJobWithoutWorker   = "job_without_worker"
+)
+
+// orphanedLabel is set on the objects marked by the reconciliation pass.
+const orphanedLabel = "orphaned"
+
+// completedAnnotation is set on a build secret by the controller when its
+// worker completes, so that a build whose worker pod was deleted afterwards is
+// not mistaken for a build that lost its worker.
+const completedAnnotation = "completed"
+
+// reconcileGracePeriod is the minimum age of the objects the reconciliation
+// pass looks at, so that it does not race with builds that are being started.
+var reconcileGracePeriod = 2 * time.Minute
+
+// reconcile looks for builds and pods that are inconsistent with each other
+// and marks, repairs or cleans them according to the reconcile policy.
+func (c *Controller) reconcile() {
+	reconcileRuns.Inc()
+	defer reconcileLastRun.SetToCurrentTime()
+
+	opts := metav1.ListOptions{LabelSelector: "heritage=brigade,component in (build, job)"}
+	secrets, err := c.clientset.CoreV1().Secrets(c.Namespace).List(context.TODO(), opts)
+	if err != nil {
+		logging.Errorf("reconcile: failed to list builds: %s", err)
+		return
+	}
+	pods, err := c.clientset.CoreV1().Pods(c.Namespace).List(context.TODO(), opts)
+	if err != nil {
+		logging.Errorf("reconcile: failed to list pods: %s", err)
+		return
+	}
+
+	builds := map[string]*v1.Secret{}
+	for i := range secrets.Items {
+		if secrets.Items[i].Labels["component"] == "build" {
+			builds[secrets.Items[i].Name] = &secrets.Items[i]
+		}
+	}
+	workers := map[string]*v1.Pod{}
+	for i := range pods.Items {
+		if pods.Items[i].Labels["component"] == "build" {
+			workers[pods.Items[i].Name] = &pods.Items[i]
+		}
+	}
+
+	found := map[string]int{
+		inconsistencyBuildWithoutWorker: 0,
+		inconsistencyWorkerWithoutBuild: 0,
+		inconsistencyJobWithoutWorker:   0,
+	}
+	for _, build := range builds {
+		if build.Labels["status"] != "accepted" || !c.reconcilable(build) {
+			continue
+		}
+		// The worker of a retried or completed build may have been deleted on
+		// purpose.
+		if _, ok := workers[build.Name]; ok || build.Annotations[kube.RetriedByAnnotation] != "" || completed(build) {
+			continue
+		}
+		found[inconsistencyBuildWithoutWorker]++
+		c.reconcileObject(inconsistencyBuildWithoutWorker, build, fmt.Sprintf("Build %s was accepted but has no worker pod", build.Labels["build"]))
+	}
+	for _, pod := range workers {
+		if _, ok := builds[pod.Name]; ok || !c.reconcilable(pod) {
+			continue
+		}
+		found[inconsistencyWorkerWithoutBuild]++
+		c.reconcileObject(inconsistencyWorkerWithoutBuild, pod, fmt.Sprintf("Worker %s has no build secret", pod.Name))
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Labels["component"] != "job" || !c.reconcilable(pod) {
+			continue
+		}
+		worker := pod.Labels["worker"]
+		if worker == "" {
+			worker = "brigade-worker-" + pod.Labels["build"]
+		}
+		if _, ok := workers[worker]; ok {
+			continue
+		}
+		found[inconsistencyJobWithoutWorker]++
+		c.reconcileObject(inconsistencyJobWithoutWorker, pod, fmt.Sprintf("Job %s has no worker pod %s", pod.Name, worker))
+	}
+
+	for kind, n := range found {
+		reconcileFound.WithLabelValues(kind).Set(float64(n))
+	}
+	logging.Infof("reconcile: %d builds without worker, %d workers without build, %d jobs without worker (policy %s)",
+		found[inconsistencyBuildWithoutWorker], found[inconsistencyWorkerWithoutBuild], found[inconsistencyJobWithoutWorker], c.reconcilePolicy())
+}
+
+// completed returns true if the worker of the build completed. Builds that
+// completed before completedAnnotation was introduced are recognized by the
+// annotations of the other steps run on completion.
+func completed(build *v1.Secret) bool {
+	for _, a := range []string{completedAnnotation, archivedAnnotation, notifiedAnnotation, statusReportedAnnotation, triggeredAnnotation} {
+		if build.Annotations[a] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// markCompleted marks the build of a completed worker pod with
+// completedAnnotation, unless it was already marked.
+func (c *Controller) markCompleted(pod *v1.Pod) error {
+	if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+		return nil
+	}
+	secretClient := c.clientset.CoreV1().Secrets(pod.Namespace)
+	build, err := secretClient.Get(context.TODO(), pod.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if build.Annotations[completedAnnotation] != "" {
+		return nil
+	}
+	buildCopy := build.DeepCopy()
+	if buildCopy.Annotations == nil {
+		buildCopy.Annotations = map[string]string{}
+	}
+	buildCopy.Annotations[completedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	_, err = secretClient.Update(context.TODO(), buildCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// reconcilable returns true if the object is old enough to be reconciled.
+func (c *Controller) reconcilable(obj metav1.Object) bool {
+	return time.Since(obj.GetCreationTimestamp().Time) >= reconcileGracePeriod
+}
+
+// reconcilePolicy returns the configured reconcile policy.
+func (c *Controller) reconcilePolicy() string {
+	if c.ReconcilePolicy == "" {
+		return ReconcilePolicyMark
+	}
+	return c.ReconcilePolicy
+}
+
+// reconcileObject applies the reconcile policy to an inconsistent build
+// secret or pod.
+func (c *Controller) reconcileObject(kind string, obj runtime.Object, msg string) {
+	var action string
+	var err error
+	switch policy := c.reconcilePolicy(); {
+	case policy == ReconcilePolicyMark:
+		action = "marked"
+		var marked bool
+		if marked, err = c.markOrphaned(obj); err == nil && !marked {
+			// Already reported by a previous pass.
+			return
+		}
+	case kind == inconsistencyBuildWithoutWorker && policy == ReconcilePolicyRepair:
+		action = "repaired"
+		err = c.restartBuild(obj.(*v1.Secret))
+	case kind == inconsistencyBuildWithoutWorker:
+		action = "cleaned"
+		build := obj.(*v1.Secret)
+		err = c.store.DeleteBuild(context.TODO(), build.Labels["build"], storage.DeleteBuildOptions{})
+	default:
+		action = "cleaned"
+		if policy == ReconcilePolicyRepair {
+			action = "repaired"
+		}
+		pod := obj.(*v1.Pod)
+		err = c.clientset.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		logging.Errorf("reconcile: %s: failed to apply policy %s: %s", msg, c.reconcilePolicy(), err)
+		reconcileActions.WithLabelValues(kind, "failed").Inc()
+		return
+	}
+	logging.Infof("reconcile: %s (%s)", msg, action)
+	reconcileActions.WithLabelValues(kind, action).Inc()
+	if action != "cleaned" {
+		c.recorder.Eventf(obj, v1.EventTypeWarning, ReasonInconsistent, "%s (%s)", msg, action)
+	}
+}
+
+// markOrphaned labels the object as orphaned. It returns false if the object
+// was already marked.
+func (c *Controller) markOrphaned(obj runtime.Object) (bool, error) {
+	var err error
+	switch o := obj.(type) {
+	case *v1.Secret:
+		if o.Labels[orphanedLabel] == "true" {
+			return false, nil
+		}
+		o = o.DeepCopy()
+		o.Labels[orphanedLabel] = "true"
+		_, err = c.clientset.CoreV1().Secrets(o.Namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
+	case *v1.Pod:
+		if o.Labels[orphanedLabel] == "true" {
+			return false, nil
+		}
+		o = o.DeepCopy()
+		o.Labels[orphanedLabel] = "true"
+		_, err = c.clientset.CoreV1().Pods(o.Namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
+	}
+	return err == nil, err
+}
+
+// restartBuild starts a new worker for an accepted build that lost its worker.
+func (c *Controller) restartBuild(build *v1.Secret) error {
+	buildCopy := build.DeepCopy()
+	delete(buildCopy.Labels, "status")
+	delete(buildCopy.Labels, orphanedLabel)
+	return c.syncSecret(buildCopy)
+}
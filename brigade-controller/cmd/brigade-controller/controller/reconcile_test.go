@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func reconcileFixtures() []runtime.Object {
+	labels := func(component, build string, extra ...string) map[string]string {
+		l := map[string]string{"heritage": "brigade", "component": component, "project": "ahab", "build": build}
+		for i := 0; i+1 < len(extra); i += 2 {
+			l[extra[i]] = extra[i+1]
+		}
+		return l
+	}
+	secret := func(name string, labels map[string]string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: v1.NamespaceDefault, Labels: labels},
+			Type:       "brigade.sh/build",
+		}
+	}
+	pod := func(name string, labels map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: meta.ObjectMeta{Name: name, Namespace: v1.NamespaceDefault, Labels: labels}}
+	}
+	return []runtime.Object{
+		&v1.Secret{ObjectMeta: meta.ObjectMeta{
+			Name:        "ahab",
+			Namespace:   v1.NamespaceDefault,
+			Annotations: map[string]string{"projectName": "ahab"},
+			Labels:      map[string]string{"heritage": "brigade", "component": "project"},
+		}},
+		// A consistent build with a job.
+		secret("brigade-worker-queequeg", labels("build", "queequeg", "status", "accepted")),
+		pod("brigade-worker-queequeg", labels("build", "queequeg")),
+		pod("queequeg-job", labels("job", "queequeg", "worker", "brigade-worker-queequeg")),
+		// An accepted build that lost its worker.
+		secret("brigade-worker-starbuck", labels("build", "starbuck", "status", "accepted")),
+		// A completed build whose worker was deleted.
+		&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "brigade-worker-ishmael",
+				Namespace:   v1.NamespaceDefault,
+				Labels:      labels("build", "ishmael", "status", "accepted"),
+				Annotations: map[string]string{completedAnnotation: "2020-06-01T15:04:05Z"},
+			},
+			Type: "brigade.sh/build",
+		},
+		// A build that is not accepted yet.
+		secret("brigade-worker-stubb", labels("build", "stubb")),
+		// A worker without a build.
+		pod("brigade-worker-flask", labels("build", "flask")),
+		// A job without a worker.
+		pod("pip-job", labels("job", "pip", "worker", "brigade-worker-pip")),
+	}
+}
+
+func TestController_Reconcile(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		secrets map[string]string
+		pods    map[string]string
+	}{
+		{
+			"mark",
+			ReconcilePolicyMark,
+			map[string]string{"brigade-worker-queequeg": "", "brigade-worker-starbuck": "true", "brigade-worker-ishmael": "", "brigade-worker-stubb": ""},
+			map[string]string{"brigade-worker-queequeg": "", "queequeg-job": "", "brigade-worker-flask": "true", "pip-job": "true"},
+		},
+		{
+			"repair",
+			ReconcilePolicyRepair,
+			map[string]string{"brigade-worker-queequeg": "", "brigade-worker-starbuck": "", "brigade-worker-ishmael": "", "brigade-worker-stubb": ""},
+			map[string]string{"brigade-worker-queequeg": "", "queequeg-job": "", "brigade-worker-starbuck": ""},
+		},
+		{
+			"clean",
+			ReconcilePolicyClean,
+			map[string]string{"brigade-worker-queequeg": "", "brigade-worker-ishmael": "", "brigade-worker-stubb": ""},
+			map[string]string{"brigade-worker-queequeg": "", "queequeg-job": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(reconcileFixtures()...)
+			controller := NewController(client, &Config{
+				Namespace:       v1.NamespaceDefault,
+				WorkerImage:     "brigadecore/brigade-worker:latest",
+				ReconcilePolicy: tt.policy,
+			})
+
+			controller.reconcile()
+
+			secrets, err := client.CoreV1().Secrets(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{LabelSelector: "component=build"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(secrets.Items) != len(tt.secrets) {
+				t.Errorf("expected %d builds, got %d", len(tt.secrets), len(secrets.Items))
+			}
+			for _, s := range secrets.Items {
+				orphaned, ok := tt.secrets[s.Name]
+				if !ok {
+					t.Errorf("unexpected build %s", s.Name)
+				} else if s.Labels[orphanedLabel] != orphaned {
+					t.Errorf("expected build %s to have orphaned label %q, got %q", s.Name, orphaned, s.Labels[orphanedLabel])
+				}
+			}
+
+			pods, err := client.CoreV1().Pods(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pods.Items) != len(tt.pods) {
+				t.Errorf("expected %d pods, got %d", len(tt.pods), len(pods.Items))
+			}
+			for _, p := range pods.Items {
+				orphaned, ok := tt.pods[p.Name]
+				if !ok {
+					t.Errorf("unexpected pod %s", p.Name)
+				} else if p.Labels[orphanedLabel] != orphaned {
+					t.Errorf("expected pod %s to have orphaned label %q, got %q", p.Name, orphaned, p.Labels[orphanedLabel])
+				}
+			}
+		})
+	}
+}
+
+func TestController_ReconcileGracePeriod(t *testing.T) {
+	build := &v1.Secret{ObjectMeta: meta.ObjectMeta{
+		Name:              "brigade-worker-starbuck",
+		Namespace:         v1.NamespaceDefault,
+		CreationTimestamp: meta.Now(),
+		Labels:            map[string]string{"heritage": "brigade", "component": "build", "build": "starbuck", "status": "accepted"},
+	}}
+	client := fake.NewSimpleClientset(build)
+	controller := NewController(client, &Config{Namespace: v1.NamespaceDefault, ReconcilePolicy: ReconcilePolicyClean})
+
+	controller.reconcile()
+
+	if _, err := client.CoreV1().Secrets(v1.NamespaceDefault).Get(context.TODO(), build.Name, meta.GetOptions{}); err != nil {
+		t.Errorf("expected a recent build to be left alone: %s", err)
+	}
+}
+
+func TestController_MarkCompleted(t *testing.T) {
+	labels := map[string]string{"heritage": "brigade", "component": "build", "project": "ahab", "build": "ishmael"}
+	build := &v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-ishmael", Namespace: v1.NamespaceDefault, Labels: labels}}
+	worker := &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-ishmael", Namespace: v1.NamespaceDefault, Labels: labels},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	client := fake.NewSimpleClientset(build, worker)
+	controller := NewController(client, &Config{Namespace: v1.NamespaceDefault})
+
+	for _, phase := range []v1.PodPhase{v1.PodRunning, v1.PodSucceeded} {
+		worker.Status.Phase = phase
+		if err := controller.markCompleted(worker); err != nil {
+			t.Fatal(err)
+		}
+		build, err := client.CoreV1().Secrets(v1.NamespaceDefault).Get(context.TODO(), build.Name, meta.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := completed(build); got != (phase == v1.PodSucceeded) {
+			t.Errorf("expected the build of a %s worker to be completed: %t, got %t", phase, phase == v1.PodSucceeded, got)
+		}
+	}
+}
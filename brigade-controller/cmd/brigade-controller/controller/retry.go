@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+// notBeforeAnnotation is set on retried builds. The controller does not start
+// the worker of such a build before the given RFC 3339 time.
+const notBeforeAnnotation = "notBefore"
+
+var entropy = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// syncWorker archives, notifies and reports the build of a completed worker
+// pod and follows the triggers of other projects on it, and retries the build
+// of a worker pod that failed for a reason the project retry policy covers.
+//
+// Each step runs even if the ones before it failed: their errors are logged
+// and recorded as events, and the worker is requeued. The steps that already
+// succeeded do nothing when they run again, as each marks the build with its
+// own annotation.
+func (c *Controller) syncWorker(key string) error {
+	obj, exists, err := c.workerIndexer.GetByKey(key)
+	if err != nil || !exists {
+		return err
+	}
+	pod := obj.(*v1.Pod)
+
+	steps := []struct {
+		name string
+		run  func(*v1.Pod) error
+	}{
+		{"mark the completion of", c.markCompleted},
+		{"record the history of", c.recordHistory},
+		{"archive", c.archiveWorker},
+		{"notify", c.notifyWorker},
+		{"report the status of", c.reportWorker},
+		{"follow the triggers of", c.triggerWorker},
+		{"retry", c.retryWorker},
+	}
+	var failed []string
+	for _, step := range steps {
+		if err := step.run(pod); err != nil {
+			msg := fmt.Sprintf("Could not %s build %s: %s", step.name, pod.Labels["build"], err)
+			logging.With(logging.ProjectIDKey, pod.Labels["project"]).
+				With(logging.BuildIDKey, pod.Labels["build"]).
+				Errorf("%s", msg)
+			var obj runtime.Object = pod
+			if build := c.buildSecret(pod); build != nil {
+				obj = build
+			}
+			c.recorder.Event(obj, v1.EventTypeWarning, ReasonWorkerSyncFailed, msg)
+			failed = append(failed, fmt.Sprintf("%s: %s", step.name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// retryWorker retries the build of a worker pod that failed for a reason the
+// project retry policy covers, unless it was already retried.
+func (c *Controller) retryWorker(pod *v1.Pod) error {
+	reason := workerFailureReason(pod)
+	if reason == "" {
+		return nil
+	}
+
+	secretClient := c.clientset.CoreV1().Secrets(pod.Namespace)
+	build, err := secretClient.Get(context.TODO(), pod.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	project, err := secretClient.Get(context.TODO(), build.Labels["project"], metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	proj, err := kube.NewProjectFromSecret(project, pod.Namespace)
+	if err != nil {
+		buildLogger(build).Warnf("not retrying build %s: %s", build.Labels["build"], err)
+		return nil
+	}
+
+	attempt := buildAttempt(build)
+	if retryID := build.Annotations[kube.RetriedByAnnotation]; retryID != "" {
+		// The build was already retried, but the controller may have stopped
+		// before the new build could be created.
+		return c.ensureRetryBuild(build, retryID, proj.Retry.Delay(attempt))
+	}
+	if !proj.Retry.Retries(reason, attempt) {
+		return nil
+	}
+
+	retryID := strings.ToLower(ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String())
+	buildCopy := build.DeepCopy()
+	if buildCopy.Annotations == nil {
+		buildCopy.Annotations = map[string]string{}
+	}
+	buildCopy.Annotations[kube.RetriedByAnnotation] = retryID
+	// Updating the original build first makes sure that only one retry is
+	// created for it, even if the worker pod is synced more than once.
+	if build, err = secretClient.Update(context.TODO(), buildCopy, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	buildLogger(build).Warnf("Worker %s failed (%s) on attempt %d of %d, retrying as build %s", pod.Name, reason, attempt, proj.Retry.MaxAttempts, retryID)
+	c.recorder.Eventf(build, v1.EventTypeWarning, ReasonWorkerRetried, "Worker failed (%s) on attempt %d of %d, retrying as build %s", reason, attempt, proj.Retry.MaxAttempts, retryID)
+	if err := c.ensureRetryBuild(build, retryID, proj.Retry.Delay(attempt)); err != nil {
+		return err
+	}
+
+	// A worker that cannot pull its image stays pending forever.
+	if reason == brigade.RetryReasonImagePull {
+		podClient := c.clientset.CoreV1().Pods(pod.Namespace)
+		if err := podClient.Delete(context.TODO(), pod.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			buildLogger(build).Errorf("failed to delete worker %s (continuing): %s", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// ensureRetryBuild creates the build secret for the retry of the given build,
+// unless it already exists.
+func (c *Controller) ensureRetryBuild(build *v1.Secret, retryID string, delay time.Duration) error {
+	secretClient := c.clientset.CoreV1().Secrets(build.Namespace)
+	retry := newRetryBuild(build, retryID, delay)
+	if _, err := secretClient.Create(context.TODO(), retry, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// newRetryBuild returns a copy of the build secret for a new attempt of the build.
+func newRetryBuild(build *v1.Secret, retryID string, delay time.Duration) *v1.Secret {
+	name := fmt.Sprintf("brigade-worker-%s", retryID)
+
+	labels := map[string]string{}
+	for k, v := range build.Labels {
+		labels[k] = v
+	}
+	labels["build"] = retryID
+	delete(labels, "status")
+
+	data := map[string][]byte{}
+	for k, v := range build.Data {
+		data[k] = v
+	}
+	data["build_id"] = []byte(name)
+	data["build_name"] = []byte(name)
+	data["attempt"] = []byte(strconv.Itoa(buildAttempt(build) + 1))
+	data["retry_of"] = []byte(build.Labels["build"])
+	// The controller generates a new upload token for the retry.
+	delete(data, kube.UploadTokenKey)
+
+	// The retry continues the trace of the build.
+	annotations := tracing.Annotations(tracing.FromAnnotations(context.Background(), build.Annotations))
+	if delay > 0 {
+		annotations[notBeforeAnnotation] = time.Now().Add(delay).UTC().Format(time.RFC3339)
+	}
+
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   build.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Type: build.Type,
+		Data: data,
+	}
+}
+
+// buildAttempt returns the attempt number of a build secret.
+func buildAttempt(build *v1.Secret) int {
+	if attempt, err := strconv.Atoi(string(build.Data["attempt"])); err == nil && attempt > 0 {
+		return attempt
+	}
+	return 1
+}
+
+// notBefore returns how long to wait before the worker of the build may start.
+func notBefore(build *v1.Secret) time.Duration {
+	t, err := time.Parse(time.RFC3339, build.Annotations[notBeforeAnnotation])
+	if err != nil {
+		return 0
+	}
+	return time.Until(t)
+}
+
+// imagePullReasons are the reasons of the containers waiting for an image that
+// cannot be pulled: ErrImagePull on the first failure, ImagePullBackOff on the
+// next ones, and InvalidImageName if the image reference cannot be parsed.
+var imagePullReasons = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+	"InvalidImageName": true,
+}
+
+// workerFailureReason returns the retry reason matching the failure of the
+// worker pod, or an empty string if the worker did not fail because of the
+// infrastructure.
+func workerFailureReason(pod *v1.Pod) string {
+	switch {
+	case pod.Status.Reason == "Evicted":
+		return brigade.RetryReasonEvicted
+	case pod.Status.Reason == "NodeLost":
+		return brigade.RetryReasonNodeLost
+	case pod.Status.Phase == v1.PodPending:
+		statuses := []v1.ContainerStatus{}
+		statuses = append(statuses, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if w := cs.State.Waiting; w != nil && imagePullReasons[w.Reason] {
+				return brigade.RetryReasonImagePull
+			}
+		}
+	}
+	return ""
+}
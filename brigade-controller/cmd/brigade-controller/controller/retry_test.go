@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+func TestWorkerFailureReason(t *testing.T) {
+	waiting := func(reason string) []v1.ContainerStatus {
+		return []v1.ContainerStatus{{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}}}}
+	}
+	tests := []struct {
+		name   string
+		status v1.PodStatus
+		reason string
+	}{
+		{"running", v1.PodStatus{Phase: v1.PodRunning}, ""},
+		{"script failure", v1.PodStatus{Phase: v1.PodFailed}, ""},
+		{"evicted", v1.PodStatus{Phase: v1.PodFailed, Reason: "Evicted"}, brigade.RetryReasonEvicted},
+		{"node lost", v1.PodStatus{Phase: v1.PodUnknown, Reason: "NodeLost"}, brigade.RetryReasonNodeLost},
+		{"pulling", v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: waiting("ContainerCreating")}, ""},
+		{"worker image pull", v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: waiting("ImagePullBackOff")}, brigade.RetryReasonImagePull},
+		{"sidecar image pull", v1.PodStatus{Phase: v1.PodPending, InitContainerStatuses: waiting("ImagePullBackOff")}, brigade.RetryReasonImagePull},
+		{"first image pull", v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: waiting("ErrImagePull")}, brigade.RetryReasonImagePull},
+		{"invalid image", v1.PodStatus{Phase: v1.PodPending, ContainerStatuses: waiting("InvalidImageName")}, brigade.RetryReasonImagePull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{Status: tt.status}
+			if got := workerFailureReason(pod); got != tt.reason {
+				t.Errorf("expected reason %q, got %q", tt.reason, got)
+			}
+		})
+	}
+}
+
+func TestNewRetryBuild(t *testing.T) {
+	build := &v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "brigade-worker-queequeg",
+			Namespace: v1.NamespaceDefault,
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "build",
+				"project":   "ahab",
+				"build":     "queequeg",
+				"status":    "accepted",
+			},
+		},
+		Type: "brigade.sh/build",
+		Data: map[string][]byte{
+			"script":       []byte("hello"),
+			"build_id":     []byte("brigade-worker-queequeg"),
+			"build_name":   []byte("brigade-worker-queequeg"),
+			"upload_token": []byte("s3cr3t"),
+		},
+	}
+
+	retry := newRetryBuild(build, "starbuck", time.Minute)
+
+	if retry.Name != "brigade-worker-starbuck" {
+		t.Errorf("unexpected name %q", retry.Name)
+	}
+	if retry.Labels["build"] != "starbuck" || retry.Labels["project"] != "ahab" {
+		t.Errorf("unexpected labels %v", retry.Labels)
+	}
+	if _, ok := retry.Labels["status"]; ok {
+		t.Error("expected the status label to be removed")
+	}
+	if _, ok := build.Labels["status"]; !ok {
+		t.Error("expected the original build to be left untouched")
+	}
+
+	b := kube.NewBuildFromSecret(*retry)
+	if b.Attempt != 2 {
+		t.Errorf("expected attempt 2, got %d", b.Attempt)
+	}
+	if b.RetryOf != "queequeg" {
+		t.Errorf("expected retry of %q, got %q", "queequeg", b.RetryOf)
+	}
+	if string(b.Script) != "hello" {
+		t.Errorf("expected script to be copied, got %q", b.Script)
+	}
+	if b.UploadToken != "" {
+		t.Errorf("expected the upload token to be left to the controller, got %q", b.UploadToken)
+	}
+	if d := notBefore(retry); d <= 0 || d > time.Minute {
+		t.Errorf("expected the retry to be delayed by up to a minute, got %s", d)
+	}
+}
+
+func TestController_RetryEvictedWorker(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	config := &Config{
+		Namespace:        v1.NamespaceDefault,
+		WorkerImage:      "brigadecore/brigade-worker:latest",
+		WorkerPullPolicy: string(v1.PullIfNotPresent),
+	}
+	controller := NewController(client, config)
+	// A history that cannot be written to must not stop the retries.
+	controller.RecordHistoryTo(failingRecorder{})
+
+	build := v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "brigade-worker-queequeg",
+			Namespace: v1.NamespaceDefault,
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "build",
+				"project":   "ahab",
+				"build":     "queequeg",
+			},
+		},
+		Type: "brigade.sh/build",
+		Data: map[string][]byte{
+			"script": []byte("hello"),
+		},
+	}
+	project := v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        "ahab",
+			Namespace:   v1.NamespaceDefault,
+			Annotations: map[string]string{"projectName": "ahab"},
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "project",
+			},
+		},
+		Data: map[string][]byte{
+			"retry.maxAttempts": []byte("2"),
+			"retry.reasons":     []byte("Evicted"),
+		},
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go controller.Run(1, stop)
+
+	secrets := client.CoreV1().Secrets(v1.NamespaceDefault)
+	pods := client.CoreV1().Pods(v1.NamespaceDefault)
+	secrets.Create(context.TODO(), &project, meta.CreateOptions{})
+	secrets.Create(context.TODO(), &build, meta.CreateOptions{})
+
+	var pod *v1.Pod
+	err := wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		var err error
+		pod, err = pods.Get(context.TODO(), build.Name, meta.GetOptions{})
+		return err == nil, nil
+	})
+	if err != nil {
+		t.Fatalf("worker was not created: %s", err)
+	}
+
+	pod.Status.Phase = v1.PodFailed
+	pod.Status.Reason = "Evicted"
+	if _, err := pods.UpdateStatus(context.TODO(), pod, meta.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var retryID string
+	err = wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		b, err := secrets.Get(context.TODO(), build.Name, meta.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		retryID = b.Annotations[kube.RetriedByAnnotation]
+		return retryID != "", nil
+	})
+	if err != nil {
+		t.Fatalf("build was not retried: %s", err)
+	}
+
+	retryName := "brigade-worker-" + retryID
+	retry, err := secrets.Get(context.TODO(), retryName, meta.GetOptions{})
+	if err != nil {
+		t.Fatalf("retry build was not created: %s", err)
+	}
+	if b := kube.NewBuildFromSecret(*retry); b.Attempt != 2 || b.RetryOf != "queequeg" {
+		t.Errorf("unexpected retry build: attempt %d, retry of %q", b.Attempt, b.RetryOf)
+	}
+
+	err = wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		_, err := pods.Get(context.TODO(), retryName, meta.GetOptions{})
+		return err == nil, nil
+	})
+	if err != nil {
+		t.Fatalf("worker of the retry was not created: %s", err)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/commitstatus"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+// statusReportedAnnotation is set on build secrets once the final state of the
+// build was reported to GitHub.
+const statusReportedAnnotation = "statusReported"
+
+// ReportStatuses makes the controller report the state of the builds of the
+// projects with a GitHub token as commit statuses: pending when their worker
+// is created, then success or failure. The statuses link to the build in the
+// given dashboard. Each report is given up after the timeout, so that a GitHub
+// server that does not answer does not hold up the other builds.
+func (c *Controller) ReportStatuses(dashboardURL string, timeout time.Duration) {
+	c.reporter = &commitstatus.Reporter{
+		DashboardURL: dashboardURL,
+		Client:       &http.Client{Timeout: timeout},
+		Timeout:      timeout,
+	}
+}
+
+// reportPending reports a build whose worker was just created as pending.
+// Errors are logged and recorded as events, as they must not stop the build.
+func (c *Controller) reportPending(ctx context.Context, build, project *v1.Secret) {
+	if c.reporter == nil {
+		return
+	}
+	proj, err := kube.NewProjectFromSecret(project, build.Namespace)
+	if err != nil {
+		return
+	}
+	c.reportStatus(ctx, build, proj, kube.NewBuildFromSecret(*build), commitstatus.StatePending)
+}
+
+// reportWorker reports the final state of the build of a completed worker pod,
+// unless it was already reported or will be retried, in which case its retry
+// is reported.
+func (c *Controller) reportWorker(pod *v1.Pod) error {
+	if c.reporter == nil || (pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed) {
+		return nil
+	}
+	ctx := context.TODO()
+	secretClient := c.clientset.CoreV1().Secrets(pod.Namespace)
+	build, err := secretClient.Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if build.Annotations[statusReportedAnnotation] != "" || build.Annotations[kube.RetriedByAnnotation] != "" {
+		return nil
+	}
+	project, err := secretClient.Get(ctx, build.Labels["project"], metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	proj, err := kube.NewProjectFromSecret(project, pod.Namespace)
+	if err != nil {
+		buildLogger(build).Warnf("not reporting the status of build %s: %s", build.Labels["build"], err)
+		return nil
+	}
+	b := kube.NewBuildFromSecret(*build)
+	if !commitstatus.Reports(proj, b) {
+		return nil
+	}
+	if reason := workerFailureReason(pod); reason != "" && proj.Retry.Retries(reason, buildAttempt(build)) {
+		return nil
+	}
+	if b.Worker, err = c.store.GetWorker(ctx, b.ID); err != nil {
+		return err
+	}
+	c.reportStatus(ctx, build, proj, b, commitstatus.StateOf(b))
+
+	buildCopy := build.DeepCopy()
+	if buildCopy.Annotations == nil {
+		buildCopy.Annotations = map[string]string{}
+	}
+	buildCopy.Annotations[statusReportedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	_, err = secretClient.Update(ctx, buildCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// reportStatus reports the state of a build, logging errors and recording
+// them as events of the build.
+func (c *Controller) reportStatus(ctx context.Context, secret *v1.Secret, proj *brigade.Project, build *brigade.Build, state string) {
+	if err := c.reporter.Report(ctx, proj, build, state); err != nil {
+		buildLogger(secret).Errorf("%s", err)
+		c.recorder.Event(secret, v1.EventTypeWarning, ReasonStatusReportFailed, err.Error())
+	}
+}
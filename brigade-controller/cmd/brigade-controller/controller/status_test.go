@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/commitstatus"
+)
+
+func TestController_ReportStatuses(t *testing.T) {
+	var states []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status struct {
+			State string `json:"state"`
+		}
+		json.NewDecoder(r.Body).Decode(&status)
+		if r.URL.Path == "/api/v3/repos/ahab/pequod/statuses/c0ffee" {
+			states = append(states, status.State)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	labels := func(component string) map[string]string {
+		return map[string]string{
+			"heritage":  "brigade",
+			"component": component,
+			"project":   "ahab",
+			"build":     "queequeg",
+		}
+	}
+	build := &v1.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels("build")},
+		Data: map[string][]byte{
+			"event_type": []byte("push"),
+			"commit_id":  []byte("c0ffee"),
+		},
+	}
+	project := &v1.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        "ahab",
+			Namespace:   v1.NamespaceDefault,
+			Annotations: map[string]string{"projectName": "ahab/pequod"},
+			Labels:      map[string]string{"heritage": "brigade", "component": "project"},
+		},
+		Data: map[string][]byte{
+			"repository":     []byte("github.com/ahab/pequod"),
+			"github.token":   []byte("s3cr3t"),
+			"github.baseURL": []byte(ts.URL),
+		},
+	}
+	started := meta.Now()
+	worker := &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels("build")},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "brigade-runner"}}},
+		Status:     v1.PodStatus{Phase: v1.PodSucceeded, StartTime: &started},
+	}
+	client := fake.NewSimpleClientset(build, project, worker)
+	controller := NewController(client, &Config{Namespace: v1.NamespaceDefault})
+
+	// Nothing is reported unless statuses are enabled.
+	controller.reportPending(context.TODO(), build, project)
+	if err := controller.reportWorker(worker); err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 0 {
+		t.Fatalf("expected no status, got %v", states)
+	}
+
+	controller.ReportStatuses("https://kashti.example.com", commitstatus.DefaultTimeout)
+	controller.reportPending(context.TODO(), build, project)
+	for i := 0; i < 2; i++ {
+		if err := controller.reportWorker(worker); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(states) != 2 || states[0] != "pending" || states[1] != "success" {
+		t.Errorf("expected a pending then a success status, got %v", states)
+	}
+	build, err := client.CoreV1().Secrets(v1.NamespaceDefault).Get(context.TODO(), worker.Name, meta.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.Annotations[statusReportedAnnotation] == "" {
+		t.Error("expected the build to be annotated as reported")
+	}
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+// triggeredAnnotation is set on build secrets before the builds of the
+// triggers of the other projects on the build are created, so that they are
+// created at most once.
+const triggeredAnnotation = "triggered"
+
+// TriggerPayload is the payload of the builds created by triggers.
+type TriggerPayload struct {
+	// Upstream is the build that triggered the build.
+	Upstream TriggerUpstream `json:"upstream"`
+	// Chain are the builds that led to the build, the first one first.
+	Chain []brigade.TriggerLink `json:"chain"`
+}
+
+// TriggerUpstream describes the build that triggered a build.
+type TriggerUpstream struct {
+	ProjectID string            `json:"project_id"`
+	Project   string            `json:"project"`
+	BuildID   string            `json:"build_id"`
+	Type      string            `json:"type"`
+	Provider  string            `json:"provider"`
+	Revision  *brigade.Revision `json:"revision,omitempty"`
+	// Result is success or failure.
+	Result string `json:"result"`
+}
+
+// triggerWorker creates the builds of the triggers of other projects on the
+// build of a completed worker pod, unless they were already created or the
+// build will be retried, in which case its retry triggers them.
+//
+// A trigger does not create a build of a project that is already in the
+// trigger chain of the build, nor one longer than brigade.MaxTriggerDepth.
+// The build is marked with triggeredAnnotation before the builds are created,
+// so that they are never created twice. Builds that cannot be created are
+// logged and recorded as events, but are not attempted again.
+func (c *Controller) triggerWorker(pod *v1.Pod) error {
+	if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+		return nil
+	}
+	ctx := context.TODO()
+	secretClient := c.clientset.CoreV1().Secrets(pod.Namespace)
+	build, err := secretClient.Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if build.Annotations[triggeredAnnotation] != "" || build.Annotations[kube.RetriedByAnnotation] != "" {
+		return nil
+	}
+
+	projects, err := c.store.GetProjects(ctx)
+	if err != nil {
+		return err
+	}
+	var upstream *brigade.Project
+	var triggered bool
+	for _, p := range projects {
+		if p.ID == build.Labels["project"] {
+			upstream = p
+		}
+		triggered = triggered || len(p.Triggers) > 0
+	}
+	if upstream == nil || !triggered {
+		return nil
+	}
+	if reason := workerFailureReason(pod); reason != "" && upstream.Retry.Retries(reason, buildAttempt(build)) {
+		return nil
+	}
+	b, err := c.store.GetBuild(ctx, build.Labels["build"])
+	if err != nil {
+		return err
+	}
+
+	// The build is claimed before any build is created: if the annotation
+	// cannot be written, for example because of a conflict, nothing was
+	// created yet and the worker is synced again.
+	buildCopy := build.DeepCopy()
+	if buildCopy.Annotations == nil {
+		buildCopy.Annotations = map[string]string{}
+	}
+	buildCopy.Annotations[triggeredAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if build, err = secretClient.Update(ctx, buildCopy, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	log := buildLogger(build)
+	result := brigade.TriggerOnFailure
+	if b.Worker != nil && b.Worker.Status == brigade.JobSucceeded {
+		result = brigade.TriggerOnSuccess
+	}
+	chain := append(append([]brigade.TriggerLink{}, b.TriggerChain...), brigade.TriggerLink{ProjectID: upstream.ID, BuildID: b.ID})
+	// The triggered builds continue the trace of the build.
+	ctx = tracing.FromAnnotations(ctx, build.Annotations)
+	for _, downstream := range projects {
+		for _, trigger := range downstream.Triggers {
+			if !trigger.Matches(upstream, b, result) {
+				continue
+			}
+			if loop := triggerLoop(chain, downstream.ID); loop != "" {
+				log.Warnf("not triggering a build of project %s: %s", downstream.Name, loop)
+				c.recorder.Eventf(build, v1.EventTypeWarning, ReasonTriggerSkipped, "Not triggering a build of project %s: %s", downstream.Name, loop)
+				continue
+			}
+			triggeredBuild, err := newTriggeredBuild(upstream, b, result, downstream, trigger, chain)
+			if err == nil {
+				err = c.store.CreateBuild(ctx, triggeredBuild)
+			}
+			if err != nil {
+				log.Errorf("could not trigger a build of project %s: %s", downstream.Name, err)
+				c.recorder.Eventf(build, v1.EventTypeWarning, ReasonTriggerFailed, "Could not trigger a build of project %s: %s", downstream.Name, err)
+				continue
+			}
+			log.Infof("Triggered build %s of project %s", triggeredBuild.ID, downstream.Name)
+			c.recorder.Eventf(build, v1.EventTypeNormal, ReasonBuildTriggered, "Triggered build %s of project %s", triggeredBuild.ID, downstream.Name)
+		}
+	}
+	return nil
+}
+
+// triggerLoop returns why a build of the given project cannot be added to the
+// trigger chain, if it cannot.
+func triggerLoop(chain []brigade.TriggerLink, projectID string) string {
+	for _, link := range chain {
+		if link.ProjectID == projectID {
+			return fmt.Sprintf("the project is already in the trigger chain (build %s)", link.BuildID)
+		}
+	}
+	if len(chain) >= brigade.MaxTriggerDepth {
+		return fmt.Sprintf("the trigger chain is longer than %d builds", brigade.MaxTriggerDepth)
+	}
+	return ""
+}
+
+// newTriggeredBuild returns the build a trigger of the downstream project
+// creates on a build of the upstream project.
+func newTriggeredBuild(upstream *brigade.Project, build *brigade.Build, result string, downstream *brigade.Project, trigger brigade.Trigger, chain []brigade.TriggerLink) (*brigade.Build, error) {
+	payload, err := json.Marshal(TriggerPayload{
+		Upstream: TriggerUpstream{
+			ProjectID: upstream.ID,
+			Project:   upstream.Name,
+			BuildID:   build.ID,
+			Type:      build.Type,
+			Provider:  build.Provider,
+			Revision:  build.Revision,
+			Result:    result,
+		},
+		Chain: chain,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ref := trigger.Ref
+	if ref == "" {
+		// The sidecar needs a revision. It is ignored by projects without VCS.
+		ref = "master"
+	}
+	return &brigade.Build{
+		ProjectID:    downstream.ID,
+		Type:         trigger.BuildType(),
+		Provider:     brigade.TriggerProvider,
+		Revision:     &brigade.Revision{Ref: ref},
+		Payload:      payload,
+		ShortTitle:   fmt.Sprintf("%s %s %s", upstream.Name, build.Type, result),
+		LongTitle:    fmt.Sprintf("Triggered by the %s build %s of %s", result, build.ID, upstream.Name),
+		TriggerChain: chain,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+func TestController_TriggerWorker(t *testing.T) {
+	ahab, starbuck := brigade.ProjectID("ahab"), brigade.ProjectID("starbuck")
+	project := func(name string, data map[string][]byte) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        brigade.ProjectID(name),
+				Namespace:   v1.NamespaceDefault,
+				Annotations: map[string]string{"projectName": name},
+				Labels:      map[string]string{"app": "brigade", "heritage": "brigade", "component": "project"},
+			},
+			Data: data,
+		}
+	}
+	labels := map[string]string{
+		"heritage":  "brigade",
+		"component": "build",
+		"project":   ahab,
+		"build":     "queequeg",
+	}
+	started := meta.Now()
+	worker := &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "brigade-runner"}}},
+		Status:     v1.PodStatus{Phase: v1.PodSucceeded, StartTime: &started},
+	}
+	client := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: labels},
+			Data: map[string][]byte{
+				"event_type":     []byte("push"),
+				"event_provider": []byte("github"),
+				"commit_id":      []byte("abc123"),
+			},
+		},
+		project("ahab", map[string][]byte{
+			// Builds of starbuck trigger builds of ahab, not the other way around.
+			"triggers": []byte(`[{"project": "starbuck", "on": ["always"]}]`),
+		}),
+		project("starbuck", map[string][]byte{
+			"triggers": []byte(`[{"project": "ahab", "eventType": "push", "event": "deploy"}, {"project": "ahab", "on": ["failure"]}]`),
+		}),
+		worker,
+	)
+	controller := NewController(client, &Config{Namespace: v1.NamespaceDefault})
+
+	// A build that cannot be claimed triggers nothing.
+	conflict := true
+	client.PrependReactor("update", "secrets", func(action core.Action) (bool, runtime.Object, error) {
+		if conflict {
+			conflict = false
+			return true, nil, apierrors.NewConflict(v1.Resource("secrets"), worker.Name, errors.New("modified"))
+		}
+		return false, nil, nil
+	})
+	if err := controller.triggerWorker(worker); err == nil {
+		t.Fatal("expected the conflict to be returned")
+	}
+	if secrets, _ := client.CoreV1().Secrets(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{LabelSelector: "component=build,project=" + starbuck}); len(secrets.Items) != 0 {
+		t.Fatalf("expected no build of starbuck before the build is claimed, got %d", len(secrets.Items))
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := controller.triggerWorker(worker); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secrets, err := client.CoreV1().Secrets(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{LabelSelector: "component=build,project=" + starbuck})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets.Items) != 1 {
+		t.Fatalf("expected one build of starbuck, got %d", len(secrets.Items))
+	}
+	// The fake clientset does not convert StringData.
+	secret := secrets.Items[0]
+	secret.Data = map[string][]byte{"payload": secret.Data["payload"]}
+	for k, v := range secret.StringData {
+		secret.Data[k] = []byte(v)
+	}
+	build := kube.NewBuildFromSecret(secret)
+	if build.Type != "deploy" || build.Provider != brigade.TriggerProvider {
+		t.Errorf("expected a deploy event of the %s provider, got %s of %s", brigade.TriggerProvider, build.Type, build.Provider)
+	}
+	chain := []brigade.TriggerLink{{ProjectID: ahab, BuildID: "queequeg"}}
+	if len(build.TriggerChain) != 1 || build.TriggerChain[0] != chain[0] {
+		t.Errorf("expected trigger chain %v, got %v", chain, build.TriggerChain)
+	}
+	var payload TriggerPayload
+	if err := json.Unmarshal(build.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Upstream.BuildID != "queequeg" || payload.Upstream.Result != brigade.TriggerOnSuccess || payload.Upstream.Revision.Commit != "abc123" {
+		t.Errorf("unexpected upstream build in payload: %+v", payload.Upstream)
+	}
+
+	upstream, err := client.CoreV1().Secrets(v1.NamespaceDefault).Get(context.TODO(), worker.Name, meta.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upstream.Annotations[triggeredAnnotation] == "" {
+		t.Error("expected the build to be annotated as triggered")
+	}
+}
+
+func TestTriggerLoop(t *testing.T) {
+	chain := []brigade.TriggerLink{{ProjectID: "brigade-ahab", BuildID: "queequeg"}}
+	if loop := triggerLoop(chain, "brigade-starbuck"); loop != "" {
+		t.Errorf("expected no loop, got %q", loop)
+	}
+	if loop := triggerLoop(chain, "brigade-ahab"); loop == "" {
+		t.Error("expected a loop")
+	}
+	for len(chain) < brigade.MaxTriggerDepth {
+		chain = append(chain, brigade.TriggerLink{ProjectID: "brigade-stubb", BuildID: "flask"})
+	}
+	if loop := triggerLoop(chain, "brigade-starbuck"); loop == "" {
+		t.Error("expected the chain to be too long")
+	}
+}
@@ -1,26 +1,45 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/brigadecore/brigade/brigade-controller/cmd/brigade-controller/controller"
-
+	"github.com/brigadecore/brigade/pkg/archive"
+	"github.com/brigadecore/brigade/pkg/commitstatus"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/notify"
+	"github.com/brigadecore/brigade/pkg/storage/sqlstore"
+	"github.com/brigadecore/brigade/pkg/tracing"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-func init() {
-	log.SetFlags(log.Lshortfile)
-}
-
 func main() {
 	var (
 		kubeconfig string
 		master     string
 		ctrConfig  controller.Config
+
+		metricsAddress string
+		archiveURL     string
+		historyURL     string
+		logLevel       string
+		logFormat      string
+		otlpEndpoint   string
+		smtp           notify.SMTPConfig
+		notifyTimeout  time.Duration
+		reportStatuses bool
+		reportTimeout  time.Duration
+		dashboardURL   string
 	)
 
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
@@ -36,10 +55,43 @@ func main() {
 	flag.StringVar(&ctrConfig.WorkerRequestsMemory, "worker-requests-memory", "", "kubernetes worker memory requests")
 	flag.StringVar(&ctrConfig.WorkerLimitsCPU, "worker-limits-cpu", "", "kubernetes worker cpu limits")
 	flag.StringVar(&ctrConfig.WorkerLimitsMemory, "worker-limits-memory", "", "kubernetes worker memory limits")
+	flag.StringVar(&ctrConfig.WorkerMaxCPU, "worker-max-cpu", "", "maximum cpu requests and limits a project may set for its worker")
+	flag.StringVar(&ctrConfig.WorkerMaxMemory, "worker-max-memory", "", "maximum memory requests and limits a project may set for its worker")
+	flag.StringVar(&ctrConfig.WorkerTimeout, "worker-timeout", "", "maximum duration of a worker (e.g. 1h) after which the build is timed out, no timeout if empty")
 	flag.StringVar(&ctrConfig.DefaultBuildStorageClass, "default-build-storage-class", defaultBuildStorageClass(), "default storage class to use for shared build storage")
 	flag.StringVar(&ctrConfig.DefaultCacheStorageClass, "default-cache-storage-class", defaultCacheStorageClass(), "default storage class to use for caching jobs")
+	flag.StringVar(&ctrConfig.ReconcileInterval, "reconcile-interval", defaultReconcileInterval(), "interval between the passes looking for orphaned builds and pods, disabled if 0")
+	flag.StringVar(&ctrConfig.ReconcilePolicy, "reconcile-policy", defaultReconcilePolicy(), "what to do with orphaned builds and pods: mark, repair or clean")
+	flag.StringVar(&metricsAddress, "metrics-address", defaultMetricsAddress(), "address to serve Prometheus metrics on, disabled if empty")
+	flag.StringVar(&archiveURL, "archive-url", os.Getenv("BRIGADE_ARCHIVE_URL"), "where to archive the logs of completed builds (file:///path or s3://bucket/prefix), disabled if empty")
+	flag.StringVar(&historyURL, "history-database", os.Getenv("BRIGADE_HISTORY_DATABASE"), "database to record the history of builds and jobs in (postgres://... or sqlite3:///path), disabled if empty")
+	flag.StringVar(&smtp.Address, "smtp-address", os.Getenv("BRIGADE_SMTP_ADDRESS"), "host:port of the SMTP server email notifications are sent through, disabled if empty")
+	flag.StringVar(&smtp.From, "smtp-from", defaultSMTPFrom(), "sender of the email notifications")
+	flag.StringVar(&smtp.Username, "smtp-username", os.Getenv("BRIGADE_SMTP_USERNAME"), "username to authenticate to the SMTP server with, the password is read from $BRIGADE_SMTP_PASSWORD")
+	flag.DurationVar(&notifyTimeout, "notify-timeout", defaultNotifyTimeout(), "maximum duration of each webhook, Slack or email notification")
+	flag.BoolVar(&reportStatuses, "report-statuses", defaultReportStatuses(), "report the state of builds as GitHub commit statuses, for the projects with a GitHub token")
+	flag.DurationVar(&reportTimeout, "report-timeout", defaultReportTimeout(), "maximum duration of each commit status report to GitHub")
+	flag.StringVar(&dashboardURL, "dashboard-url", os.Getenv("BRIGADE_DASHBOARD_URL"), "URL of the dashboard the commit statuses link to (e.g. https://kashti.example.com)")
+	flag.StringVar(&logLevel, "log-level", logging.DefaultLevel(), "minimum level of the log lines: debug, info, warn or error")
+	flag.StringVar(&logFormat, "log-format", logging.DefaultFormat(), "format of the log lines: text or json")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", tracing.DefaultEndpoint(), "OTLP gRPC endpoint to export traces to (e.g. http://otel-collector:4317), disabled if empty")
 	flag.Parse()
 
+	if err := logging.Configure(logLevel, logFormat); err != nil {
+		logging.Fatalf("%s", err)
+	}
+	shutdown, err := tracing.Configure("brigade-controller", otlpEndpoint)
+	if err != nil {
+		logging.Fatalf("%s", err)
+	}
+	defer shutdown(context.Background())
+	if !validReconcilePolicy(ctrConfig.ReconcilePolicy) {
+		logging.Fatalf("invalid reconcile policy %q, must be one of %s", ctrConfig.ReconcilePolicy, strings.Join(controller.ReconcilePolicies, ", "))
+	}
+	if _, err := time.ParseDuration(ctrConfig.ReconcileInterval); err != nil {
+		logging.Fatalf("invalid reconcile interval %q: %s", ctrConfig.ReconcileInterval, err)
+	}
+
 	if ctrConfig.ProjectServiceAccountRegex == "" {
 		// No regex was given so only allow the default project service account
 		ctrConfig.ProjectServiceAccountRegex = ctrConfig.ProjectServiceAccount
@@ -48,17 +100,44 @@ func main() {
 	// creates the connection
 	config, err := clientcmd.BuildConfigFromFlags(master, kubeconfig)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatalf("%s", err)
 	}
 
 	// creates the clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatalf("%s", err)
 	}
 
 	controller := controller.NewController(clientset, &ctrConfig)
-	log.Printf("Listening in namespace %q for new events", ctrConfig.Namespace)
+	if archiveURL != "" {
+		sink, err := archive.NewSink(archiveURL)
+		if err != nil {
+			logging.Fatalf("%s", err)
+		}
+		controller.ArchiveTo(sink)
+	}
+	if historyURL != "" {
+		history, err := sqlstore.OpenURL(historyURL)
+		if err != nil {
+			logging.Fatalf("error opening the history database: %s", err)
+		}
+		defer history.Close()
+		controller.RecordHistoryTo(history)
+	}
+	smtp.Password = os.Getenv("BRIGADE_SMTP_PASSWORD")
+	controller.NotifyBuilds(smtp, notifyTimeout)
+	if reportStatuses {
+		controller.ReportStatuses(dashboardURL, reportTimeout)
+	}
+	logging.Infof("Listening in namespace %q for new events", ctrConfig.Namespace)
+
+	if metricsAddress != "" {
+		http.Handle("/metrics", promhttp.Handler())
+		go func() {
+			logging.Fatalf("%s", http.ListenAndServe(metricsAddress, nil))
+		}()
+	}
 
 	// Now let's start the controller
 	stop := make(chan struct{})
@@ -115,3 +194,59 @@ func defaultBuildStorageClass() string {
 func defaultCacheStorageClass() string {
 	return os.Getenv("BRIGADE_DEFAULT_CACHE_STORAGE_CLASS")
 }
+
+func defaultReconcileInterval() string {
+	if i, ok := os.LookupEnv("BRIGADE_RECONCILE_INTERVAL"); ok {
+		return i
+	}
+	return "5m"
+}
+
+func defaultReconcilePolicy() string {
+	if p, ok := os.LookupEnv("BRIGADE_RECONCILE_POLICY"); ok {
+		return p
+	}
+	return controller.ReconcilePolicyMark
+}
+
+func defaultSMTPFrom() string {
+	if from, ok := os.LookupEnv("BRIGADE_SMTP_FROM"); ok {
+		return from
+	}
+	return "brigade@localhost"
+}
+
+func defaultNotifyTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("BRIGADE_NOTIFY_TIMEOUT")); err == nil {
+		return d
+	}
+	return notify.DefaultTimeout
+}
+
+func defaultReportStatuses() bool {
+	report, _ := strconv.ParseBool(os.Getenv("BRIGADE_REPORT_STATUSES"))
+	return report
+}
+
+func defaultReportTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("BRIGADE_REPORT_TIMEOUT")); err == nil {
+		return d
+	}
+	return commitstatus.DefaultTimeout
+}
+
+func defaultMetricsAddress() string {
+	if addr, ok := os.LookupEnv("BRIGADE_METRICS_ADDRESS"); ok {
+		return addr
+	}
+	return ":9090"
+}
+
+func validReconcilePolicy(policy string) bool {
+	for _, p := range controller.ReconcilePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
@@ -1,38 +1,56 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"log"
 	"net/http"
 	"os"
+	"time"
 
 	gin "gopkg.in/gin-gonic/gin.v1"
 
 	v1 "k8s.io/api/core/v1"
 
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
+	"github.com/brigadecore/brigade/pkg/tracing"
 	"github.com/brigadecore/brigade/pkg/webhook"
 )
 
 var (
-	kubeconfig string
-	master     string
-	namespace  string
+	kubeconfig   string
+	master       string
+	namespace    string
+	logLevel     string
+	logFormat    string
+	otlpEndpoint string
 )
 
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
 	flag.StringVar(&master, "master", "", "master url")
 	flag.StringVar(&namespace, "namespace", defaultNamespace(), "kubernetes namespace")
+	flag.DurationVar(&webhook.CreateBuildTimeout, "create-build-timeout", defaultCreateBuildTimeout(), "maximum duration of the creation of a build")
+	flag.StringVar(&logLevel, "log-level", logging.DefaultLevel(), "minimum level of the log lines: debug, info, warn or error")
+	flag.StringVar(&logFormat, "log-format", logging.DefaultFormat(), "format of the log lines: text or json")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", tracing.DefaultEndpoint(), "OTLP gRPC endpoint to export traces to (e.g. http://otel-collector:4317), disabled if empty")
 }
 
 func main() {
 	flag.Parse()
+	if err := logging.Configure(logLevel, logFormat); err != nil {
+		logging.Fatalf("%s", err)
+	}
+	shutdown, err := tracing.Configure("brigade-cr-gateway", otlpEndpoint)
+	if err != nil {
+		logging.Fatalf("%s", err)
+	}
+	defer shutdown(context.Background())
 
 	clientset, err := kube.GetClient(master, kubeconfig)
 	if err != nil {
-		log.Fatal(err)
+		logging.Fatalf("%s", err)
 	}
 
 	if namespace == "" {
@@ -45,7 +63,7 @@ func main() {
 	router.Run(":8000")
 }
 
-func newRouter(store storage.Store) *gin.Engine {
+func newRouter(store storage.ContextStore) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Recovery())
 
@@ -53,7 +71,7 @@ func newRouter(store storage.Store) *gin.Engine {
 
 	events := router.Group("/events")
 	{
-		events.Use(gin.Logger())
+		events.Use(webhook.Logger(), webhook.Tracing())
 
 		// We need to handle the full project name (brigade-00000), the org/project
 		// format of the name (for backward compatibility), and variants where the
@@ -86,3 +104,10 @@ func defaultNamespace() string {
 	}
 	return v1.NamespaceDefault
 }
+
+func defaultCreateBuildTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("BRIGADE_CREATE_BUILD_TIMEOUT")); err == nil {
+		return d
+	}
+	return webhook.CreateBuildTimeout
+}
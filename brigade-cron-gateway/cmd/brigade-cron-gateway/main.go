@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+	// The time zones of the schedules are embedded, the image has no zoneinfo.
+	_ "time/tzdata"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/brigadecore/brigade/brigade-cron-gateway/cmd/brigade-cron-gateway/scheduler"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+var (
+	kubeconfig   string
+	master       string
+	namespace    string
+	interval     time.Duration
+	stateName    string
+	logLevel     string
+	logFormat    string
+	otlpEndpoint string
+)
+
+func init() {
+	flag.StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	flag.StringVar(&master, "master", "", "master url")
+	flag.StringVar(&namespace, "namespace", defaultNamespace(), "kubernetes namespace")
+	flag.DurationVar(&interval, "interval", defaultInterval(), "interval between the checks of the schedules")
+	flag.StringVar(&stateName, "state-config-map", defaultStateName(), "config map the time of the last run of every schedule is stored in")
+	flag.DurationVar(&scheduler.StartingDeadline, "starting-deadline", scheduler.StartingDeadline, "how late a run can start before it is considered missed")
+	flag.IntVar(&scheduler.MaxCatchUp, "max-catch-up", scheduler.MaxCatchUp, "maximum number of missed runs of a schedule started under the catchup policy")
+	flag.StringVar(&logLevel, "log-level", logging.DefaultLevel(), "minimum level of the log lines: debug, info, warn or error")
+	flag.StringVar(&logFormat, "log-format", logging.DefaultFormat(), "format of the log lines: text or json")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", tracing.DefaultEndpoint(), "OTLP gRPC endpoint to export traces to (e.g. http://otel-collector:4317), disabled if empty")
+}
+
+func main() {
+	flag.Parse()
+	if err := logging.Configure(logLevel, logFormat); err != nil {
+		logging.Fatalf("%s", err)
+	}
+	// A run is seen up to an interval after it is due, so on-time runs would be
+	// missed under the skip policy.
+	if interval <= 0 || interval >= scheduler.StartingDeadline {
+		logging.Fatalf("--interval (%s) must be positive and shorter than --starting-deadline (%s)", interval, scheduler.StartingDeadline)
+	}
+	shutdown, err := tracing.Configure("brigade-cron-gateway", otlpEndpoint)
+	if err != nil {
+		logging.Fatalf("%s", err)
+	}
+	defer shutdown(context.Background())
+
+	clientset, err := kube.GetClient(master, kubeconfig)
+	if err != nil {
+		logging.Fatalf("%s", err)
+	}
+
+	if namespace == "" {
+		namespace = v1.NamespaceDefault
+	}
+
+	s := &scheduler.Scheduler{
+		Store: kube.New(clientset, namespace),
+		State: &scheduler.ConfigMapState{
+			Client:    clientset,
+			Namespace: namespace,
+			Name:      stateName,
+		},
+	}
+	logging.Infof("Checking the schedules of the projects in namespace %q every %s", namespace, interval)
+	s.Run(context.Background(), interval)
+}
+
+func defaultNamespace() string {
+	if ns, ok := os.LookupEnv("BRIGADE_NAMESPACE"); ok {
+		return ns
+	}
+	return v1.NamespaceDefault
+}
+
+func defaultInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("BRIGADE_CRON_INTERVAL")); err == nil {
+		return d
+	}
+	return 15 * time.Second
+}
+
+func defaultStateName() string {
+	if name, ok := os.LookupEnv("BRIGADE_CRON_STATE_CONFIG_MAP"); ok {
+		return name
+	}
+	return "brigade-cron-gateway"
+}
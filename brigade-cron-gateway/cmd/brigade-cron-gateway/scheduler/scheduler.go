@@ -0,0 +1,224 @@
+// Package scheduler creates the builds of the cron schedules of projects.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+// StartingDeadline is how late a run can start under the skip policy. Later
+// runs are considered missed.
+var StartingDeadline = time.Minute
+
+// MaxCatchUp is the maximum number of missed runs of a schedule started under
+// the catchup policy. Only the most recent ones are started.
+var MaxCatchUp = 24
+
+// Scheduler creates the builds of the schedules of all projects.
+type Scheduler struct {
+	Store storage.ContextStore
+	State State
+	// Now returns the current time. time.Now if nil.
+	Now func() time.Time
+
+	// lastTick is the time of the previous check of the schedules.
+	lastTick time.Time
+}
+
+// Run checks the schedules every interval until the context is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil {
+			logging.Errorf("%s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick creates the builds of the runs of all the schedules that are due, and
+// records the time of their last run.
+//
+// A schedule seen for the first time has no run in the past: its first run is
+// the next one.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	previous := s.lastTick
+	s.lastTick = now
+	projects, err := s.Store.GetProjects(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list projects: %s", err)
+	}
+	last, err := s.State.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load the state of the schedules: %s", err)
+	}
+
+	next := map[string]time.Time{}
+	for _, proj := range projects {
+		for _, schedule := range proj.Schedules {
+			key := Key(proj, schedule)
+			from, ok := last[key]
+			if !ok {
+				from = now
+			}
+			next[key] = s.runSchedule(ctx, proj, schedule, from, previous, now)
+		}
+	}
+	// The state is only saved when it changed, which drops the schedules that
+	// were removed.
+	if equal(last, next) {
+		return nil
+	}
+	if err := s.State.Save(ctx, next); err != nil {
+		return fmt.Errorf("could not save the state of the schedules: %s", err)
+	}
+	return nil
+}
+
+// runSchedule creates the builds of the runs of a schedule after the given
+// time, and returns the time of the last run that is done. previous is the
+// time of the previous check, zero if there was none.
+func (s *Scheduler) runSchedule(ctx context.Context, proj *brigade.Project, schedule brigade.Schedule, from, previous, now time.Time) time.Time {
+	log := logging.With(logging.ProjectIDKey, proj.ID).With("schedule", schedule.Name)
+	runs, err := DueRuns(schedule, from, now)
+	if err != nil {
+		log.Errorf("invalid schedule %s: %s", schedule.Name, err)
+		return from
+	}
+	if len(runs) == 0 {
+		return from
+	}
+	done := runs[len(runs)-1]
+
+	switch schedule.MissedRuns {
+	case brigade.MissedRunsCatchUp:
+		if len(runs) > MaxCatchUp {
+			log.Warnf("skipping %d missed runs of schedule %s", len(runs)-MaxCatchUp, schedule.Name)
+			runs = runs[len(runs)-MaxCatchUp:]
+		}
+	default:
+		var late []time.Time
+		for len(runs) > 0 && lateness(runs[0], previous, now) > StartingDeadline {
+			late = append(late, runs[0])
+			runs = runs[1:]
+		}
+		if len(late) > 0 {
+			log.Warnf("skipping %d missed runs of schedule %s, the last one at %s", len(late), schedule.Name, late[len(late)-1].Format(time.RFC3339))
+		}
+	}
+
+	for _, run := range runs {
+		if err := s.createBuild(ctx, proj, schedule, run); err != nil {
+			log.Errorf("could not create the build of schedule %s at %s: %s", schedule.Name, run.Format(time.RFC3339), err)
+			// The run is attempted again on the next tick.
+			return run.Add(-time.Nanosecond)
+		}
+	}
+	return done
+}
+
+// lateness returns how late a run was at the first check that could start it:
+// the previous check if the run was already due then, which happens when its
+// build could not be created, and the current one otherwise.
+func lateness(run, previous, now time.Time) time.Duration {
+	if !previous.IsZero() && !run.After(previous) {
+		return previous.Sub(run)
+	}
+	return now.Sub(run)
+}
+
+// createBuild creates the build of a run of a schedule.
+func (s *Scheduler) createBuild(ctx context.Context, proj *brigade.Project, schedule brigade.Schedule, run time.Time) error {
+	b := NewBuild(proj, schedule, run)
+	ctx, span := tracing.Start(ctx, "CreateBuild", trace.WithAttributes(
+		attribute.String(logging.ProjectIDKey, b.ProjectID),
+		attribute.String(logging.EventTypeKey, b.Type),
+		attribute.String(logging.ProviderKey, b.Provider),
+	))
+	defer span.End()
+	if err := s.Store.CreateBuild(ctx, b); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetAttributes(attribute.String(logging.BuildIDKey, b.ID))
+	logging.WithBuild(b).Infof("Created build %s for the run of schedule %s at %s", b.ID, schedule.Name, run.Format(time.RFC3339))
+	return nil
+}
+
+// NewBuild returns the build of a run of a schedule.
+func NewBuild(proj *brigade.Project, schedule brigade.Schedule, run time.Time) *brigade.Build {
+	payload := []byte(schedule.Payload)
+	if len(payload) == 0 {
+		payload, _ = json.Marshal(map[string]string{
+			"schedule": schedule.Name,
+			"time":     run.Format(time.RFC3339),
+		})
+	}
+	ref := schedule.Ref
+	if ref == "" {
+		// The sidecar needs a revision. It is ignored by projects without VCS.
+		ref = "master"
+	}
+	return &brigade.Build{
+		ProjectID:  proj.ID,
+		Type:       schedule.Event(),
+		Provider:   brigade.ScheduleProvider,
+		Revision:   &brigade.Revision{Ref: ref},
+		Payload:    payload,
+		ShortTitle: schedule.Name,
+		LongTitle:  fmt.Sprintf("Run of schedule %s at %s", schedule.Name, run.Format(time.RFC3339)),
+	}
+}
+
+// DueRuns returns the times of the runs of a schedule after from, up to now.
+func DueRuns(schedule brigade.Schedule, from, now time.Time) ([]time.Time, error) {
+	var runs []time.Time
+	for t := from; ; {
+		next, err := schedule.Next(t)
+		if err != nil {
+			return nil, err
+		}
+		if next.IsZero() || next.After(now) {
+			return runs, nil
+		}
+		runs = append(runs, next)
+		t = next
+	}
+}
+
+// Key returns the key of the state of a schedule.
+func Key(proj *brigade.Project, schedule brigade.Schedule) string {
+	return proj.ID + "." + schedule.Name
+}
+
+func equal(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !w.Equal(v) {
+			return false
+		}
+	}
+	return true
+}
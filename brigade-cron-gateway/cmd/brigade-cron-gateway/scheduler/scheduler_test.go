@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/mock"
+)
+
+// memoryState stores the state of the schedules in memory.
+type memoryState map[string]time.Time
+
+func (m memoryState) Load(ctx context.Context) (map[string]time.Time, error) {
+	runs := map[string]time.Time{}
+	for k, v := range m {
+		runs[k] = v
+	}
+	return runs, nil
+}
+
+func (m memoryState) Save(ctx context.Context, runs map[string]time.Time) error {
+	for k := range m {
+		delete(m, k)
+	}
+	for k, v := range runs {
+		m[k] = v
+	}
+	return nil
+}
+
+func TestDueRuns(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	schedule := brigade.Schedule{Name: "nightly", Cron: "0 3 * * *", Timezone: "Europe/Paris"}
+	from := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	runs, err := DueRuns(schedule, from, from.Add(72*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 runs, got %v", runs)
+	}
+	if expect := time.Date(2020, 6, 1, 3, 0, 0, 0, paris); !runs[0].Equal(expect) {
+		t.Errorf("expected the first run at %s, got %s", expect, runs[0])
+	}
+	if runs[0].UTC().Hour() != 1 {
+		t.Errorf("expected the run at 3am in Paris to be at 1am UTC, got %s", runs[0].UTC())
+	}
+}
+
+func TestScheduler_Tick(t *testing.T) {
+	proj := &brigade.Project{
+		ID:   "brigade-83f1",
+		Name: "ahab/pequod",
+		Schedules: []brigade.Schedule{
+			{Name: "hourly", Cron: "@hourly", EventType: "hourly", Payload: `{"sail": true}`},
+			{Name: "skipped", Cron: "*/10 * * * *"},
+			{Name: "caught-up", Cron: "*/10 * * * *", MissedRuns: brigade.MissedRunsCatchUp},
+		},
+	}
+	store := &mock.Store{ProjectList: []*brigade.Project{proj}}
+	state := memoryState{}
+	now := time.Date(2020, 6, 1, 15, 0, 30, 0, time.UTC)
+	s := &Scheduler{Store: store, State: state, Now: func() time.Time { return now }}
+
+	// The first tick only records when the schedules were first seen.
+	if err := s.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Builds) != 0 {
+		t.Fatalf("expected no build on the first tick, got %d", len(store.Builds))
+	}
+	if !state["brigade-83f1.hourly"].Equal(now) {
+		t.Errorf("expected the schedules to start now, got %v", state)
+	}
+
+	// The gateway was down for 40 minutes, and comes back right after 15:40.
+	now = time.Date(2020, 6, 1, 15, 40, 10, 0, time.UTC)
+	if err := s.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, b := range store.Builds {
+		counts[b.ShortTitle]++
+		if b.Provider != brigade.ScheduleProvider {
+			t.Errorf("expected provider %s, got %s", brigade.ScheduleProvider, b.Provider)
+		}
+	}
+	if counts["skipped"] != 1 || counts["caught-up"] != 4 || counts["hourly"] != 0 {
+		t.Errorf("expected 1 skipped and 4 caught up runs, got %v", counts)
+	}
+	expect := time.Date(2020, 6, 1, 15, 40, 0, 0, time.UTC)
+	if !state["brigade-83f1.skipped"].Equal(expect) || !state["brigade-83f1.caught-up"].Equal(expect) {
+		t.Errorf("expected the last runs at %s, got %v", expect, state)
+	}
+
+	now = time.Date(2020, 6, 1, 16, 0, 5, 0, time.UTC)
+	store.Builds = nil
+	if err := s.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var hourly *brigade.Build
+	for _, b := range store.Builds {
+		if b.ShortTitle == "hourly" {
+			hourly = b
+		}
+	}
+	if hourly == nil || hourly.Type != "hourly" || string(hourly.Payload) != `{"sail": true}` || hourly.Revision.Ref != "master" {
+		t.Errorf("unexpected build of the hourly schedule: %+v", hourly)
+	}
+
+	// Removed schedules are dropped from the state.
+	proj.Schedules = proj.Schedules[:1]
+	if err := s.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state["brigade-83f1.skipped"]; ok || len(state) != 1 {
+		t.Errorf("expected the removed schedules to be dropped, got %v", state)
+	}
+}
+
+// flakyStore fails to create the first build.
+type flakyStore struct {
+	*mock.Store
+	failed bool
+}
+
+func (s *flakyStore) CreateBuild(ctx context.Context, b *brigade.Build) error {
+	if !s.failed {
+		s.failed = true
+		return errors.New("api is down")
+	}
+	return s.Store.CreateBuild(ctx, b)
+}
+
+func TestScheduler_TickRetriesFailedRun(t *testing.T) {
+	proj := &brigade.Project{
+		ID:        "brigade-83f1",
+		Schedules: []brigade.Schedule{{Name: "skipped", Cron: "*/10 * * * *"}},
+	}
+	store := &flakyStore{Store: &mock.Store{ProjectList: []*brigade.Project{proj}}}
+	state := memoryState{}
+	now := time.Date(2020, 6, 1, 15, 9, 50, 0, time.UTC)
+	s := &Scheduler{Store: store, State: state, Now: func() time.Time { return now }}
+	if err := s.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The build of the run at 15:10 cannot be created.
+	now = time.Date(2020, 6, 1, 15, 10, 40, 0, time.UTC)
+	if err := s.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Builds) != 0 {
+		t.Fatalf("expected no build, got %d", len(store.Builds))
+	}
+
+	// The retry is more than a minute after the run, but the run was on time
+	// when its build was first attempted.
+	now = time.Date(2020, 6, 1, 15, 11, 10, 0, time.UTC)
+	if err := s.Tick(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Builds) != 1 {
+		t.Fatalf("expected the failed run to be retried, got %d builds", len(store.Builds))
+	}
+}
+
+func TestNewBuild_DefaultPayload(t *testing.T) {
+	run := time.Date(2020, 6, 1, 3, 0, 0, 0, time.UTC)
+	b := NewBuild(&brigade.Project{ID: "brigade-83f1"}, brigade.Schedule{Name: "nightly", Ref: "refs/heads/main"}, run)
+	var payload map[string]string
+	if err := json.Unmarshal(b.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["schedule"] != "nightly" || payload["time"] != "2020-06-01T03:00:00Z" {
+		t.Errorf("unexpected payload %s", b.Payload)
+	}
+	if b.Type != brigade.DefaultScheduleEventType || b.Revision.Ref != "refs/heads/main" {
+		t.Errorf("unexpected build %+v", b)
+	}
+}
+
+func TestConfigMapState(t *testing.T) {
+	s := &ConfigMapState{Client: fake.NewSimpleClientset(), Namespace: v1.NamespaceDefault, Name: "brigade-cron-gateway"}
+	runs, err := s.Load(context.Background())
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("expected an empty state, got %v (%v)", runs, err)
+	}
+	run := time.Date(2020, 6, 1, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if err := s.Save(context.Background(), map[string]time.Time{"brigade-83f1.nightly": run}); err != nil {
+			t.Fatal(err)
+		}
+		run = run.Add(24 * time.Hour)
+	}
+	runs, err = s.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !runs["brigade-83f1.nightly"].Equal(run.Add(-24 * time.Hour)) {
+		t.Errorf("unexpected state %v", runs)
+	}
+}
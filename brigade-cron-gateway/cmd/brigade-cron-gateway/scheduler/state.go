@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// State stores the time of the last run of every schedule, by Key.
+type State interface {
+	Load(ctx context.Context) (map[string]time.Time, error)
+	Save(ctx context.Context, runs map[string]time.Time) error
+}
+
+// ConfigMapState stores the state of the schedules in a config map, so that
+// the runs missed while the gateway was not running are known when it starts.
+type ConfigMapState struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+// Load returns the state stored in the config map. It is empty if the config
+// map does not exist.
+func (s *ConfigMapState) Load(ctx context.Context) (map[string]time.Time, error) {
+	cm, err := s.Client.CoreV1().ConfigMaps(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return map[string]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	runs := map[string]time.Time{}
+	for k, v := range cm.Data {
+		// Unreadable times are dropped, their schedules start over.
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			runs[k] = t
+		}
+	}
+	return runs, nil
+}
+
+// Save replaces the state stored in the config map.
+func (s *ConfigMapState) Save(ctx context.Context, runs map[string]time.Time) error {
+	data := map[string]string{}
+	for k, t := range runs {
+		data[k] = t.UTC().Format(time.RFC3339Nano)
+	}
+	client := s.Client.CoreV1().ConfigMaps(s.Namespace)
+	cm, err := client.Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.Name,
+				Namespace: s.Namespace,
+				Labels: map[string]string{
+					"heritage":  "brigade",
+					"component": "cron-gateway",
+				},
+			},
+			Data: data,
+		}
+		_, err = client.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	cm.Data = data
+	_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
@@ -3,14 +3,21 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/brigadecore/brigade/brigade-vacuum/cmd/brigade-vacuum/vacuum"
+	"github.com/brigadecore/brigade/pkg/archive"
+	"github.com/brigadecore/brigade/pkg/artifacts"
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
 )
 
@@ -20,6 +27,13 @@ const (
 	envAge               = "VACUUM_AGE"
 	envSkipRunningBuilds = "VACUUM_SKIP_RUNNING_BUILDS"
 	envNamespace         = "BRIGADE_NAMESPACE"
+	envArchiveURL        = "BRIGADE_ARCHIVE_URL"
+	envArtifactsURL      = "BRIGADE_ARTIFACTS_URL"
+	envPolicyFile        = "VACUUM_POLICY_FILE"
+	envOrphanedCacheAge  = "VACUUM_ORPHANED_CACHE_AGE"
+	envDaemon            = "VACUUM_DAEMON"
+	envInterval          = "VACUUM_INTERVAL"
+	envMetricsAddress    = "VACUUM_METRICS_ADDRESS"
 )
 
 const mainUsage = `Clean up old Brigade builds
@@ -34,6 +48,25 @@ There are two ways to identify which records should be removed:
 
 The two can be combined. Setting a zero-value for either one will remove the limit.
 
+PER-PROJECT RETENTION
+=====================
+
+Projects can set their own retention policy in their 'retention.*' settings.
+A policy file given with '--policy-file' sets the policy of the other projects:
+
+    default:
+      maxAge: 720h
+    projects:
+      brigadecore/brigade:
+        maxBuilds: 100
+        maxFailedAge: 168h
+        keepSuccessful: 5
+
+A build is deleted when it exceeds one of the limits of its project, unless it
+is one of the 'keepSuccessful' most recent successful builds. A summary of the
+builds to delete and deleted is printed for each project. The '--age' and
+'--max-builds' limits still apply to all builds.
+
 AGE VALUES
 ==========
 
@@ -46,14 +79,85 @@ The '--age' parameter can take a valid string duration with any of these suffixe
 
 Note that unless the vacuum is run with high frequency, smaller values are not
 particularly useful.
+
+DRY RUN
+=======
+
+With '--dry-run', nothing is deleted and the builds, pods and secrets that would
+be deleted are listed instead. Use '--output json' to process the result, which
+has the same format with or without '--dry-run':
+
+    {"dryRun": true, "deleted": [...], "skipped": [...], "failed": [...], "projects": [...]}
+
+Builds that are still running are deleted too, unless '--skip-running-builds'
+is given.
+
+STORAGE
+=======
+
+The persistent volume claims of a build (its shared storage) are deleted with
+the build. The cache volumes of the jobs are kept by default because they are
+shared by all the builds of a project. With '--orphaned-cache-age', the cache
+volumes of projects that no longer exist are deleted once they are older than
+the given age:
+
+    brigade-vacuum --orphaned-cache-age 168h
+
+DAEMON
+======
+
+By default, the vacuum runs once and exits, and is scheduled by a CronJob. With
+'--daemon', it runs continuously instead: it watches the builds and deletes
+them as soon as they fall outside the policy, and runs at least once every
+'--interval' for the builds that expire with time:
+
+    brigade-vacuum --daemon --interval 5m --age 720h --max-builds 1000
+
+The daemon serves its Prometheus metrics on /metrics and its health on /healthz
+at '--metrics-address'. It is unhealthy when its last run failed, or when it
+has not run for twice the interval.
+
+ARCHIVE
+=======
+
+With '--archive-url', the logs of the worker and the jobs of a build and the
+build metadata are archived before the build is deleted. A build that cannot
+be archived is not deleted. Supported URLs are:
+
+- file:///var/lib/brigade/archive
+- s3://bucket/prefix?endpoint=minio:9000&region=us-east-1&insecure=true
+
+The S3 credentials are read from $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY.
+
+ARTIFACTS
+=========
+
+With '--artifacts-url', the artifacts the jobs of a build uploaded to the API
+server are deleted with the build, and the artifacts of builds that were
+deleted otherwise (e.g. with 'brig build delete') are deleted too. The URL is
+the one of 'brigade-api --artifacts-url':
+
+- file:///var/lib/brigade/artifacts
 `
 
 var (
-	globalKubeConfig = ""
-	globalNamespace  = ""
-	globalAge        = ""
-	globalVerbose    = false
-	globalMaxBuilds  = vacuum.NoMaxBuilds
+	globalKubeConfig        = ""
+	globalNamespace         = ""
+	globalAge               = ""
+	globalVerbose           = false
+	globalArchiveURL        = ""
+	globalArtifactsURL      = ""
+	globalPolicyFile        = ""
+	globalDryRun            = false
+	globalOutput            = outputText
+	globalSkipRunningBuilds = false
+	globalOrphanedCacheAge  = ""
+	globalDaemon            = false
+	globalInterval          = ""
+	globalMetricsAddress    = ""
+	globalMaxBuilds         = vacuum.NoMaxBuilds
+	globalLogLevel          = ""
+	globalLogFormat         = ""
 )
 
 func init() {
@@ -62,7 +166,19 @@ func init() {
 	f.StringVarP(&globalAge, "age", "a", "", "Age as a fuzzy date ('48h' for hours, '20m' for minutes, '2000s' for seconds)")
 	f.IntVarP(&globalMaxBuilds, "max-builds", "m", vacuum.NoMaxBuilds, "Maximum number of builds to keep")
 	f.BoolVarP(&globalVerbose, "verbose", "v", false, "Turn on verbose output")
+	f.BoolVar(&globalDryRun, "dry-run", false, "List the builds, pods and secrets that would be deleted without deleting them")
+	f.StringVarP(&globalOutput, "output", "o", outputText, "Output format of the result: text or json")
+	f.BoolVar(&globalSkipRunningBuilds, "skip-running-builds", false, "Keep the builds that are still running, overrides $VACUUM_SKIP_RUNNING_BUILDS.")
+	f.StringVar(&globalOrphanedCacheAge, "orphaned-cache-age", "", "Delete the job caches of deleted projects older than this age, overrides $VACUUM_ORPHANED_CACHE_AGE.")
+	f.StringVar(&globalPolicyFile, "policy-file", "", "YAML file with per-project retention policies, overrides $VACUUM_POLICY_FILE.")
+	f.StringVar(&globalArchiveURL, "archive-url", "", "Archive builds to this URL before deleting them, overrides $BRIGADE_ARCHIVE_URL.")
+	f.StringVar(&globalArtifactsURL, "artifacts-url", "", "Delete the artifacts of the builds at this URL, overrides $BRIGADE_ARTIFACTS_URL.")
+	f.BoolVar(&globalDaemon, "daemon", false, "Run continuously instead of once, overrides $VACUUM_DAEMON.")
+	f.StringVar(&globalInterval, "interval", "", "Maximum interval between two runs of the daemon, overrides $VACUUM_INTERVAL (default 5m).")
+	f.StringVar(&globalMetricsAddress, "metrics-address", "", "Address the daemon serves its metrics and health on, overrides $VACUUM_METRICS_ADDRESS (default :9090).")
 	f.StringVar(&globalKubeConfig, "kubeconfig", "", "The path to a KUBECONFIG file, overrides $KUBECONFIG.")
+	f.StringVar(&globalLogLevel, "log-level", logging.DefaultLevel(), "Minimum level of the log lines: debug, info, warn or error, overrides $BRIGADE_LOG_LEVEL.")
+	f.StringVar(&globalLogFormat, "log-format", logging.DefaultFormat(), "Format of the log lines: text or json, overrides $BRIGADE_LOG_FORMAT.")
 }
 
 // Root is the top-level command, which just prints help text.
@@ -71,18 +187,32 @@ var Root = &cobra.Command{
 	Short: "Clean up old Brigade builds",
 	Long:  mainUsage,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := logging.Configure(globalLogLevel, globalLogFormat); err != nil {
+			return err
+		}
+		if globalOutput != outputText && globalOutput != outputJSON {
+			return fmt.Errorf("unsupported output format %q, must be %s or %s", globalOutput, outputText, outputJSON)
+		}
 		a := getAge()
 		mb := maxBuilds()
 		srb := getSkipRunningBuilds()
-		if a == "" && mb == 0 {
-			return errors.New("one of --age or --max-builds must be greater than zero")
+		pf := policyFile()
+		ca := orphanedCacheAge()
+		if a == "" && mb == 0 && pf == "" && ca == "" {
+			return errors.New("one of --age, --max-builds, --policy-file or --orphaned-cache-age must be set")
+		}
+		if mb == 0 {
+			// A zero-value removes the limit rather than deleting every build.
+			mb = vacuum.NoMaxBuilds
 		}
 		var age = vacuum.NoMaxAge
+		var maxAge time.Duration
 		if a != "" {
 			dur, err := time.ParseDuration(a)
 			if err != nil {
 				return err
 			}
+			maxAge = dur
 			age = time.Now().Add(-dur)
 		}
 		c, err := kube.GetClient("", kubeConfigPath())
@@ -92,10 +222,83 @@ var Root = &cobra.Command{
 		if globalVerbose {
 			fmt.Fprintf(os.Stderr, "Max Age: %s\nMax Builds: %d\n", age, mb)
 		}
-		return vacuum.New(age, mb, srb, c, ns()).Run()
+		v := vacuum.New(age, mb, srb, c, ns())
+		if pf != "" {
+			policies, err := vacuum.LoadPolicyFile(pf)
+			if err != nil {
+				return err
+			}
+			v.UsePolicies(policies)
+		}
+		if u := archiveURL(); u != "" {
+			sink, err := archive.NewSink(u)
+			if err != nil {
+				return err
+			}
+			v.ArchiveTo(sink)
+		}
+		if u := artifactsURL(); u != "" {
+			store, err := artifacts.NewStore(u)
+			if err != nil {
+				return err
+			}
+			v.CleanArtifacts(store)
+		}
+		if ca != "" {
+			dur, err := time.ParseDuration(ca)
+			if err != nil {
+				return fmt.Errorf("invalid orphaned cache age: %s", err)
+			}
+			v.CleanOrphanedCaches(dur)
+		}
+		v.SetDryRun(globalDryRun)
+		if daemon() {
+			return runDaemon(v, maxAge)
+		}
+		result, err := v.Run()
+		if err != nil {
+			return err
+		}
+		return printResult(os.Stdout, result, globalOutput)
 	},
 }
 
+// runDaemon runs the vacuum until it receives SIGINT or SIGTERM.
+func runDaemon(v *vacuum.Vacuum, maxAge time.Duration) error {
+	interval, err := time.ParseDuration(getInterval())
+	if err != nil {
+		return fmt.Errorf("invalid interval: %s", err)
+	}
+	if interval <= 0 {
+		return errors.New("the interval must be positive")
+	}
+	d := vacuum.NewDaemon(v, maxAge, interval)
+	d.OnResult = func(result *vacuum.Result) {
+		if err := printResult(os.Stdout, result, globalOutput); err != nil {
+			logging.Errorf("Failed to print the result: %s", err)
+		}
+	}
+
+	if addr := metricsAddress(); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		mux.Handle("/healthz", d)
+		go func() {
+			logging.Fatalf("%s", http.ListenAndServe(addr, mux))
+		}()
+	}
+
+	stopCh := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(stopCh)
+	}()
+	d.Run(stopCh)
+	return nil
+}
+
 func kubeConfigPath() string {
 	if globalKubeConfig != "" {
 		return globalKubeConfig
@@ -105,7 +308,7 @@ func kubeConfigPath() string {
 	}
 	defConfig := os.ExpandEnv("$HOME/.kube/config")
 	if _, err := os.Stat(defConfig); err == nil {
-		fmt.Printf("Using config from %s\n", defConfig)
+		fmt.Fprintf(os.Stderr, "Using config from %s\n", defConfig)
 		return defConfig
 	}
 
@@ -113,6 +316,61 @@ func kubeConfigPath() string {
 	return ""
 }
 
+func policyFile() string {
+	if globalPolicyFile != "" {
+		return globalPolicyFile
+	}
+	return os.Getenv(envPolicyFile)
+}
+
+func daemon() bool {
+	if globalDaemon {
+		return true
+	}
+	return os.Getenv(envDaemon) == "true"
+}
+
+func getInterval() string {
+	if globalInterval != "" {
+		return globalInterval
+	}
+	if v, ok := os.LookupEnv(envInterval); ok {
+		return v
+	}
+	return "5m"
+}
+
+func metricsAddress() string {
+	if globalMetricsAddress != "" {
+		return globalMetricsAddress
+	}
+	if v, ok := os.LookupEnv(envMetricsAddress); ok {
+		return v
+	}
+	return ":9090"
+}
+
+func orphanedCacheAge() string {
+	if globalOrphanedCacheAge != "" {
+		return globalOrphanedCacheAge
+	}
+	return os.Getenv(envOrphanedCacheAge)
+}
+
+func archiveURL() string {
+	if globalArchiveURL != "" {
+		return globalArchiveURL
+	}
+	return os.Getenv(envArchiveURL)
+}
+
+func artifactsURL() string {
+	if globalArtifactsURL != "" {
+		return globalArtifactsURL
+	}
+	return os.Getenv(envArtifactsURL)
+}
+
 func ns() string {
 	if globalNamespace != "" {
 		return globalNamespace
@@ -147,6 +405,9 @@ func getAge() string {
 }
 
 func getSkipRunningBuilds() bool {
+	if globalSkipRunningBuilds {
+		return true
+	}
 	//delete all builds by default, so default is false
 	v, ok := os.LookupEnv(envSkipRunningBuilds)
 	if !ok {
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gosuri/uitable"
+
+	"github.com/brigadecore/brigade/brigade-vacuum/cmd/brigade-vacuum/vacuum"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// printResult writes the result of a vacuum run in the given format.
+func printResult(out io.Writer, result *vacuum.Result, format string) error {
+	if format == outputJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	deleted := "Deleted"
+	if result.DryRun {
+		deleted = "Would delete"
+	}
+	if len(result.Deleted)+len(result.Skipped)+len(result.Failed) > 0 {
+		table := uitable.New()
+		table.AddRow("BUILD", "PROJECT", "REASON", "STATUS", "PODS", "SECRETS", "PVCS")
+		add := func(status string, builds []vacuum.BuildResult) {
+			for _, b := range builds {
+				s := status
+				if b.Error != "" {
+					s = fmt.Sprintf("%s: %s", status, b.Error)
+				}
+				table.AddRow(b.Build, b.Project, b.Reason, s, strings.Join(b.Pods, ","), strings.Join(b.Secrets, ","), strings.Join(b.PersistentVolumeClaims, ","))
+			}
+		}
+		add(strings.ToLower(deleted), result.Deleted)
+		add("skipped", result.Skipped)
+		add("failed", result.Failed)
+		fmt.Fprintln(out, table)
+	}
+	if len(result.Caches) > 0 {
+		table := uitable.New()
+		table.AddRow("CACHE", "PROJECT", "STATUS")
+		failed := 0
+		for _, c := range result.Caches {
+			status := strings.ToLower(deleted)
+			if c.Error != "" {
+				status = fmt.Sprintf("failed: %s", c.Error)
+				failed++
+			}
+			table.AddRow(c.PersistentVolumeClaim, c.Project, status)
+		}
+		fmt.Fprintln(out, table)
+		fmt.Fprintf(out, "%s %d orphaned caches, failed %d\n", deleted, len(result.Caches)-failed, failed)
+	}
+	if len(result.Artifacts) > 0 {
+		table := uitable.New()
+		table.AddRow("ARTIFACTS OF BUILD", "STATUS")
+		failed := 0
+		for _, a := range result.Artifacts {
+			status := strings.ToLower(deleted)
+			if a.Error != "" {
+				status = fmt.Sprintf("failed: %s", a.Error)
+				failed++
+			}
+			table.AddRow(a.Build, status)
+		}
+		fmt.Fprintln(out, table)
+		fmt.Fprintf(out, "%s the artifacts of %d deleted builds, failed %d\n", deleted, len(result.Artifacts)-failed, failed)
+	}
+	_, err := fmt.Fprintf(out, "%s %d builds, skipped %d, failed %d\n", deleted, len(result.Deleted), len(result.Skipped), len(result.Failed))
+	return err
+}
@@ -0,0 +1,47 @@
+package vacuum
+
+import (
+	"github.com/brigadecore/brigade/pkg/artifacts"
+	"github.com/brigadecore/brigade/pkg/logging"
+)
+
+// CleanArtifacts makes the vacuum delete the artifacts of the builds it
+// deletes, and the artifacts of the builds that were deleted otherwise, e.g.
+// with `brig build delete`.
+func (v *Vacuum) CleanArtifacts(store artifacts.Store) {
+	v.artifacts = store
+}
+
+// cleanOrphanedArtifacts deletes the artifacts of deleted builds.
+func (v *Vacuum) cleanOrphanedArtifacts() error {
+	// The builds with artifacts are listed first, so that a build created
+	// in the meantime cannot be taken for a deleted one.
+	ids, err := v.artifacts.Builds()
+	if err != nil {
+		return err
+	}
+	secrets, err := v.listSecrets(buildFilter)
+	if err != nil {
+		return err
+	}
+	exists := map[string]bool{}
+	for _, s := range secrets {
+		exists[s.Labels["build"]] = true
+	}
+	for _, bid := range ids {
+		if exists[bid] {
+			continue
+		}
+		result := ArtifactResult{Build: bid}
+		if v.dryRun {
+			logging.With(logging.BuildIDKey, bid).Infof("Would delete the artifacts of deleted build %s", bid)
+		} else if err := v.artifacts.DeleteBuild(bid); err != nil {
+			logging.With(logging.BuildIDKey, bid).Errorf("Failed to delete the artifacts of deleted build %s: %s", bid, err)
+			result.Error = err.Error()
+		} else {
+			logging.With(logging.BuildIDKey, bid).Infof("Deleted the artifacts of deleted build %s", bid)
+		}
+		v.result.Artifacts = append(v.result.Artifacts, result)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package vacuum
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/brigadecore/brigade/pkg/artifacts"
+)
+
+func TestRun_Artifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := artifacts.NewFileStore(dir)
+	// 123456 is expired, 723457 is kept and 999999 was deleted.
+	for _, bid := range []string{"123456", "723457", "999999"} {
+		if _, err := store.Put(bid, "report.html", "text/html", strings.NewReader("report")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v := New(time.Now(), NoMaxBuilds, false, setupFakeClient(), v1.NamespaceDefault)
+	v.CleanArtifacts(store)
+	v.SetDryRun(true)
+	result, err := v.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Artifacts) != 1 || result.Artifacts[0].Build != "999999" {
+		t.Errorf("expected the artifacts of the deleted build to be listed, got %+v", result.Artifacts)
+	}
+	for _, b := range result.Deleted {
+		if b.Build == "123456" && b.Artifacts != 1 {
+			t.Errorf("expected build 123456 to have 1 artifact, got %d", b.Artifacts)
+		}
+	}
+	if builds, _ := store.Builds(); len(builds) != 3 {
+		t.Errorf("expected a dry run to keep all artifacts, got %v", builds)
+	}
+
+	v.SetDryRun(false)
+	if _, err := v.Run(); err != nil {
+		t.Fatal(err)
+	}
+	builds, err := store.Builds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 1 || builds[0] != "723457" {
+		t.Errorf("expected only the artifacts of the kept build, got %v", builds)
+	}
+}
@@ -0,0 +1,59 @@
+package vacuum
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+)
+
+const cacheFilter = "component = jobCache, heritage = brigade"
+
+// CleanOrphanedCaches makes the vacuum delete the job cache PVCs of projects
+// that no longer exist, once they are older than minAge.
+//
+// Caches of existing projects are never deleted, as they are shared by all
+// builds of the project, and neither are caches without a project label.
+func (v *Vacuum) CleanOrphanedCaches(minAge time.Duration) {
+	v.cleanCaches = true
+	v.cacheMinAge = minAge
+}
+
+// cleanOrphanedCaches deletes the job cache PVCs of deleted projects.
+func (v *Vacuum) cleanOrphanedCaches() error {
+	projects, err := v.listSecrets(projectFilter)
+	if err != nil {
+		return err
+	}
+	exists := map[string]bool{}
+	for _, p := range projects {
+		exists[p.Name] = true
+	}
+
+	pvcClient := v.client.CoreV1().PersistentVolumeClaims(v.namespace)
+	pvcs, err := pvcClient.List(context.TODO(), metav1.ListOptions{LabelSelector: cacheFilter})
+	if err != nil {
+		return err
+	}
+	for _, pvc := range pvcs.Items {
+		pid := pvc.Labels["project"]
+		// A cache without a project label cannot be told apart from the cache
+		// of an existing project, so it is kept.
+		if pid == "" || exists[pid] || time.Since(pvc.CreationTimestamp.Time) < v.cacheMinAge {
+			continue
+		}
+		cache := CacheResult{PersistentVolumeClaim: pvc.Name, Project: pid}
+		if v.dryRun {
+			logging.With(logging.ProjectIDKey, pid).Infof("Would delete cache %s of deleted project %s", pvc.Name, pid)
+		} else if err := pvcClient.Delete(context.TODO(), pvc.Name, metav1.DeleteOptions{}); err != nil {
+			logging.With(logging.ProjectIDKey, pid).Errorf("Failed to delete cache %s of deleted project %s: %s", pvc.Name, pid, err)
+			cache.Error = err.Error()
+		} else {
+			logging.With(logging.ProjectIDKey, pid).Infof("Deleted cache %s of deleted project %s", pvc.Name, pid)
+		}
+		v.result.Caches = append(v.result.Caches, cache)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package vacuum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRun_OrphanedCaches(t *testing.T) {
+	cache := func(name, project string, age time.Duration) *v1.PersistentVolumeClaim {
+		pvc := &v1.PersistentVolumeClaim{ObjectMeta: meta.ObjectMeta{
+			Name:              name,
+			Namespace:         v1.NamespaceDefault,
+			CreationTimestamp: meta.NewTime(time.Now().Add(-age)),
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "jobCache",
+				"project":   project,
+				"job":       "test",
+			},
+		}}
+		if project == "" {
+			delete(pvc.Labels, "project")
+		}
+		return pvc
+	}
+	client := fake.NewSimpleClientset(
+		&v1.Secret{ObjectMeta: meta.ObjectMeta{
+			Name:      "brigade-ahab",
+			Namespace: v1.NamespaceDefault,
+			Labels:    map[string]string{"heritage": "brigade", "component": "project"},
+		}},
+		cache("ahab-test", "brigade-ahab", 48*time.Hour),
+		cache("pequod-test", "brigade-pequod", 48*time.Hour),
+		cache("rachel-test", "brigade-rachel", time.Hour),
+		cache("unlabeled-test", "", 48*time.Hour),
+	)
+
+	v := New(NoMaxAge, NoMaxBuilds, false, client, v1.NamespaceDefault)
+	v.CleanOrphanedCaches(24 * time.Hour)
+	v.SetDryRun(true)
+	result, err := v.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Caches) != 1 || result.Caches[0].PersistentVolumeClaim != "pequod-test" {
+		t.Fatalf("expected only the old cache of the deleted project to be listed, got %+v", result.Caches)
+	}
+	pvcs, _ := client.CoreV1().PersistentVolumeClaims(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{})
+	if len(pvcs.Items) != 4 {
+		t.Errorf("expected a dry run to keep all caches, got %d", len(pvcs.Items))
+	}
+
+	v.SetDryRun(false)
+	if _, err := v.Run(); err != nil {
+		t.Fatal(err)
+	}
+	pvcs, _ = client.CoreV1().PersistentVolumeClaims(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{})
+	if len(pvcs.Items) != 3 {
+		t.Fatalf("expected 3 caches to be kept, got %d", len(pvcs.Items))
+	}
+	for _, p := range pvcs.Items {
+		if p.Name == "pequod-test" {
+			t.Error("expected the cache of the deleted project to be deleted")
+		}
+	}
+}
+
+func TestRun_KeepsCachesByDefault(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.PersistentVolumeClaim{ObjectMeta: meta.ObjectMeta{
+		Name:      "pequod-test",
+		Namespace: v1.NamespaceDefault,
+		Labels:    map[string]string{"heritage": "brigade", "component": "jobCache", "project": "brigade-pequod"},
+	}})
+	result, err := New(NoMaxAge, NoMaxBuilds, false, client, v1.NamespaceDefault).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Caches) != 0 {
+		t.Errorf("expected no cache to be deleted, got %+v", result.Caches)
+	}
+}
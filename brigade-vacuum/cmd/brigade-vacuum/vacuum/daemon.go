@@ -0,0 +1,203 @@
+package vacuum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+)
+
+// daemonSecretFilter selects the secrets the daemon watches: the builds, and
+// the projects for their retention policies.
+const daemonSecretFilter = "heritage = brigade, component in (build, project)"
+
+// Daemon runs the vacuum continuously.
+//
+// It watches the builds, their workers and the projects, and runs the vacuum
+// as soon as one of them changes, so that builds are deleted as soon as they
+// fall outside the policy. Builds expire with time too, so the vacuum also runs
+// once per interval.
+type Daemon struct {
+	vacuum   *Vacuum
+	maxAge   time.Duration
+	interval time.Duration
+
+	// OnResult is called with the result of every run that handled a build or
+	// a cache.
+	OnResult func(*Result)
+
+	secretInformer cache.Controller
+	podInformer    cache.Controller
+	trigger        chan struct{}
+
+	mu      sync.Mutex
+	lastRun time.Time
+	lastErr error
+}
+
+// NewDaemon creates a daemon running the vacuum at least once per interval.
+//
+// Builds older than maxAge are deleted, unless maxAge is 0. The age of the
+// vacuum itself is ignored, as it does not move with time.
+func NewDaemon(v *Vacuum, maxAge, interval time.Duration) *Daemon {
+	d := &Daemon{
+		vacuum:   v,
+		maxAge:   maxAge,
+		interval: interval,
+		trigger:  make(chan struct{}, 1),
+	}
+	client := v.client
+	v.secrets, d.secretInformer = cache.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = daemonSecretFilter
+				return client.CoreV1().Secrets(v.namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = daemonSecretFilter
+				return client.CoreV1().Secrets(v.namespace).Watch(context.TODO(), options)
+			},
+		},
+		&v1.Secret{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) { d.Trigger() },
+			UpdateFunc: func(old, new interface{}) {
+				// The retention policy of a project may have changed.
+				if new.(*v1.Secret).Labels["component"] == "project" {
+					d.Trigger()
+				}
+			},
+		},
+		cache.Indexers{},
+	)
+	v.pods, d.podInformer = cache.NewIndexerInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = buildFilter
+				return client.CoreV1().Pods(v.namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = buildFilter
+				return client.CoreV1().Pods(v.namespace).Watch(context.TODO(), options)
+			},
+		},
+		&v1.Pod{},
+		0,
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, new interface{}) {
+				// Completed builds may be deleted by policies that keep
+				// running or successful builds.
+				if old.(*v1.Pod).Status.Phase != new.(*v1.Pod).Status.Phase {
+					d.Trigger()
+				}
+			},
+		},
+		cache.Indexers{},
+	)
+	return d
+}
+
+// Trigger requests a run of the vacuum. Requests made while a run is pending
+// are coalesced.
+func (d *Daemon) Trigger() {
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// HasSynced returns true if the informers of the daemon have synced.
+func (d *Daemon) HasSynced() bool {
+	return d.secretInformer.HasSynced() && d.podInformer.HasSynced()
+}
+
+// Run runs the vacuum until stopCh is closed.
+func (d *Daemon) Run(stopCh <-chan struct{}) {
+	go d.secretInformer.Run(stopCh)
+	go d.podInformer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, d.HasSynced) {
+		logging.Errorf("Timed out waiting for the caches to sync")
+		return
+	}
+	logging.Infof("Vacuum daemon started, running at least every %s", d.interval)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	d.Trigger()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		case <-d.trigger:
+		}
+		d.runOnce()
+	}
+}
+
+// runOnce runs the vacuum and records its outcome.
+func (d *Daemon) runOnce() {
+	if d.maxAge > 0 {
+		d.vacuum.age = time.Now().Add(-d.maxAge)
+	}
+	start := time.Now()
+	result, err := d.vacuum.Run()
+	vacuumRunDuration.Observe(time.Since(start).Seconds())
+	recordMetrics(result)
+	if err != nil {
+		logging.Errorf("Vacuum run failed: %s", err)
+		vacuumRuns.WithLabelValues("error").Inc()
+	} else {
+		vacuumRuns.WithLabelValues("success").Inc()
+		vacuumLastSuccess.SetToCurrentTime()
+	}
+
+	d.mu.Lock()
+	d.lastRun = time.Now()
+	d.lastErr = err
+	d.mu.Unlock()
+
+	handled := len(result.Deleted) + len(result.Skipped) + len(result.Failed) + len(result.Caches)
+	if handled > 0 && d.OnResult != nil {
+		d.OnResult(result)
+	}
+}
+
+// Healthy returns an error if the informers have not synced, or if the last
+// run failed or is older than twice the interval.
+func (d *Daemon) Healthy() error {
+	if !d.HasSynced() {
+		return errors.New("the caches have not synced")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case d.lastRun.IsZero():
+		return errors.New("the vacuum has not run yet")
+	case d.lastErr != nil:
+		return fmt.Errorf("the last run failed: %s", d.lastErr)
+	case time.Since(d.lastRun) > 2*d.interval:
+		return fmt.Errorf("the vacuum has not run since %s", d.lastRun.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ServeHTTP serves the health of the daemon.
+func (d *Daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := d.Healthy(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,76 @@
+package vacuum
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDaemon(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	secrets := client.CoreV1().Secrets(v1.NamespaceDefault)
+	createBuild := func(bid string, age time.Duration) {
+		secrets.Create(context.TODO(), &v1.Secret{ObjectMeta: meta.ObjectMeta{
+			Name:              "brigade-worker-" + bid,
+			CreationTimestamp: meta.NewTime(time.Now().Add(-age)),
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "build",
+				"project":   "moby-dick",
+				"build":     bid,
+			},
+		}}, meta.CreateOptions{})
+	}
+	builds := func() []string {
+		list, _ := secrets.List(context.TODO(), meta.ListOptions{})
+		names := []string{}
+		for _, s := range list.Items {
+			names = append(names, s.Labels["build"])
+		}
+		return names
+	}
+	waitForBuilds := func(expected ...string) {
+		t.Helper()
+		err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			return fmt.Sprint(builds()) == fmt.Sprint(expected), nil
+		})
+		if err != nil {
+			t.Fatalf("expected builds %v, got %v", expected, builds())
+		}
+	}
+
+	createBuild("ahab", 72*time.Hour)
+	createBuild("ishmael", 2*time.Hour)
+	createBuild("queequeg", time.Hour)
+
+	d := NewDaemon(New(NoMaxAge, 2, false, client, v1.NamespaceDefault), 48*time.Hour, time.Hour)
+	handler := httptest.NewRecorder()
+	d.ServeHTTP(handler, nil)
+	if handler.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected the daemon to be unhealthy before it started, got %d", handler.Code)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go d.Run(stopCh)
+
+	// The build older than the max age is deleted by the first run.
+	waitForBuilds("ishmael", "queequeg")
+	// A new build is over the max, and the oldest build is deleted right away.
+	createBuild("starbuck", 0)
+	waitForBuilds("queequeg", "starbuck")
+
+	handler = httptest.NewRecorder()
+	d.ServeHTTP(handler, nil)
+	if handler.Code != http.StatusOK {
+		t.Errorf("expected the daemon to be healthy, got %d: %s", handler.Code, handler.Body)
+	}
+}
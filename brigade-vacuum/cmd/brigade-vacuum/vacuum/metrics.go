@@ -0,0 +1,62 @@
+package vacuum
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	vacuumRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "brigade",
+		Subsystem: "vacuum",
+		Name:      "runs_total",
+		Help:      "Number of vacuum runs, by result.",
+	}, []string{"result"})
+	vacuumLastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "brigade",
+		Subsystem: "vacuum",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Time of the last successful vacuum run.",
+	})
+	vacuumRunDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "brigade",
+		Subsystem: "vacuum",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of the vacuum runs.",
+	})
+	vacuumBuilds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "brigade",
+		Subsystem: "vacuum",
+		Name:      "builds_total",
+		Help:      "Number of builds handled by the vacuum, by outcome and reason.",
+	}, []string{"outcome", "reason"})
+	vacuumCaches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "brigade",
+		Subsystem: "vacuum",
+		Name:      "caches_total",
+		Help:      "Number of orphaned job caches handled by the vacuum, by outcome.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(vacuumRuns, vacuumLastSuccess, vacuumRunDuration, vacuumBuilds, vacuumCaches)
+}
+
+// recordMetrics records the builds and caches handled by a run.
+func recordMetrics(result *Result) {
+	for outcome, builds := range map[string][]BuildResult{
+		outcomeDeleted: result.Deleted,
+		outcomeSkipped: result.Skipped,
+		outcomeFailed:  result.Failed,
+	} {
+		for _, b := range builds {
+			vacuumBuilds.WithLabelValues(outcome, b.Reason).Inc()
+		}
+	}
+	for _, c := range result.Caches {
+		outcome := outcomeDeleted
+		if c.Error != "" {
+			outcome = outcomeFailed
+		}
+		vacuumCaches.WithLabelValues(outcome).Inc()
+	}
+}
@@ -0,0 +1,90 @@
+package vacuum
+
+// These are the reasons for which the vacuum deletes a build, besides the
+// reasons of the per-project retention.
+const (
+	reasonGlobalAge       = "global-age"
+	reasonGlobalMaxBuilds = "global-max-builds"
+)
+
+// These are the outcomes of the deletion of a build.
+const (
+	outcomeDeleted = "deleted"
+	outcomeSkipped = "skipped"
+	outcomeFailed  = "failed"
+)
+
+// Result reports what a vacuum run did, or would do in a dry run.
+type Result struct {
+	// DryRun is true if nothing was actually deleted.
+	DryRun bool `json:"dryRun"`
+	// Deleted are the builds deleted, or that would be deleted in a dry run.
+	Deleted []BuildResult `json:"deleted"`
+	// Skipped are the expired builds that were kept because they are running.
+	Skipped []BuildResult `json:"skipped"`
+	// Failed are the builds that could not be deleted.
+	Failed []BuildResult `json:"failed"`
+	// Projects summarizes the per-project retention.
+	Projects []ProjectSummary `json:"projects"`
+	// Caches are the job cache PVCs of deleted projects that were deleted.
+	Caches []CacheResult `json:"caches"`
+	// Artifacts are the artifacts of deleted builds that were deleted.
+	Artifacts []ArtifactResult `json:"artifacts"`
+}
+
+// BuildResult describes a build the vacuum deleted or tried to delete.
+type BuildResult struct {
+	Build   string `json:"build"`
+	Project string `json:"project"`
+	// Reason is the limit the build exceeded.
+	Reason string `json:"reason"`
+	// Pods are the worker and job pods of the build.
+	Pods []string `json:"pods,omitempty"`
+	// Secrets are the build and job secrets of the build.
+	Secrets []string `json:"secrets,omitempty"`
+	// PersistentVolumeClaims are the build storage of the build.
+	PersistentVolumeClaims []string `json:"persistentVolumeClaims,omitempty"`
+	// Artifacts is the number of artifacts of the build.
+	Artifacts int `json:"artifacts,omitempty"`
+	// Error is set for failed builds.
+	Error string `json:"error,omitempty"`
+}
+
+// CacheResult describes a job cache PVC the vacuum deleted or tried to delete.
+type CacheResult struct {
+	PersistentVolumeClaim string `json:"persistentVolumeClaim"`
+	Project               string `json:"project"`
+	// Error is set if the PVC could not be deleted.
+	Error string `json:"error,omitempty"`
+}
+
+// ArtifactResult describes the artifacts of a deleted build the vacuum deleted
+// or tried to delete.
+type ArtifactResult struct {
+	Build string `json:"build"`
+	// Error is set if the artifacts could not be deleted.
+	Error string `json:"error,omitempty"`
+}
+
+// handled returns true if the build was already deleted, skipped or failed
+// during the run.
+func (r *Result) handled(bid string) bool {
+	for _, list := range [][]BuildResult{r.Deleted, r.Skipped, r.Failed} {
+		for _, b := range list {
+			if b.Build == bid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleted returns true if the build was deleted during the run.
+func (r *Result) deleted(bid string) bool {
+	for _, b := range r.Deleted {
+		if b.Build == bid {
+			return true
+		}
+	}
+	return false
+}
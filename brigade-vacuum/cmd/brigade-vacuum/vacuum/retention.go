@@ -0,0 +1,206 @@
+package vacuum
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+const projectFilter = "component = project, heritage = brigade"
+
+// These are the reasons for which the retention deletes a build.
+const (
+	reasonMaxBuilds = "max-builds"
+	reasonAge       = "age"
+	reasonFailedAge = "failed-age"
+)
+
+// PolicyFile configures the retention of builds per project. Projects that
+// set their own retention policy are not affected by it.
+type PolicyFile struct {
+	// Default is the policy of the projects not listed in Projects.
+	Default brigade.RetentionPolicy `json:"default"`
+	// Projects maps project names or IDs to their policy.
+	Projects map[string]brigade.RetentionPolicy `json:"projects"`
+}
+
+// LoadPolicyFile reads a YAML or JSON policy file.
+func LoadPolicyFile(path string) (*PolicyFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policies := &PolicyFile{}
+	if err := yaml.UnmarshalStrict(data, policies); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %s", path, err)
+	}
+	return policies, nil
+}
+
+// ProjectSummary reports what the retention did with the builds of a project.
+type ProjectSummary struct {
+	Project string `json:"project"`
+	// Builds is the number of builds the project had.
+	Builds int `json:"builds"`
+	// Expired counts the builds to delete by reason.
+	Expired map[string]int `json:"expired"`
+	// Deleted is the number of builds deleted, or that would be in a dry run.
+	Deleted int `json:"deleted"`
+	// Skipped is the number of expired builds kept because they are running.
+	Skipped int `json:"skipped"`
+	// Failed is the number of builds that could not be deleted.
+	Failed int `json:"failed"`
+}
+
+// String formats the summary for the log.
+func (s ProjectSummary) String() string {
+	expired := s.Expired[reasonMaxBuilds] + s.Expired[reasonAge] + s.Expired[reasonFailedAge]
+	return fmt.Sprintf("project %s: %d builds, %d to delete (%d %s, %d %s, %d %s), %d deleted, %d skipped, %d failed",
+		s.Project, s.Builds, expired,
+		s.Expired[reasonMaxBuilds], reasonMaxBuilds, s.Expired[reasonAge], reasonAge, s.Expired[reasonFailedAge], reasonFailedAge,
+		s.Deleted, s.Skipped, s.Failed)
+}
+
+// UsePolicies sets the policies of the projects that do not have their own.
+func (v *Vacuum) UsePolicies(policies *PolicyFile) {
+	v.policies = policies
+}
+
+// policyFor returns the retention policy of a project.
+func (v *Vacuum) policyFor(id string, proj *brigade.Project) brigade.RetentionPolicy {
+	if proj != nil && !proj.Retention.IsZero() {
+		return proj.Retention
+	}
+	if v.policies == nil {
+		return brigade.RetentionPolicy{}
+	}
+	if proj != nil {
+		if p, ok := v.policies.Projects[proj.Name]; ok {
+			return p
+		}
+	}
+	if p, ok := v.policies.Projects[id]; ok {
+		return p
+	}
+	return v.policies.Default
+}
+
+// applyRetention deletes the builds that the retention policy of their project
+// does not keep, and returns a summary per project.
+func (v *Vacuum) applyRetention() ([]ProjectSummary, error) {
+	projectSecrets, err := v.listSecrets(projectFilter)
+	if err != nil {
+		return nil, err
+	}
+	projects := map[string]*brigade.Project{}
+	for i := range projectSecrets {
+		s := &projectSecrets[i]
+		proj, err := kube.NewProjectFromSecret(s, v.namespace)
+		if err != nil {
+			logging.With(logging.ProjectIDKey, s.Name).Warnf("Ignoring the retention policy of project %s: %s", s.Name, err)
+			continue
+		}
+		projects[s.Name] = proj
+	}
+
+	secrets, err := v.listSecrets(buildFilter)
+	if err != nil {
+		return nil, err
+	}
+	workers, err := v.listPods(buildFilter)
+	if err != nil {
+		return nil, err
+	}
+	phases := map[string]v1.PodPhase{}
+	for _, p := range workers {
+		phases[p.Labels["build"]] = p.Status.Phase
+	}
+
+	builds := map[string][]v1.Secret{}
+	for _, s := range secrets {
+		pid := s.Labels["project"]
+		builds[pid] = append(builds[pid], s)
+	}
+	ids := make([]string, 0, len(builds))
+	for id := range builds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	summaries := []ProjectSummary{}
+	now := time.Now()
+	for _, id := range ids {
+		policy := v.policyFor(id, projects[id])
+		if policy.IsZero() {
+			continue
+		}
+		summary := ProjectSummary{Project: id, Builds: len(builds[id]), Expired: map[string]int{}}
+		if proj, ok := projects[id]; ok {
+			summary.Project = proj.Name
+		}
+		expired := expiredBuilds(policy, builds[id], phases, now)
+		for _, reason := range expired {
+			summary.Expired[reason]++
+		}
+		for _, s := range builds[id] {
+			reason, ok := expired[s.Labels["build"]]
+			if !ok {
+				continue
+			}
+			switch v.deleteBuild(s, reason) {
+			case outcomeDeleted:
+				summary.Deleted++
+			case outcomeSkipped:
+				summary.Skipped++
+			case outcomeFailed:
+				summary.Failed++
+			}
+		}
+		logging.Infof("%s", summary)
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// expiredBuilds returns the IDs of the builds of a project that the policy
+// does not keep, with the reason.
+func expiredBuilds(policy brigade.RetentionPolicy, builds []v1.Secret, phases map[string]v1.PodPhase, now time.Time) map[string]string {
+	sorted := make([]v1.Secret, len(builds))
+	copy(sorted, builds)
+	sort.Sort(ByCreation(sorted))
+	maxAge, maxFailedAge := policy.Ages()
+
+	expired := map[string]string{}
+	successful := 0
+	for i, s := range sorted {
+		bid, ok := s.Labels["build"]
+		if !ok {
+			continue
+		}
+		phase := phases[bid]
+		if phase == v1.PodSucceeded {
+			successful++
+			if successful <= policy.KeepSuccessful {
+				continue
+			}
+		}
+		age := now.Sub(s.CreationTimestamp.Time)
+		switch {
+		case policy.MaxBuilds > 0 && i >= policy.MaxBuilds:
+			expired[bid] = reasonMaxBuilds
+		case maxAge > 0 && age > maxAge:
+			expired[bid] = reasonAge
+		case maxFailedAge > 0 && phase == v1.PodFailed && age > maxFailedAge:
+			expired[bid] = reasonFailedAge
+		}
+	}
+	return expired
+}
@@ -0,0 +1,186 @@
+package vacuum
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// retentionBuild returns a build secret and its worker pod.
+func retentionBuild(project, id string, age time.Duration, phase v1.PodPhase) (*v1.Secret, *v1.Pod) {
+	created := meta.NewTime(time.Now().Add(-age))
+	labels := map[string]string{
+		"heritage":  "brigade",
+		"component": "build",
+		"project":   project,
+		"build":     id,
+	}
+	secret := &v1.Secret{ObjectMeta: meta.ObjectMeta{
+		Name:              "brigade-worker-" + id,
+		Namespace:         v1.NamespaceDefault,
+		Labels:            labels,
+		CreationTimestamp: created,
+	}}
+	pod := &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:              "brigade-worker-" + id,
+			Namespace:         v1.NamespaceDefault,
+			Labels:            labels,
+			CreationTimestamp: created,
+		},
+		Status: v1.PodStatus{Phase: phase},
+	}
+	return secret, pod
+}
+
+func TestExpiredBuilds(t *testing.T) {
+	// b1 is the most recent build, b6 the oldest.
+	phases := map[string]v1.PodPhase{
+		"b1": v1.PodRunning,
+		"b2": v1.PodFailed,
+		"b3": v1.PodSucceeded,
+		"b4": v1.PodFailed,
+		"b5": v1.PodSucceeded,
+		"b6": v1.PodSucceeded,
+	}
+	builds := []v1.Secret{}
+	for i := 6; i >= 1; i-- {
+		id := fmt.Sprintf("b%d", i)
+		s, _ := retentionBuild("p", id, time.Duration(i)*24*time.Hour, phases[id])
+		builds = append(builds, *s)
+	}
+
+	tests := []struct {
+		name     string
+		policy   brigade.RetentionPolicy
+		expected map[string]string
+	}{
+		{"no limit", brigade.RetentionPolicy{}, map[string]string{}},
+		{"max builds", brigade.RetentionPolicy{MaxBuilds: 4}, map[string]string{"b5": reasonMaxBuilds, "b6": reasonMaxBuilds}},
+		{"max age", brigade.RetentionPolicy{MaxAge: "100h"}, map[string]string{"b5": reasonAge, "b6": reasonAge}},
+		{"max failed age", brigade.RetentionPolicy{MaxFailedAge: "36h"}, map[string]string{"b2": reasonFailedAge, "b4": reasonFailedAge}},
+		{"keep successful", brigade.RetentionPolicy{MaxBuilds: 1, KeepSuccessful: 2}, map[string]string{
+			"b2": reasonMaxBuilds, "b4": reasonMaxBuilds, "b6": reasonMaxBuilds,
+		}},
+		{"combined", brigade.RetentionPolicy{MaxBuilds: 5, MaxAge: "110h", MaxFailedAge: "60h", KeepSuccessful: 1}, map[string]string{
+			"b4": reasonFailedAge, "b5": reasonAge, "b6": reasonMaxBuilds,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expiredBuilds(tt.policy, builds, phases, time.Now())
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRun_Retention(t *testing.T) {
+	objects := []runtime.Object{
+		&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "chatty",
+				Namespace:   v1.NamespaceDefault,
+				Annotations: map[string]string{"projectName": "brigadecore/chatty"},
+				Labels:      map[string]string{"heritage": "brigade", "component": "project"},
+			},
+			Data: map[string][]byte{"retention.maxBuilds": []byte("2")},
+		},
+		&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        "quiet",
+				Namespace:   v1.NamespaceDefault,
+				Annotations: map[string]string{"projectName": "brigadecore/quiet"},
+				Labels:      map[string]string{"heritage": "brigade", "component": "project"},
+			},
+		},
+	}
+	for i := 1; i <= 5; i++ {
+		s, p := retentionBuild("chatty", fmt.Sprintf("chatty%d", i), time.Duration(i)*time.Hour, v1.PodSucceeded)
+		objects = append(objects, s, p)
+	}
+	for i := 1; i <= 2; i++ {
+		s, p := retentionBuild("quiet", fmt.Sprintf("quiet%d", i), time.Duration(i)*24*time.Hour, v1.PodFailed)
+		objects = append(objects, s, p)
+	}
+	client := fake.NewSimpleClientset(objects...)
+
+	v := New(NoMaxAge, NoMaxBuilds, false, client, v1.NamespaceDefault)
+	v.UsePolicies(&PolicyFile{
+		Default: brigade.RetentionPolicy{MaxAge: "720h"},
+		Projects: map[string]brigade.RetentionPolicy{
+			"brigadecore/quiet": {MaxFailedAge: "36h"},
+		},
+	})
+	result, err := v.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	summaries := result.Projects
+
+	expected := []ProjectSummary{
+		{Project: "brigadecore/chatty", Builds: 5, Expired: map[string]int{reasonMaxBuilds: 3}, Deleted: 3},
+		{Project: "brigadecore/quiet", Builds: 2, Expired: map[string]int{reasonFailedAge: 1}, Deleted: 1},
+	}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Errorf("expected summaries %v, got %v", expected, summaries)
+	}
+
+	verifyPodsExist(t, client, "brigade-worker-chatty1", "brigade-worker-chatty2", "brigade-worker-quiet1")
+	verifyPodsDeleted(t, client, "brigade-worker-chatty3", "brigade-worker-chatty4", "brigade-worker-chatty5", "brigade-worker-quiet2")
+
+	secrets, _ := client.CoreV1().Secrets(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{LabelSelector: buildFilter})
+	if len(secrets.Items) != 3 {
+		t.Errorf("expected 3 builds to be kept, got %d", len(secrets.Items))
+	}
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-vacuum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "policy.yaml")
+	ioutil.WriteFile(path, []byte(`
+default:
+  maxAge: 720h
+projects:
+  brigadecore/brigade:
+    maxBuilds: 100
+    keepSuccessful: 5
+`), 0644)
+	policies, err := LoadPolicyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &PolicyFile{
+		Default: brigade.RetentionPolicy{MaxAge: "720h"},
+		Projects: map[string]brigade.RetentionPolicy{
+			"brigadecore/brigade": {MaxBuilds: 100, KeepSuccessful: 5},
+		},
+	}
+	if !reflect.DeepEqual(policies, expected) {
+		t.Errorf("expected %+v, got %+v", expected, policies)
+	}
+
+	ioutil.WriteFile(path, []byte("default:\n  maxBuild: 1\n"), 0644)
+	if _, err := LoadPolicyFile(path); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -2,14 +2,19 @@ package vacuum
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"sort"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 
+	"github.com/brigadecore/brigade/pkg/archive"
+	"github.com/brigadecore/brigade/pkg/artifacts"
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage"
 	"github.com/brigadecore/brigade/pkg/storage/kube"
 )
@@ -31,6 +36,21 @@ type Vacuum struct {
 	skipRunningBuilds bool
 	namespace         string
 	client            kubernetes.Interface
+	store             storage.ContextStore
+	archiver          *archive.Archiver
+	artifacts         artifacts.Store
+	policies          *PolicyFile
+	dryRun            bool
+	cleanCaches       bool
+	cacheMinAge       time.Duration
+
+	// secrets and pods are the informer caches of the daemon. When they are
+	// nil, builds are listed from the API server.
+	secrets cache.Indexer
+	pods    cache.Indexer
+
+	// result is the result of the current run.
+	result *Result
 }
 
 // New creates a new *Vacuum.
@@ -41,75 +61,231 @@ func New(age time.Time, max int, skipRunningBuilds bool, client kubernetes.Inter
 		skipRunningBuilds: skipRunningBuilds,
 		client:            client,
 		namespace:         ns,
+		store:             kube.New(client, ns),
+	}
+}
+
+// ArchiveTo makes the vacuum archive the logs and metadata of every build
+// before deleting it.
+func (v *Vacuum) ArchiveTo(sink archive.Sink) {
+	v.archiver = &archive.Archiver{
+		Store: v.store,
+		Sink:  sink,
 	}
 }
 
+// SetDryRun makes the vacuum only report what it would delete.
+func (v *Vacuum) SetDryRun(dryRun bool) {
+	v.dryRun = dryRun
+}
+
 // Run executes the vacuum, destroying resources that are expired.
-func (v *Vacuum) Run() error {
-	opts := metav1.ListOptions{
-		LabelSelector: buildFilter,
+//
+// In a dry run, nothing is deleted and the result lists what would be.
+func (v *Vacuum) Run() (*Result, error) {
+	v.result = &Result{
+		DryRun:    v.dryRun,
+		Deleted:   []BuildResult{},
+		Skipped:   []BuildResult{},
+		Failed:    []BuildResult{},
+		Projects:  []ProjectSummary{},
+		Caches:    []CacheResult{},
+		Artifacts: []ArtifactResult{},
+	}
+
+	summaries, err := v.applyRetention()
+	if err != nil {
+		return v.result, err
+	}
+	v.result.Projects = summaries
+
+	if v.cleanCaches {
+		if err := v.cleanOrphanedCaches(); err != nil {
+			return v.result, err
+		}
+	}
+	if v.artifacts != nil {
+		if err := v.cleanOrphanedArtifacts(); err != nil {
+			return v.result, err
+		}
 	}
 
 	if !v.age.IsZero() {
-		log.Printf("Pruning records older than %s", v.age)
-		secrets, err := v.client.CoreV1().Secrets(v.namespace).List(context.TODO(), opts)
+		logging.Infof("Pruning records older than %s", v.age)
+		secrets, err := v.listSecrets(buildFilter)
 		if err != nil {
-			return err
+			return v.result, err
 		}
-		for _, s := range secrets.Items {
+		for _, s := range secrets {
 			ts := s.ObjectMeta.CreationTimestamp.Time
-			bid, ok := s.ObjectMeta.Labels["build"]
-			if !ok {
-				log.Printf("Build %q has no build ID. Skipping.\n", s.Name)
-				continue
-			}
 			if v.age.After(ts) {
-				if err := v.deleteBuild(bid); err != nil {
-					log.Printf("Failed to delete build %s: %s (age)\n", bid, err)
-					continue
-				}
+				v.deleteBuild(s, reasonGlobalAge)
 			}
 		}
 	}
 
 	// If no max, return now.
 	if v.max == NoMaxBuilds {
-		return nil
+		return v.result, nil
 	}
 
 	// We need to re-load the secrets list and see if we are still over the max.
-	secrets, err := v.client.CoreV1().Secrets(v.namespace).List(context.TODO(), opts)
+	secrets, err := v.listSecrets(buildFilter)
 	if err != nil {
-		return err
+		return v.result, err
+	}
+	// In a dry run, or when listing from the informer cache, the builds that
+	// were deleted may still be listed.
+	remaining := []v1.Secret{}
+	for _, s := range secrets {
+		if !v.result.deleted(s.Labels["build"]) {
+			remaining = append(remaining, s)
+		}
 	}
-	l := len(secrets.Items)
+	l := len(remaining)
 	if l <= v.max {
-		log.Printf("Skipping vacuum. %d is ≤ max %d", l, v.max)
-		return nil
+		logging.Infof("Skipping vacuum. %d is ≤ max %d", l, v.max)
+		return v.result, nil
 	}
-	sort.Sort(ByCreation(secrets.Items))
+	sort.Sort(ByCreation(remaining))
 	for i := v.max; i < l; i++ {
 		// Delete secret and builds
-		s := secrets.Items[i]
-		bid, ok := s.ObjectMeta.Labels["build"]
-		if !ok {
-			log.Printf("Build %q has no build ID. Skipping.\n", s.Name)
-			continue
+		v.deleteBuild(remaining[i], reasonGlobalMaxBuilds)
+	}
+
+	return v.result, nil
+}
+
+// deleteBuild deletes the pods and secrets of a build, and records the outcome
+// in the result of the run. It returns the outcome, or an empty string if the
+// build was already handled during the run.
+func (v *Vacuum) deleteBuild(s v1.Secret, reason string) string {
+	bid, ok := s.Labels["build"]
+	if !ok {
+		logging.Warnf("Build %q has no build ID. Skipping.", s.Name)
+		return ""
+	}
+	if v.result.handled(bid) {
+		return ""
+	}
+	build := BuildResult{Build: bid, Project: s.Labels["project"], Reason: reason}
+	fail := func(err error) string {
+		logging.With(logging.BuildIDKey, bid).Errorf("Failed to delete build %s: %s (%s)", bid, err, reason)
+		build.Error = err.Error()
+		v.result.Failed = append(v.result.Failed, build)
+		return outcomeFailed
+	}
+
+	opts := metav1.ListOptions{LabelSelector: kube.BuildResourcesSelector(bid)}
+	pods, err := v.client.CoreV1().Pods(v.namespace).List(context.TODO(), opts)
+	if err != nil {
+		return fail(err)
+	}
+	for _, p := range pods.Items {
+		build.Pods = append(build.Pods, p.Name)
+		if v.skipRunningBuilds && p.Labels["component"] == "build" &&
+			(p.Status.Phase == v1.PodRunning || p.Status.Phase == v1.PodPending) {
+			logging.With(logging.BuildIDKey, bid).Infof("skipping Build %s because its Status is %s", bid, p.Status.Phase)
+			v.result.Skipped = append(v.result.Skipped, build)
+			return outcomeSkipped
+		}
+	}
+	secrets, err := v.client.CoreV1().Secrets(v.namespace).List(context.TODO(), opts)
+	if err != nil {
+		return fail(err)
+	}
+	if len(secrets.Items) == 0 {
+		// The build was deleted since it was listed.
+		return ""
+	}
+	for _, sec := range secrets.Items {
+		build.Secrets = append(build.Secrets, sec.Name)
+	}
+	pvcs, err := v.client.CoreV1().PersistentVolumeClaims(v.namespace).List(context.TODO(), opts)
+	if err != nil {
+		return fail(err)
+	}
+	for _, pvc := range pvcs.Items {
+		build.PersistentVolumeClaims = append(build.PersistentVolumeClaims, pvc.Name)
+	}
+
+	if v.artifacts != nil {
+		list, err := v.artifacts.List(bid)
+		if err != nil {
+			return fail(err)
+		}
+		build.Artifacts = len(list)
+	}
+
+	if v.dryRun {
+		logging.With(logging.BuildIDKey, bid).Infof("Would delete build %s (%s)", bid, reason)
+		v.result.Deleted = append(v.result.Deleted, build)
+		return outcomeDeleted
+	}
+	if v.archiver != nil {
+		// Keep the build if it cannot be archived, its logs would be lost.
+		if err := v.archiver.ArchiveBuild(context.TODO(), bid); err != nil {
+			return fail(fmt.Errorf("failed to archive build: %s", err))
 		}
-		if err := v.deleteBuild(bid); err != nil {
-			log.Printf("Failed to delete build %s: %s (max)\n", bid, err)
-			continue
+	}
+	if v.artifacts != nil {
+		// The artifacts are deleted before the build, so that a build whose
+		// artifacts cannot be deleted is attempted again by the next run.
+		if err := v.artifacts.DeleteBuild(bid); err != nil {
+			return fail(fmt.Errorf("failed to delete artifacts: %s", err))
 		}
 	}
+	if err := v.store.DeleteBuild(context.TODO(), bid, storage.DeleteBuildOptions{}); err != nil {
+		return fail(err)
+	}
+	v.result.Deleted = append(v.result.Deleted, build)
+	return outcomeDeleted
+}
 
-	return nil
+// listSecrets lists the secrets matching the label selector, from the informer
+// cache when the vacuum runs as a daemon.
+func (v *Vacuum) listSecrets(selector string) ([]v1.Secret, error) {
+	if v.secrets == nil {
+		list, err := v.client.CoreV1().Secrets(v.namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	secrets := []v1.Secret{}
+	for _, obj := range v.secrets.List() {
+		if s := obj.(*v1.Secret); sel.Matches(labels.Set(s.Labels)) {
+			secrets = append(secrets, *s)
+		}
+	}
+	return secrets, nil
 }
 
-func (v *Vacuum) deleteBuild(bid string) error {
-	store := kube.New(v.client, v.namespace)
-	return store.DeleteBuild(bid, storage.DeleteBuildOptions{
-		SkipRunningBuilds: v.skipRunningBuilds,
-	})
+// listPods lists the pods matching the label selector, from the informer
+// cache when the vacuum runs as a daemon.
+func (v *Vacuum) listPods(selector string) ([]v1.Pod, error) {
+	if v.pods == nil {
+		list, err := v.client.CoreV1().Pods(v.namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	pods := []v1.Pod{}
+	for _, obj := range v.pods.List() {
+		if p := obj.(*v1.Pod); sel.Matches(labels.Set(p.Labels)) {
+			pods = append(pods, *p)
+		}
+	}
+	return pods, nil
 }
 
 // ByCreation sorts secrets by their creation timestamp.
@@ -2,6 +2,11 @@ package vacuum
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -11,6 +16,10 @@ import (
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/archive"
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
 )
 
 const (
@@ -39,13 +48,21 @@ func TestRun_Age(t *testing.T) {
 		t.Fatalf("expected 6 pods, got %d", len(pods.Items))
 	}
 
-	err = New(time.Now(), NoMaxBuilds, false, client, v1.NamespaceDefault).Run()
+	result, err := New(time.Now(), NoMaxBuilds, false, client, v1.NamespaceDefault).Run()
 	if err != nil {
 		t.Errorf("I blame fakeclient: %s", err)
 	}
+	verifyResult(t, result.Deleted, "123456", "234567")
+	verifyResult(t, result.Skipped)
+	verifyResult(t, result.Failed)
 
 	verifyPodsDeleted(t, client, testBuildPod1Name, testJobPod11Name, testBuildPod2Name, testJobPod21Name, testJobPod22Name)
 
+	pvcs, _ := client.CoreV1().PersistentVolumeClaims(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{})
+	if len(pvcs.Items) != 0 {
+		t.Errorf("expected the build storage to be deleted, got %d PVCs", len(pvcs.Items))
+	}
+
 	secrets, _ = client.CoreV1().Secrets(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{})
 	if len(secrets.Items) != 1 {
 		t.Fatalf("expected 1 secret, got %d", len(secrets.Items))
@@ -58,10 +75,16 @@ func TestRun_Age(t *testing.T) {
 
 func TestRun_Max(t *testing.T) {
 	client := setupFakeClient()
-	err := New(time.Time{}, 1, false, client, v1.NamespaceDefault).Run()
+	result, err := New(time.Time{}, 1, false, client, v1.NamespaceDefault).Run()
 	if err != nil {
 		t.Errorf("error running: %s", err)
 	}
+	verifyResult(t, result.Deleted, "123456", "234567")
+	for _, b := range result.Deleted {
+		if b.Reason != reasonGlobalMaxBuilds {
+			t.Errorf("expected build %s to be deleted for %s, got %s", b.Build, reasonGlobalMaxBuilds, b.Reason)
+		}
+	}
 
 	verifyPodsDeleted(t, client, testBuildPod1Name, testJobPod11Name, testBuildPod2Name, testJobPod21Name, testJobPod22Name)
 
@@ -92,10 +115,12 @@ func TestRun_SkipRunningBuilds(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = New(time.Now(), NoMaxBuilds, true, client, v1.NamespaceDefault).Run()
+	result, err := New(time.Now(), NoMaxBuilds, true, client, v1.NamespaceDefault).Run()
 	if err != nil {
 		t.Errorf("I blame fakeclient: %s", err)
 	}
+	verifyResult(t, result.Deleted, "123456")
+	verifyResult(t, result.Skipped, "234567")
 
 	verifyPodsExist(t, client, testBuildPod2Name, testJobPod21Name, testJobPod22Name)
 	verifyPodsDeleted(t, client, testBuildPod1Name, testJobPod11Name)
@@ -110,6 +135,65 @@ func TestRun_SkipRunningBuilds(t *testing.T) {
 	}
 }
 
+func TestRun_DryRun(t *testing.T) {
+	client := setupFakeClient()
+
+	v := New(time.Now(), 1, false, client, v1.NamespaceDefault)
+	v.SetDryRun(true)
+	result, err := v.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.DryRun {
+		t.Error("expected the result to be a dry run")
+	}
+	// The max-builds limit must not count the builds that would be deleted for their age.
+	verifyResult(t, result.Deleted, "123456", "234567")
+	verifyPodsExist(t, client, testBuildPod1Name, testJobPod11Name, testBuildPod2Name, testJobPod21Name, testJobPod22Name)
+
+	for _, b := range result.Deleted {
+		if b.Build != "234567" {
+			continue
+		}
+		expectedPods := []string{testBuildPod2Name, testJobPod21Name, testJobPod22Name}
+		if !reflect.DeepEqual(b.Pods, expectedPods) {
+			t.Errorf("expected pods %v, got %v", expectedPods, b.Pods)
+		}
+		expectedSecrets := []string{"queequeg2", "tashtego21", "tashtego22"}
+		if !reflect.DeepEqual(b.Secrets, expectedSecrets) {
+			t.Errorf("expected secrets %v, got %v", expectedSecrets, b.Secrets)
+		}
+		if !reflect.DeepEqual(b.PersistentVolumeClaims, []string{"queequeg2"}) {
+			t.Errorf("expected the build storage to be listed, got %v", b.PersistentVolumeClaims)
+		}
+		if b.Reason != reasonGlobalAge || b.Project != "moby-dick" {
+			t.Errorf("unexpected build result %+v", b)
+		}
+	}
+
+	secrets, _ := client.CoreV1().Secrets(v1.NamespaceDefault).List(context.TODO(), meta.ListOptions{})
+	if len(secrets.Items) != 6 {
+		t.Errorf("expected 6 secrets, got %d", len(secrets.Items))
+	}
+}
+
+// verifyResult checks the IDs of the builds of a result list.
+func verifyResult(t *testing.T, builds []BuildResult, ids ...string) {
+	t.Helper()
+	got := []string{}
+	for _, b := range builds {
+		got = append(got, b.Build)
+	}
+	sort.Strings(got)
+	if len(ids) == 0 {
+		ids = []string{}
+	}
+	if !reflect.DeepEqual(got, ids) {
+		t.Errorf("expected builds %v, got %v", ids, got)
+	}
+}
+
 func verifyPodsDeleted(t *testing.T, client kubernetes.Interface, podNames ...string) {
 	for _, podName := range podNames {
 		_, err := client.CoreV1().Pods(v1.NamespaceDefault).Get(context.TODO(), podName, meta.GetOptions{})
@@ -352,6 +436,20 @@ func setupFakeClient() kubernetes.Interface {
 		},
 	}
 
+	buildStorage := v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "queequeg2",
+			Labels: map[string]string{
+				"heritage":  "brigade",
+				"component": "buildStorage",
+				"project":   "moby-dick",
+				"build":     "234567",
+			},
+			CreationTimestamp: started,
+		},
+	}
+	client.CoreV1().PersistentVolumeClaims(v1.NamespaceDefault).Create(context.TODO(), &buildStorage, meta.CreateOptions{})
+
 	cb := client.CoreV1().Pods(v1.NamespaceDefault)
 	cb.Create(context.TODO(), &buildPod, meta.CreateOptions{})
 	cb.Create(context.TODO(), &jobPod, meta.CreateOptions{})
@@ -362,3 +460,105 @@ func setupFakeClient() kubernetes.Interface {
 
 	return client
 }
+
+// failingSink is an archive sink that cannot be written to.
+type failingSink struct {
+	archive.Sink
+}
+
+func (failingSink) Put(key string, data []byte) error {
+	return errors.NewServiceUnavailable("archive is down")
+}
+
+// logStore serves fixed logs, as the fake clientset cannot stream pod logs.
+type logStore struct {
+	storage.ContextStore
+}
+
+func (logStore) GetWorkerLog(ctx context.Context, w *brigade.Worker) (string, error) {
+	return "worker log", nil
+}
+func (logStore) GetWorkerInitLog(ctx context.Context, w *brigade.Worker) (string, error) {
+	return "init log", nil
+}
+func (logStore) GetJobLog(ctx context.Context, j *brigade.Job) (string, error) { return "job log", nil }
+
+// archiveTo is ArchiveTo, reading logs from a logStore.
+func archiveTo(v *Vacuum, sink archive.Sink) {
+	v.ArchiveTo(sink)
+	v.archiver.Store = logStore{v.archiver.Store}
+}
+
+func TestRun_Archive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-vacuum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := setupArchiveClient()
+	v := New(time.Now(), NoMaxBuilds, false, client, v1.NamespaceDefault)
+	archiveTo(v, archive.NewFileSink(dir))
+	if _, err := v.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	verifyPodsDeleted(t, client, "brigade-worker-ishmael", "ishmael-job")
+	for _, name := range []string{"builds/ishmael/build.json", "builds/ishmael/worker.log", "builds/ishmael/jobs.json", "jobs/ishmael-job.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be archived: %s", name, err)
+		}
+	}
+
+	client = setupArchiveClient()
+	v = New(time.Now(), NoMaxBuilds, false, client, v1.NamespaceDefault)
+	archiveTo(v, failingSink{})
+	result, err := v.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyResult(t, result.Failed, "ishmael")
+	verifyPodsExist(t, client, "brigade-worker-ishmael", "ishmael-job")
+}
+
+// setupArchiveClient creates a fake Kubernetes client with a completed build.
+func setupArchiveClient() kubernetes.Interface {
+	started := meta.NewTime(time.Now().AddDate(0, -1, 0))
+	labels := func(component string) map[string]string {
+		return map[string]string{
+			"heritage":  "brigade",
+			"component": component,
+			"project":   "moby-dick",
+			"build":     "ishmael",
+		}
+	}
+	pod := func(name, component string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:              name,
+				Namespace:         v1.NamespaceDefault,
+				Labels:            labels(component),
+				CreationTimestamp: started,
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{Image: "foo"}},
+			},
+			Status: v1.PodStatus{
+				Phase:     v1.PodSucceeded,
+				StartTime: &started,
+			},
+		}
+	}
+	return fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:              "brigade-worker-ishmael",
+				Namespace:         v1.NamespaceDefault,
+				Labels:            labels("build"),
+				CreationTimestamp: started,
+			},
+		},
+		pod("brigade-worker-ishmael", "build"),
+		pod("ishmael-job", "job"),
+	)
+}
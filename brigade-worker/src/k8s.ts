@@ -344,6 +344,21 @@ export class JobRunner implements jobs.JobRunner {
       }
     }
 
+    // Jobs upload artifacts and test results to the API server with the
+    // upload token of the build.
+    if (!("BRIGADE_UPLOAD_TOKEN" in job.env)) {
+      envVars.push({
+        name: "BRIGADE_UPLOAD_TOKEN",
+        valueFrom: {
+          secretKeyRef: {
+            name: e.workerID,
+            key: "upload_token",
+            optional: true
+          }
+        }
+      } as kubernetes.V1EnvVar);
+    }
+
     this.runner.spec.containers[0].env = envVars;
 
     let mountPath = job.mountPath || this.options.mountPath;
@@ -199,6 +199,14 @@ describe("k8s", function () {
             assert.equal(found, 1);
           });
         });
+        it("passes the upload token of the build", function () {
+          let jr = new k8s.JobRunner().init(j, e, p);
+          let token = jr.runner.spec.containers[0].env.find(
+            env => env.name == "BRIGADE_UPLOAD_TOKEN"
+          );
+          assert.equal(token.valueFrom.secretKeyRef.name, e.workerID);
+          assert.equal(token.valueFrom.secretKeyRef.key, "upload_token");
+        });
       });
       context("when resources are specified", function () {
         beforeEach(function () {
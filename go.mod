@@ -20,6 +20,7 @@ require (
 	github.com/fatih/color v1.9.0 // indirect
 	github.com/gdamore/tcell v1.3.0 // indirect
 	github.com/gin-gonic/gin v1.5.0 // indirect
+	github.com/go-ini/ini v1.42.0 // indirect
 	github.com/go-openapi/jsonreference v0.19.3 // indirect
 	github.com/go-openapi/spec v0.19.6
 	github.com/go-openapi/swag v0.19.7 // indirect
@@ -31,16 +32,23 @@ require (
 	github.com/imdario/mergo v0.3.8 // indirect
 	github.com/json-iterator/go v1.1.9 // indirect
 	github.com/leodido/go-urn v1.2.0 // indirect
+	github.com/lib/pq v1.8.0
 	github.com/lucasb-eyer/go-colorful v1.0.3 // indirect
 	github.com/mailru/easyjson v0.7.1 // indirect
 	github.com/mattn/go-isatty v0.0.12 // indirect
 	github.com/mattn/go-runewidth v0.0.8 // indirect
+	github.com/minio/minio-go v6.0.14+incompatible
 	github.com/oklog/ulid v1.3.1
+	github.com/prometheus/client_golang v0.9.4
 	github.com/rivo/tview v0.0.0-20180728193050-6614b16d9037
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/slok/brigadeterm v0.11.1
 	github.com/spf13/cobra v1.0.0
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
-	golang.org/x/sys v0.0.0-20200219091948-cb0a6d8edb6c // indirect
 	golang.org/x/time v0.0.0-20191024005414-555d28b269f0 // indirect
 	gopkg.in/AlecAivazis/survey.v1 v1.8.8
 	gopkg.in/gin-gonic/gin.v1 v1.1.5-0.20170702092826-d459835d2b07
@@ -50,4 +58,6 @@ require (
 	k8s.io/apimachinery v0.18.2
 	k8s.io/client-go v2.0.0-alpha.0.0.20181016174657-85ed251159e4+incompatible
 	k8s.io/kube-openapi v0.0.0-20200204173128-addea2498afe // indirect
+	modernc.org/sqlite v1.14.6
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -6,11 +6,11 @@ import (
 
 // API represents the rest api handlers.
 type API struct {
-	store storage.Store
+	store storage.ContextStore
 }
 
 // New creates a new api handler.
-func New(s storage.Store) API {
+func New(s storage.ContextStore) API {
 	return API{store: s}
 }
 
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	restful "github.com/emicklei/go-restful"
+
+	"github.com/brigadecore/brigade/pkg/artifacts"
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/testresults"
+)
+
+// MaxArtifactSize is the maximum size of an uploaded artifact, in bytes.
+var MaxArtifactSize int64 = 100 << 20
+
+// MaxBuildArtifactsSize is the maximum total size of the artifacts and test
+// results of a build, in bytes.
+var MaxBuildArtifactsSize int64 = 1 << 30
+
+// errArtifactTooLarge is returned by the reader of uploads larger than
+// MaxArtifactSize, or than what is left of MaxBuildArtifactsSize.
+var errArtifactTooLarge = errors.New("artifact too large")
+
+// Artifacts represents the artifact api handlers.
+type Artifacts struct {
+	store     storage.ContextStore
+	artifacts artifacts.Store
+}
+
+// Artifacts returns a handler for the artifacts of builds, kept in the given store.
+func (api API) Artifacts(s artifacts.Store) Artifacts {
+	return Artifacts{store: api.store, artifacts: s}
+}
+
+// List creates a new gin handler for the GET /build/:id/artifacts endpoint
+func (api Artifacts) List(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	id := request.PathParameter("id")
+	if _, err := api.store.GetBuild(ctx, id); err != nil {
+		response.WriteErrorString(http.StatusNotFound, "Build could not be found.")
+		return
+	}
+	list, err := api.artifacts.List(id)
+	if err != nil {
+		response.WriteErrorString(http.StatusInternalServerError, "Build Artifacts could not be read.")
+		return
+	}
+	response.WriteEntity(list)
+}
+
+// Get creates a new gin handler for the GET /build/:id/artifacts/:path endpoint
+func (api Artifacts) Get(request *restful.Request, response *restful.Response) {
+	a, content, err := api.artifacts.Get(request.PathParameter("id"), request.PathParameter("path"))
+	if err == artifacts.ErrNotFound || err == artifacts.ErrInvalidPath {
+		response.WriteErrorString(http.StatusNotFound, "Artifact could not be found.")
+		return
+	}
+	if err != nil {
+		response.WriteErrorString(http.StatusInternalServerError, "Artifact could not be read.")
+		return
+	}
+	defer content.Close()
+	h := response.Header()
+	h.Set("Content-Type", a.ContentType)
+	h.Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(a.Path)}))
+	response.WriteHeader(http.StatusOK)
+	io.Copy(response.ResponseWriter, content)
+}
+
+// Put creates a new gin handler for the PUT /build/:id/artifacts/:path endpoint
+//
+// Artifacts are only accepted while the worker of the build runs, so that the
+// artifacts of completed builds cannot be replaced, and with the upload token
+// of the build.
+func (api Artifacts) Put(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	id := request.PathParameter("id")
+	build, err := api.store.GetBuild(ctx, id)
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "Build could not be found.")
+		return
+	}
+	p, err := artifacts.CleanPath(request.PathParameter("path"))
+	if err != nil {
+		response.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, ok := acceptsUploads(api.artifacts, build, p, request, response)
+	if !ok {
+		return
+	}
+	if strings.HasPrefix(p, testresults.ArtifactDir+"/") {
+		response.WriteErrorString(http.StatusBadRequest, testresults.ArtifactDir+" is reserved for test results")
+		return
+	}
+
+	contentType := request.HeaderParameter("Content-Type")
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(request.PathParameter("path")))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	body := &limitedReader{r: request.Request.Body, n: limit}
+	a, err := api.artifacts.Put(id, request.PathParameter("path"), contentType, body)
+	switch err {
+	case nil:
+		response.WriteHeaderAndEntity(http.StatusCreated, a)
+	case artifacts.ErrInvalidPath:
+		response.WriteErrorString(http.StatusBadRequest, err.Error())
+	case errArtifactTooLarge:
+		response.WriteErrorString(http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		response.WriteErrorString(http.StatusInternalServerError, "Artifact could not be stored.")
+	}
+}
+
+// acceptsUploads responds with an error and returns false if the build does not
+// accept the upload of the request to the artifact at path p. Otherwise it
+// returns the maximum size of the upload, which replaces the artifact.
+func acceptsUploads(s artifacts.Store, build *brigade.Build, p string, request *restful.Request, response *restful.Response) (int64, bool) {
+	token := strings.TrimPrefix(request.HeaderParameter("Authorization"), "Bearer ")
+	if build.UploadToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(build.UploadToken)) != 1 {
+		response.WriteErrorString(http.StatusUnauthorized, "A valid upload token of the build is required.")
+		return 0, false
+	}
+	if build.Worker == nil || (build.Worker.Status != brigade.JobPending && build.Worker.Status != brigade.JobRunning) {
+		response.WriteErrorString(http.StatusConflict, "Build is not running.")
+		return 0, false
+	}
+	list, err := s.List(build.ID)
+	if err != nil {
+		response.WriteErrorString(http.StatusInternalServerError, "Build Artifacts could not be read.")
+		return 0, false
+	}
+	limit := MaxBuildArtifactsSize
+	for _, a := range list {
+		if a.Path != p {
+			limit -= a.Size
+		}
+	}
+	if limit > MaxArtifactSize {
+		limit = MaxArtifactSize
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if request.Request.ContentLength > limit {
+		response.WriteErrorString(http.StatusRequestEntityTooLarge, errArtifactTooLarge.Error())
+		return 0, false
+	}
+	return limit, true
+}
+
+// limitedReader reads at most n bytes, and fails with errArtifactTooLarge if
+// there is more to read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errArtifactTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errArtifactTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	restful "github.com/emicklei/go-restful"
+
+	"github.com/brigadecore/brigade/pkg/artifacts"
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/mock"
+)
+
+func TestArtifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := mock.New()
+	running := *mock.StubBuild1
+	running.Worker = &brigade.Worker{Status: brigade.JobRunning}
+	running.UploadToken = "s3cr3t"
+	store.Builds = []*brigade.Build{&running}
+	a := New(store).Artifacts(artifacts.NewFileStore(dir))
+
+	ws := new(restful.WebService)
+	ws.Path("/v1/build").Produces(restful.MIME_JSON)
+	ws.Route(ws.GET("/{id}/artifacts").To(a.List))
+	ws.Route(ws.GET("/{id}/artifacts/{path:*}").To(a.Get).Produces("*/*"))
+	ws.Route(ws.PUT("/{id}/artifacts/{path:*}").To(a.Put).Consumes("*/*"))
+	container := restful.NewContainer()
+	container.Add(ws)
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, url, strings.NewReader(body))
+		r.Header.Set("Accept", "*/*")
+		r.Header.Set("Authorization", "Bearer s3cr3t")
+		container.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do("PUT", "/v1/build/build-id1/artifacts/reports/report.html", "<html></html>"); w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body)
+	}
+	MaxArtifactSize = 4
+	if w := do("PUT", "/v1/build/build-id1/artifacts/app.tar.gz", "too large"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", w.Code)
+	}
+	MaxArtifactSize = 100 << 20
+	// The artifact being replaced does not count in the size of the build.
+	MaxBuildArtifactsSize = 16
+	if w := do("PUT", "/v1/build/build-id1/artifacts/reports/report.html", "<html></html>"); w.Code != http.StatusCreated {
+		t.Errorf("expected status 201, got %d: %s", w.Code, w.Body)
+	}
+	if w := do("PUT", "/v1/build/build-id1/artifacts/app.tar.gz", "1234"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413 over the size of the build, got %d", w.Code)
+	}
+	MaxBuildArtifactsSize = 1 << 30
+
+	unauthorized := httptest.NewRequest("PUT", "/v1/build/build-id1/artifacts/app.tar.gz", strings.NewReader("app"))
+	unauthorized.Header.Set("Authorization", "Bearer guessed")
+	w := httptest.NewRecorder()
+	container.ServeHTTP(w, unauthorized)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for a wrong token, got %d", w.Code)
+	}
+	unauthorized.Header.Del("Authorization")
+	w = httptest.NewRecorder()
+	container.ServeHTTP(w, unauthorized)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without a token, got %d", w.Code)
+	}
+
+	if w := do("PUT", "/v1/build/build-id1/artifacts/%5C", "app"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid path, got %d", w.Code)
+	}
+
+	w = do("GET", "/v1/build/build-id1/artifacts", "")
+	var list []*artifacts.Artifact
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("%s: %s", err, w.Body)
+	}
+	if len(list) != 1 || list[0].Path != "reports/report.html" || list[0].ContentType != "text/html; charset=utf-8" {
+		t.Errorf("unexpected artifacts %+v", list)
+	}
+
+	w = do("GET", "/v1/build/build-id1/artifacts/reports/report.html", "")
+	if w.Code != http.StatusOK || w.Body.String() != "<html></html>" {
+		t.Errorf("expected the artifact, got %d: %q", w.Code, w.Body)
+	}
+	if w := do("GET", "/v1/build/build-id1/artifacts/missing.html", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+
+	// Completed builds do not accept artifacts.
+	running.Worker.Status = brigade.JobSucceeded
+	if w := do("PUT", "/v1/build/build-id1/artifacts/reports/report.html", "replaced"); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", w.Code)
+	}
+}
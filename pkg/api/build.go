@@ -2,23 +2,26 @@ package api
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
 
 	restful "github.com/emicklei/go-restful"
 
+	"github.com/brigadecore/brigade/pkg/brigade"
 	"github.com/brigadecore/brigade/pkg/storage"
 )
 
 // Build represents the build api handlers.
 type Build struct {
-	store storage.Store
+	store storage.ContextStore
 }
 
 // Get creates a new gin handler for the GET /build/:id endpoint
 func (api Build) Get(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
 	id := request.PathParameter("id")
 	// For now, we always get the worker.
-	build, err := api.store.GetBuild(id)
+	build, err := api.store.GetBuild(ctx, id)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "Build could not be found.")
 		return
@@ -28,13 +31,14 @@ func (api Build) Get(request *restful.Request, response *restful.Response) {
 
 // Jobs creates a new gin handler for the GET /build/:id/jobs endpoint
 func (api Build) Jobs(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
 	id := request.PathParameter("id")
-	build, err := api.store.GetBuild(id)
+	build, err := api.store.GetBuild(ctx, id)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "Build could not be found.")
 		return
 	}
-	jobs, err := api.store.GetBuildJobs(build)
+	jobs, err := api.store.GetBuildJobs(ctx, build)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "Build Jobs could not be found.")
 		return
@@ -45,29 +49,61 @@ func (api Build) Jobs(request *restful.Request, response *restful.Response) {
 
 // Logs creates a new gin handler for the GET /build/:id/logs endpoint
 func (api Build) Logs(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
 	id := request.PathParameter("id")
-	build, err := api.store.GetBuild(id)
+	build, err := api.store.GetBuild(ctx, id)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "Build could not be found.")
 		return
 	}
+	opts, err := logOptions(request)
+	if err != nil {
+		response.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	if request.QueryParameter("all") == "true" {
+		api.allLogs(request, response, build, opts)
+		return
+	}
+	if build.Worker == nil {
+		response.WriteErrorString(http.StatusNotFound, "Build Logs could not be found.")
+		return
+	}
+	logReader, err := api.store.GetWorkerLogStreamWithOptions(ctx, build.Worker, opts)
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "Build Logs could not be found.")
+		return
+	}
+	if opts.Follow {
+		streamLog(request, response, logReader)
+		return
+	}
+	defer logReader.Close()
 	if request.QueryParameter("stream") == "true" {
-		logReader, err := api.store.GetWorkerLogStream(build.Worker)
-		if err != nil {
-			response.WriteErrorString(http.StatusNotFound, "Build Logs could not be found.")
-			return
-		}
-		defer logReader.Close()
 		io.Copy(response.ResponseWriter, logReader)
 	} else {
-		logs, err := api.store.GetWorkerLog(build.Worker)
+		logs, err := ioutil.ReadAll(logReader)
 		if err != nil {
-			response.WriteErrorString(http.StatusNotFound, "Build Logs could not be found.")
+			response.WriteErrorString(http.StatusInternalServerError, "Build Logs could not be read.")
 			return
 		}
 		if len(logs) == 0 {
 			response.WriteErrorString(http.StatusNoContent, "Build Logs Empty")
 		}
-		response.WriteEntity(logs)
+		response.WriteEntity(string(logs))
+	}
+}
+
+// allLogs streams the logs of the worker and of the jobs of a build as text,
+// every line prefixed with the name of the job that logged it.
+func (api Build) allLogs(request *restful.Request, response *restful.Response, build *brigade.Build, opts storage.LogOptions) {
+	logReader, err := storage.BuildLogs(request.Request.Context(), api.store, build, storage.BuildLogOptions{
+		LogOptions: opts,
+		Color:      request.QueryParameter("color") == "true",
+	})
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "Build Logs could not be found.")
+		return
 	}
+	streamLog(request, response, logReader)
 }
@@ -60,3 +60,41 @@ func TestBuildLogs(t *testing.T) {
 	}
 
 }
+
+func TestBuildLogs_Options(t *testing.T) {
+	store := mock.New()
+	store.LogData = "starting\nerror: no space left\nretrying\nerror: timeout\ndone"
+	mockAPI := New(store)
+
+	for _, tt := range []struct {
+		query  string
+		code   int
+		expect string
+	}{
+		{"?stream=true&grep=error", 200, "error: no space left\nerror: timeout\n"},
+		{"?stream=true&tail=2", 200, "error: timeout\ndone"},
+		{"?stream=true&tail=2&grep=error", 200, "error: timeout\n"},
+		{"?grep=^done", 200, `"done"`},
+		{"?tail=lots", 400, ""},
+		{"?sinceTime=yesterday", 400, ""},
+		{"?sinceSeconds=60&sinceTime=2020-06-01T15:04:05Z", 400, ""},
+		{"?grep=(", 400, ""},
+		{"?follow=true&tail=2", 200, "error: timeout\ndone"},
+		{"?all=true&grep=^error", 200, "[worker] error: no space left\n[worker] error: timeout\n[job-name] error: no space left\n[job-name] error: timeout\n"},
+		{"?all=true&tail=1&color=true", 200, "\x1b[36m[worker]\x1b[0m done\n\x1b[33m[job-name]\x1b[0m done\n"},
+	} {
+		httpWriter := httptest.NewRecorder()
+		respo := restful.NewResponse(httpWriter)
+		respo.SetRequestAccepts("application/json")
+		req := restful.NewRequest(httptest.NewRequest("GET", "/"+tt.query, nil))
+
+		mockAPI.Build().Logs(req, respo)
+		if httpWriter.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.query, tt.code, httpWriter.Code)
+			continue
+		}
+		if tt.code == 200 && httpWriter.Body.String() != tt.expect {
+			t.Errorf("%s: expected %q, got %q", tt.query, tt.expect, httpWriter.Body.String())
+		}
+	}
+}
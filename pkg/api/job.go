@@ -2,6 +2,7 @@ package api
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/brigadecore/brigade/pkg/storage"
@@ -11,13 +12,14 @@ import (
 
 // Job represents the job api handlers.
 type Job struct {
-	store storage.Store
+	store storage.ContextStore
 }
 
 // Get creates a new gin handler for the GET /job/:id endpoint
 func (api Job) Get(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
 	id := request.PathParameter("id")
-	job, err := api.store.GetJob(id)
+	job, err := api.store.GetJob(ctx, id)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "Job could not be found.")
 		return
@@ -27,29 +29,39 @@ func (api Job) Get(request *restful.Request, response *restful.Response) {
 
 // Logs creates a new gin handler for the GET /job/:id/logs endpoint
 func (api Job) Logs(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
 	id := request.PathParameter("id")
-	job, err := api.store.GetJob(id)
+	job, err := api.store.GetJob(ctx, id)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "Job could not be found.")
 		return
 	}
+	opts, err := logOptions(request)
+	if err != nil {
+		response.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	logReader, err := api.store.GetJobLogStreamWithOptions(ctx, job, opts)
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "Job Logs could not be found.")
+		return
+	}
+	if opts.Follow {
+		streamLog(request, response, logReader)
+		return
+	}
+	defer logReader.Close()
 	if request.QueryParameter("stream") == "true" {
-		logReader, err := api.store.GetJobLogStream(job)
-		if err != nil {
-			response.WriteErrorString(http.StatusNotFound, "Job could not be found.")
-			return
-		}
-		defer logReader.Close()
 		io.Copy(response.ResponseWriter, logReader)
 	} else {
-		logs, err := api.store.GetJobLog(job)
+		logs, err := ioutil.ReadAll(logReader)
 		if err != nil {
-			response.WriteErrorString(http.StatusNotFound, "Job Logs could not be found.")
+			response.WriteErrorString(http.StatusInternalServerError, "Job Logs could not be read.")
 			return
 		}
 		if len(logs) == 0 {
 			response.WriteErrorString(http.StatusNoContent, "Job Logs Empty")
 		}
-		response.WriteEntity(logs)
+		response.WriteEntity(string(logs))
 	}
 }
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	restful "github.com/emicklei/go-restful"
+
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// LogHeartbeat is how long a followed log may stay silent before an empty
+// line is sent to keep the connection open through proxies. Zero disables the
+// heartbeats.
+var LogHeartbeat = 30 * time.Second
+
+// logOptions reads the log options from the query parameters of a request:
+// tail, sinceTime (RFC3339), sinceSeconds, timestamps, follow, container and
+// grep.
+func logOptions(request *restful.Request) (storage.LogOptions, error) {
+	opts := storage.LogOptions{
+		Container:  request.QueryParameter("container"),
+		Timestamps: request.QueryParameter("timestamps") == "true",
+		Follow:     request.QueryParameter("follow") == "true",
+		Grep:       request.QueryParameter("grep"),
+	}
+	var err error
+	if v := request.QueryParameter("tail"); v != "" {
+		if opts.TailLines, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return opts, fmt.Errorf("invalid tail %q", v)
+		}
+	}
+	if v := request.QueryParameter("sinceSeconds"); v != "" {
+		if opts.SinceSeconds, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return opts, fmt.Errorf("invalid sinceSeconds %q", v)
+		}
+	}
+	if v := request.QueryParameter("sinceTime"); v != "" {
+		if opts.SinceTime, err = time.Parse(time.RFC3339, v); err != nil {
+			return opts, fmt.Errorf("invalid sinceTime %q, expected an RFC3339 time", v)
+		}
+	}
+	return opts, opts.Validate()
+}
+
+// LogParameters documents the query parameters read by logOptions.
+func LogParameters(ws *restful.WebService) []*restful.Parameter {
+	return []*restful.Parameter{
+		ws.QueryParameter("stream", "stream the log as text instead of returning a JSON string").DataType("boolean"),
+		ws.QueryParameter("tail", "number of lines to return from the end of the log").DataType("integer"),
+		ws.QueryParameter("sinceTime", "only return the lines logged after this RFC3339 time").DataType("string"),
+		ws.QueryParameter("sinceSeconds", "only return the lines logged in the last seconds").DataType("integer"),
+		ws.QueryParameter("timestamps", "prefix every line with the time it was logged at").DataType("boolean"),
+		ws.QueryParameter("follow", "stream the log as text, following it until the pod or the build completes").DataType("boolean"),
+		ws.QueryParameter("container", "container to return the log of").DataType("string"),
+		ws.QueryParameter("grep", "only return the lines matching this regular expression").DataType("string"),
+	}
+}
+
+// BuildLogParameters documents the query parameters of the logs of a build,
+// which can combine the logs of the worker and of its jobs.
+func BuildLogParameters(ws *restful.WebService) []*restful.Parameter {
+	return append(LogParameters(ws),
+		ws.QueryParameter("all", "stream the logs of the worker and of the jobs as text, every line prefixed with its job").DataType("boolean"),
+		ws.QueryParameter("color", "with all, color the prefixes of the lines").DataType("boolean"),
+	)
+}
+
+// streamLog streams a log as text, flushing every line, until the log ends or
+// the client goes away. Empty lines are sent as heartbeats while the log is
+// silent. It closes the log.
+func streamLog(request *restful.Request, response *restful.Response, r io.ReadCloser) {
+	defer r.Close()
+	w := response.ResponseWriter
+	flush := func() {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	flush()
+
+	lines := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// Lines are read whole, so heartbeats never split one.
+	var heartbeat <-chan time.Time
+	if LogHeartbeat > 0 {
+		ticker := time.NewTicker(LogHeartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	silent := true
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(line); err != nil {
+				return
+			}
+			flush()
+			silent = false
+		case <-heartbeat:
+			if silent {
+				if _, err := w.Write([]byte{'\n'}); err != nil {
+					return
+				}
+				flush()
+			}
+			silent = true
+		case <-request.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	restful "github.com/emicklei/go-restful"
+)
+
+func TestStreamLog(t *testing.T) {
+	defer func(d time.Duration) { LogHeartbeat = d }(LogHeartbeat)
+	LogHeartbeat = 10 * time.Millisecond
+
+	pr, pw := io.Pipe()
+	rw := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		streamLog(restful.NewRequest(httptest.NewRequest("GET", "/?follow=true", nil)), restful.NewResponse(rw), pr)
+		close(done)
+	}()
+
+	pw.Write([]byte("starting\n"))
+	// Stay silent long enough for a heartbeat.
+	time.Sleep(50 * time.Millisecond)
+	pw.Write([]byte("done"))
+	pw.Close()
+	<-done
+
+	body := rw.Body.String()
+	lines := strings.Split(body, "\n")
+	if len(lines) < 3 || lines[0] != "starting" || lines[len(lines)-1] != "done" {
+		t.Fatalf("expected the lines with heartbeats between them, got %q", body)
+	}
+	for _, heartbeat := range lines[1 : len(lines)-1] {
+		if heartbeat != "" {
+			t.Errorf("expected empty lines as heartbeats, got %q", body)
+		}
+	}
+	if !rw.Flushed {
+		t.Error("expected the log to be flushed")
+	}
+	if ct := rw.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("expected a text log, got %q", ct)
+	}
+}
+
+func TestStreamLog_ClientGone(t *testing.T) {
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/?follow=true", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		streamLog(restful.NewRequest(req), restful.NewResponse(httptest.NewRecorder()), pr)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to end when the client goes away")
+	}
+	if _, err := pw.Write([]byte("late\n")); err != io.ErrClosedPipe {
+		t.Errorf("expected the log to be closed, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"sort"
 
@@ -10,14 +11,19 @@ import (
 	"github.com/brigadecore/brigade/pkg/storage"
 )
 
+// ServiceAccountRegex is the regex project service accounts must match, as
+// configured on the controller. Any service account is accepted if empty.
+var ServiceAccountRegex string
+
 // Project represents the project api handlers.
 type Project struct {
-	store storage.Store
+	store storage.ContextStore
 }
 
 // List creates a new gin handler for the GET /projects endpoint
 func (api Project) List(request *restful.Request, response *restful.Response) {
-	projects, err := api.store.GetProjects()
+	ctx := request.Request.Context()
+	projects, err := api.store.GetProjects(ctx)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "No Projects found.")
 		return
@@ -33,21 +39,22 @@ type ProjectBuildSummary struct {
 
 // ListWithLatestBuild lists the projects with the latest builds attached.
 func (api Project) ListWithLatestBuild(request *restful.Request, response *restful.Response) {
-	projects, err := api.store.GetProjects()
+	ctx := request.Request.Context()
+	projects, err := api.store.GetProjects(ctx)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "No Projects found.")
 		return
 	}
-	res := api.getBuildSummariesForProjects(projects)
+	res := api.getBuildSummariesForProjects(ctx, projects)
 
 	response.WriteHeaderAndEntity(http.StatusOK, res)
 }
 
-func (api Project) getBuildSummariesForProjects(projects []*brigade.Project) []*ProjectBuildSummary {
+func (api Project) getBuildSummariesForProjects(ctx context.Context, projects []*brigade.Project) []*ProjectBuildSummary {
 	res := []*ProjectBuildSummary{}
 	for _, p := range projects {
 		pbs := &ProjectBuildSummary{Project: p}
-		builds, err := api.store.GetProjectBuilds(p)
+		builds, err := api.store.GetProjectBuilds(ctx, p)
 		if err == nil && len(builds) > 0 {
 			sort.Slice(builds, func(i, j int) bool {
 				if builds[i].Worker == nil || builds[j].Worker == nil {
@@ -64,8 +71,9 @@ func (api Project) getBuildSummariesForProjects(projects []*brigade.Project) []*
 
 // Get creates a new gin handler for the GET /project/:id endpoint
 func (api Project) Get(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
 	id := request.PathParameter("id")
-	proj, err := api.store.GetProject(id)
+	proj, err := api.store.GetProject(ctx, id)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "No Project found.")
 		return
@@ -75,16 +83,45 @@ func (api Project) Get(request *restful.Request, response *restful.Response) {
 
 // Builds creates a new gin handler for the GET /project/:id/builds endpoint
 func (api Project) Builds(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
 	id := request.PathParameter("id")
-	proj, err := api.store.GetProject(id)
+	proj, err := api.store.GetProject(ctx, id)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "No Project found.")
 		return
 	}
-	builds, err := api.store.GetProjectBuilds(proj)
+	builds, err := api.store.GetProjectBuilds(ctx, proj)
 	if err != nil {
 		response.WriteErrorString(http.StatusNotFound, "No Project Builds found.")
 		return
 	}
 	response.WriteHeaderAndEntity(http.StatusOK, builds)
 }
+
+// Validate creates a new gin handler for the GET /project/:id/validate endpoint
+//
+// It responds with the list of validation errors of the stored project, which
+// is empty when the project is valid. The service account is also checked
+// against ServiceAccountRegex.
+func (api Project) Validate(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	id := request.PathParameter("id")
+	proj, err := api.store.GetProject(ctx, id)
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "No Project found.")
+		return
+	}
+	errs := brigade.ValidationErrors{}
+	if err := proj.Validate(); err != nil {
+		errs = err.(brigade.ValidationErrors)
+	}
+	if err := proj.ValidateServiceAccount(ServiceAccountRegex); err != nil {
+		verrs, ok := err.(brigade.ValidationErrors)
+		if !ok {
+			response.WriteErrorString(http.StatusInternalServerError, err.Error())
+			return
+		}
+		errs = append(errs, verrs...)
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, errs)
+}
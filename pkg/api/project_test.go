@@ -1,8 +1,15 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	restful "github.com/emicklei/go-restful"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
 	"github.com/brigadecore/brigade/pkg/storage/mock"
 )
 
@@ -11,13 +18,63 @@ func TestGetBuildSummariesForProjects(t *testing.T) {
 		store: mock.New(),
 	}
 
-	projects, err := project.store.GetProjects()
+	projects, err := project.store.GetProjects(context.TODO())
 	if err != nil {
 		t.Fatalf("error: %s", err.Error())
 	}
-	projectSummaries := project.getBuildSummariesForProjects(projects)
+	projectSummaries := project.getBuildSummariesForProjects(context.TODO(), projects)
 
 	if projectSummaries[0].LastBuild.ID != "build-id1" {
 		t.Fatal("wrong BuildID in getBuildSummariesForProjects")
 	}
 }
+
+func TestProjectValidate(t *testing.T) {
+	store := mock.New()
+	mockAPI := New(store)
+
+	validate := func() []brigade.ValidationError {
+		httpRequest := httptest.NewRequest("GET", "/?a=b", nil)
+		req := restful.NewRequest(httpRequest)
+		req.PathParameters()["id"] = "project-id"
+		httpWriter := httptest.NewRecorder()
+		resp := restful.NewResponse(httpWriter)
+		resp.SetRequestAccepts(restful.MIME_JSON)
+
+		mockAPI.Project().Validate(req, resp)
+		if httpWriter.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, httpWriter.Code)
+		}
+		errs := []brigade.ValidationError{}
+		if err := json.Unmarshal(httpWriter.Body.Bytes(), &errs); err != nil {
+			t.Fatal(err)
+		}
+		return errs
+	}
+
+	if errs := validate(); len(errs) != 0 {
+		t.Errorf("Expected no validation errors, got %v", errs)
+	}
+
+	store.ProjectList = []*brigade.Project{{
+		ID:            "project-id",
+		Name:          "project-name",
+		BrigadejsPath: "/etc/brigade.js",
+	}}
+	errs := validate()
+	if len(errs) != 1 || errs[0].Field != "brigadejsPath" {
+		t.Errorf("Expected a brigadejsPath validation error, got %v", errs)
+	}
+
+	store.ProjectList = []*brigade.Project{{
+		ID:         "project-id",
+		Name:       "project-name",
+		Kubernetes: brigade.Kubernetes{ServiceAccount: "admin"},
+	}}
+	ServiceAccountRegex = "^brigade-.*$"
+	defer func() { ServiceAccountRegex = "" }()
+	errs = validate()
+	if len(errs) != 1 || errs[0].Field != "serviceAccount" {
+		t.Errorf("Expected a serviceAccount validation error, got %v", errs)
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	restful "github.com/emicklei/go-restful"
+
+	"github.com/brigadecore/brigade/pkg/artifacts"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/testresults"
+)
+
+// FlakyTestBuilds is the default number of recent builds of a project the
+// flaky tests are detected in.
+const FlakyTestBuilds = 20
+
+// Tests represents the test result api handlers.
+type Tests struct {
+	store     storage.ContextStore
+	artifacts artifacts.Store
+}
+
+// Tests returns a handler for the test results of builds, kept with their
+// artifacts in the given store.
+func (api API) Tests(s artifacts.Store) Tests {
+	return Tests{store: api.store, artifacts: s}
+}
+
+// Get creates a new gin handler for the GET /build/:id/tests endpoint
+func (api Tests) Get(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	id := request.PathParameter("id")
+	if _, err := api.store.GetBuild(ctx, id); err != nil {
+		response.WriteErrorString(http.StatusNotFound, "Build could not be found.")
+		return
+	}
+	results, err := api.results(id)
+	if err != nil {
+		response.WriteErrorString(http.StatusInternalServerError, "Build Tests could not be read.")
+		return
+	}
+	response.WriteEntity(testresults.NewReport(id, results))
+}
+
+// Put creates a new gin handler for the PUT /build/:id/tests/:job endpoint
+//
+// The report is parsed in the format of the format query parameter, or in the
+// detected format, and replaces the test results of the job.
+func (api Tests) Put(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	id := request.PathParameter("id")
+	build, err := api.store.GetBuild(ctx, id)
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "Build could not be found.")
+		return
+	}
+	job, err := artifacts.CleanPath(request.PathParameter("job"))
+	if err != nil || strings.Contains(job, "/") {
+		response.WriteErrorString(http.StatusBadRequest, "invalid job name")
+		return
+	}
+	artifact := path.Join(testresults.ArtifactDir, job+".json")
+	limit, ok := acceptsUploads(api.artifacts, build, artifact, request, response)
+	if !ok {
+		return
+	}
+
+	results, err := testresults.Parse(&limitedReader{r: request.Request.Body, n: limit}, request.QueryParameter("format"))
+	if err == errArtifactTooLarge {
+		response.WriteErrorString(http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if err != nil {
+		response.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	results.Job = job
+	data, err := json.Marshal(results)
+	if err != nil {
+		response.WriteErrorString(http.StatusInternalServerError, "Tests could not be stored.")
+		return
+	}
+	// The stored results are not the same size as the report.
+	if int64(len(data)) > limit {
+		response.WriteErrorString(http.StatusRequestEntityTooLarge, errArtifactTooLarge.Error())
+		return
+	}
+	if _, err := api.artifacts.Put(id, artifact, "application/json", bytes.NewReader(data)); err != nil {
+		response.WriteErrorString(http.StatusInternalServerError, "Tests could not be stored.")
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusCreated, testresults.NewReport(id, []*testresults.Results{results}).Jobs[0])
+}
+
+// Flaky creates a new gin handler for the GET /project/:id/tests/flaky endpoint
+//
+// The flaky tests are detected in the last builds of the project, as many as
+// the builds query parameter, FlakyTestBuilds by default.
+func (api Tests) Flaky(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	n := FlakyTestBuilds
+	if b := request.QueryParameter("builds"); b != "" {
+		var err error
+		if n, err = strconv.Atoi(b); err != nil || n <= 0 {
+			response.WriteErrorString(http.StatusBadRequest, "builds must be a positive number")
+			return
+		}
+	}
+	proj, err := api.store.GetProject(ctx, request.PathParameter("id"))
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "No Project found.")
+		return
+	}
+	builds, err := api.store.GetProjectBuilds(ctx, proj)
+	if err != nil {
+		response.WriteErrorString(http.StatusNotFound, "No Project Builds found.")
+		return
+	}
+	// Build IDs sort in the order of creation.
+	sort.Slice(builds, func(i, j int) bool { return builds[i].ID < builds[j].ID })
+	if len(builds) > n {
+		builds = builds[len(builds)-n:]
+	}
+	history := []testresults.BuildResults{}
+	for _, b := range builds {
+		results, err := api.results(b.ID)
+		if err != nil {
+			response.WriteErrorString(http.StatusInternalServerError, "Build Tests could not be read.")
+			return
+		}
+		commit := ""
+		if b.Revision != nil {
+			commit = b.Revision.Commit
+		}
+		history = append(history, testresults.BuildResults{BuildID: b.ID, Commit: commit, Results: results})
+	}
+	response.WriteEntity(testresults.FindFlaky(history))
+}
+
+// results reads the test results of the jobs of a build.
+func (api Tests) results(buildID string) ([]*testresults.Results, error) {
+	list, err := api.artifacts.List(buildID)
+	if err != nil {
+		return nil, err
+	}
+	results := []*testresults.Results{}
+	for _, a := range list {
+		if !strings.HasPrefix(a.Path, testresults.ArtifactDir+"/") {
+			continue
+		}
+		_, content, err := api.artifacts.Get(buildID, a.Path)
+		if err != nil {
+			return nil, err
+		}
+		r := &testresults.Results{}
+		err = json.NewDecoder(content).Decode(r)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
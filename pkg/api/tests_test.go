@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	restful "github.com/emicklei/go-restful"
+
+	"github.com/brigadecore/brigade/pkg/artifacts"
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/mock"
+	"github.com/brigadecore/brigade/pkg/testresults"
+)
+
+func TestTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := mock.New()
+	running := *mock.StubBuild1
+	running.Worker = &brigade.Worker{Status: brigade.JobRunning}
+	running.UploadToken = "s3cr3t"
+	store.Builds = []*brigade.Build{&running}
+	artifactStore := artifacts.NewFileStore(dir)
+	tests := New(store).Tests(artifactStore)
+	a := New(store).Artifacts(artifactStore)
+
+	ws := new(restful.WebService)
+	ws.Path("/v1").Produces(restful.MIME_JSON)
+	ws.Route(ws.GET("/build/{id}/tests").To(tests.Get))
+	ws.Route(ws.PUT("/build/{id}/tests/{job}").To(tests.Put).Consumes("*/*"))
+	ws.Route(ws.PUT("/build/{id}/artifacts/{path:*}").To(a.Put).Consumes("*/*"))
+	ws.Route(ws.GET("/project/{id}/tests/flaky").To(tests.Flaky))
+	container := restful.NewContainer()
+	container.Add(ws)
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, url, strings.NewReader(body))
+		r.Header.Set("Accept", "*/*")
+		r.Header.Set("Authorization", "Bearer s3cr3t")
+		container.ServeHTTP(w, r)
+		return w
+	}
+
+	junit := `<testsuite name="api"><testcase name="get"/><testcase name="logs"><failure message="404"/></testcase></testsuite>`
+	if w := do("PUT", "/v1/build/build-id1/tests/unit", junit); w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body)
+	}
+	if w := do("PUT", "/v1/build/build-id1/tests/e2e?format=test2json", junit); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a report in the wrong format, got %d", w.Code)
+	}
+	if w := do("PUT", "/v1/build/build-id1/artifacts/.tests/unit.json", "{}"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an artifact in the test results, got %d", w.Code)
+	}
+
+	w := do("GET", "/v1/build/build-id1/tests", "")
+	report := &testresults.Report{}
+	if err := json.Unmarshal(w.Body.Bytes(), report); err != nil {
+		t.Fatalf("%s: %s", err, w.Body)
+	}
+	if report.Counts != (testresults.Counts{Total: 2, Passed: 1, Failed: 1}) {
+		t.Errorf("unexpected counts %+v", report.Counts)
+	}
+	if len(report.Jobs) != 1 || report.Jobs[0].Job != "unit" || len(report.Jobs[0].Failures) != 1 || report.Jobs[0].Failures[0].Message != "404" {
+		t.Errorf("unexpected jobs %+v", report.Jobs)
+	}
+
+	// The mock store returns the same build for all the builds of the project.
+	w = do("GET", "/v1/project/project-id/tests/flaky", "")
+	var flaky []testresults.FlakyTest
+	if err := json.Unmarshal(w.Body.Bytes(), &flaky); err != nil {
+		t.Fatalf("%s: %s", err, w.Body)
+	}
+	if len(flaky) != 0 {
+		t.Errorf("expected no flaky tests, got %+v", flaky)
+	}
+	if w := do("GET", "/v1/project/project-id/tests/flaky?builds=none", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
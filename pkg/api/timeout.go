@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	restful "github.com/emicklei/go-restful"
+)
+
+// Timeout returns a filter that cancels the context of a request after d.
+// Streamed and followed logs are not limited, they end when the client goes
+// away. A zero duration disables the timeout.
+func Timeout(d time.Duration) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if d <= 0 || req.QueryParameter("stream") == "true" || req.QueryParameter("follow") == "true" {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+		ctx, cancel := context.WithTimeout(req.Request.Context(), d)
+		defer cancel()
+		req.Request = req.Request.WithContext(ctx)
+		chain.ProcessFilter(req, resp)
+	}
+}
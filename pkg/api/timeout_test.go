@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	restful "github.com/emicklei/go-restful"
+)
+
+func TestTimeout(t *testing.T) {
+	var hasDeadline bool
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/logs").To(func(req *restful.Request, resp *restful.Response) {
+		_, hasDeadline = req.Request.Context().Deadline()
+	}))
+	container := restful.NewContainer()
+	container.Add(ws)
+	container.Filter(Timeout(time.Minute))
+
+	for _, tt := range []struct {
+		url      string
+		deadline bool
+	}{
+		{"/logs", true},
+		{"/logs?stream=true", false},
+		{"/logs?all=true&follow=true", false},
+		{"/logs?all=true", true},
+	} {
+		container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.url, nil))
+		if hasDeadline != tt.deadline {
+			t.Errorf("%s: expected deadline %t, got %t", tt.url, tt.deadline, hasDeadline)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package archive keeps the logs and metadata of builds after their pods and
+// secrets were deleted.
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// ErrNotFound is returned by a Sink when the requested key was not archived.
+var ErrNotFound = errors.New("not found in archive")
+
+// Sink stores archived objects under a slash-separated key.
+type Sink interface {
+	// Put stores the data under the given key, replacing any previous data.
+	Put(key string, data []byte) error
+	// Get returns the data stored under the given key, or ErrNotFound.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the data stored under the given key, if any.
+	Delete(key string) error
+}
+
+// NewSink returns the sink described by the given URL.
+//
+// Supported URLs are:
+//
+//	file:///var/lib/brigade/archive
+//	s3://bucket/prefix?endpoint=minio:9000&region=us-east-1&insecure=true
+//
+// The credentials of the S3 sink are read from the AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY environment variables.
+func NewSink(rawurl string) (Sink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive URL %q: %s", rawurl, err)
+	}
+	switch u.Scheme {
+	case "file", "":
+		return NewFileSink(u.Path), nil
+	case "s3":
+		q := u.Query()
+		endpoint := q.Get("endpoint")
+		if endpoint == "" {
+			endpoint = "s3.amazonaws.com"
+		}
+		insecure, _ := strconv.ParseBool(q.Get("insecure"))
+		return NewS3Sink(S3Config{
+			Endpoint:        endpoint,
+			Region:          q.Get("region"),
+			Bucket:          u.Host,
+			Prefix:          u.Path,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			Insecure:        insecure,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported archive URL scheme %q", u.Scheme)
+	}
+}
+
+// These are the keys under which a build is archived.
+func buildKey(buildID string) string         { return path.Join("builds", buildID, "build.json") }
+func buildJobsKey(buildID string) string     { return path.Join("builds", buildID, "jobs.json") }
+func workerLogKey(buildID string) string     { return path.Join("builds", buildID, "worker.log") }
+func workerInitLogKey(buildID string) string { return path.Join("builds", buildID, "worker-init.log") }
+func jobKey(jobID string) string             { return path.Join("jobs", jobID+".json") }
+func jobLogKey(jobID string) string          { return path.Join("jobs", jobID+".log") }
+
+// Archiver copies builds from a store to a sink.
+type Archiver struct {
+	Store storage.ContextStore
+	Sink  Sink
+}
+
+// ArchiveBuild writes the metadata of the build and its jobs, and the logs of
+// its worker and jobs to the sink.
+//
+// Logs that cannot be read, for example because the worker never started, are
+// skipped.
+func (a *Archiver) ArchiveBuild(ctx context.Context, id string) error {
+	build, err := a.Store.GetBuild(ctx, id)
+	if err != nil {
+		return err
+	}
+	if build == nil {
+		return fmt.Errorf("build %s not found", id)
+	}
+	jobs, err := a.Store.GetBuildJobs(ctx, build)
+	if err != nil {
+		return err
+	}
+
+	if build.Worker != nil {
+		if l, err := a.Store.GetWorkerLog(ctx, build.Worker); err == nil {
+			if err := a.Sink.Put(workerLogKey(id), []byte(l)); err != nil {
+				return err
+			}
+		} else {
+			logging.With(logging.BuildIDKey, id).Warnf("not archiving the worker log of build %s: %s", id, err)
+		}
+		if l, err := a.Store.GetWorkerInitLog(ctx, build.Worker); err == nil {
+			if err := a.Sink.Put(workerInitLogKey(id), []byte(l)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, job := range jobs {
+		if l, err := a.Store.GetJobLog(ctx, job); err == nil {
+			if err := a.Sink.Put(jobLogKey(job.ID), []byte(l)); err != nil {
+				return err
+			}
+		} else {
+			logging.With(logging.BuildIDKey, id).Warnf("not archiving the log of job %s: %s", job.ID, err)
+		}
+		if err := a.putJSON(jobKey(job.ID), job); err != nil {
+			return err
+		}
+	}
+	if err := a.putJSON(buildJobsKey(id), jobs); err != nil {
+		return err
+	}
+	// The build is written last, so that a build found in the archive is
+	// archived completely.
+	return a.putJSON(buildKey(id), build)
+}
+
+// DeleteBuild removes an archived build and its jobs from the sink.
+func (a *Archiver) DeleteBuild(ctx context.Context, id string) error {
+	var jobs []*brigade.Job
+	if err := getJSON(a.Sink, buildJobsKey(id), &jobs); err != nil && err != ErrNotFound {
+		return err
+	}
+	keys := []string{buildKey(id), workerLogKey(id), workerInitLogKey(id)}
+	for _, job := range jobs {
+		keys = append(keys, jobKey(job.ID), jobLogKey(job.ID))
+	}
+	// The job list is deleted last, so that a failed deletion can be resumed.
+	keys = append(keys, buildJobsKey(id))
+	for _, key := range keys {
+		if err := a.Sink.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Archiver) putJSON(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return a.Sink.Put(key, data)
+}
+
+func getJSON(sink Sink, key string, v interface{}) error {
+	r, err := sink.Get(key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return json.NewDecoder(r).Decode(v)
+}
+
+func getString(sink Sink, key string) (string, error) {
+	r, err := sink.Get(key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	return string(data), err
+}
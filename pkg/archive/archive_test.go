@@ -0,0 +1,180 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/storage/mock"
+)
+
+// goneStore behaves like a store whose builds were deleted.
+type goneStore struct {
+	*mock.Store
+}
+
+var errGone = errors.New("gone")
+
+func (s goneStore) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
+	return nil, errGone
+}
+func (s goneStore) GetWorker(ctx context.Context, id string) (*brigade.Worker, error) {
+	return nil, errGone
+}
+func (s goneStore) GetJob(ctx context.Context, id string) (*brigade.Job, error)   { return nil, errGone }
+func (s goneStore) GetJobLog(ctx context.Context, j *brigade.Job) (string, error) { return "", errGone }
+func (s goneStore) GetWorkerLog(ctx context.Context, w *brigade.Worker) (string, error) {
+	return "", errGone
+}
+func (s goneStore) GetWorkerInitLog(ctx context.Context, w *brigade.Worker) (string, error) {
+	return "", errGone
+}
+func (s goneStore) GetBuildJobs(ctx context.Context, b *brigade.Build) ([]*brigade.Job, error) {
+	return []*brigade.Job{}, nil
+}
+func (s goneStore) GetWorkerLogStream(ctx context.Context, w *brigade.Worker) (io.ReadCloser, error) {
+	return nil, errGone
+}
+func (s goneStore) GetWorkerLogStreamWithOptions(ctx context.Context, w *brigade.Worker, opts storage.LogOptions) (io.ReadCloser, error) {
+	return nil, errGone
+}
+func (s goneStore) GetJobLogStreamWithOptions(ctx context.Context, j *brigade.Job, opts storage.LogOptions) (io.ReadCloser, error) {
+	return nil, errGone
+}
+
+func TestArchiver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sink := NewFileSink(dir)
+
+	archiver := &Archiver{Store: mock.New(), Sink: sink}
+	if err := archiver.ArchiveBuild(context.TODO(), mock.StubBuild1.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewStore(goneStore{mock.New()}, sink)
+
+	build, err := store.GetBuild(context.TODO(), mock.StubBuild1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.ID != mock.StubBuild1.ID || build.Worker == nil || build.Worker.ID != mock.StubWorker1.ID {
+		t.Errorf("unexpected archived build %#v", build)
+	}
+	worker, err := store.GetWorker(context.TODO(), mock.StubBuild1.ID)
+	if err != nil || worker.ID != mock.StubWorker1.ID {
+		t.Errorf("unexpected archived worker %#v: %v", worker, err)
+	}
+	if l, err := store.GetWorkerLog(context.TODO(), worker); err != nil || l != mock.StubLogData {
+		t.Errorf("unexpected archived worker log %q: %v", l, err)
+	}
+	if l, err := store.GetWorkerInitLog(context.TODO(), worker); err != nil || l != mock.StubLogData {
+		t.Errorf("unexpected archived worker init log %q: %v", l, err)
+	}
+	r, err := store.GetWorkerLogStream(context.TODO(), worker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(r)
+	r.Close()
+	if string(data) != mock.StubLogData {
+		t.Errorf("unexpected archived worker log stream %q", data)
+	}
+
+	r, err = store.GetWorkerLogStreamWithOptions(context.TODO(), worker, storage.LogOptions{TailLines: 1, Grep: "World"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = ioutil.ReadAll(r)
+	r.Close()
+	if string(data) != mock.StubLogData {
+		t.Errorf("unexpected archived worker log with options %q", data)
+	}
+	if _, err := store.GetWorkerLogStreamWithOptions(context.TODO(), worker, storage.LogOptions{SinceSeconds: 60}); err != errGone {
+		t.Errorf("expected archived logs not to be read by time, got %v", err)
+	}
+
+	jobs, err := store.GetBuildJobs(context.TODO(), build)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != mock.StubJob.ID {
+		t.Fatalf("unexpected archived jobs %v: %v", jobs, err)
+	}
+	job, err := store.GetJob(context.TODO(), mock.StubJob.ID)
+	if err != nil || job.Name != mock.StubJob.Name {
+		t.Errorf("unexpected archived job %#v: %v", job, err)
+	}
+	if l, err := store.GetJobLog(context.TODO(), job); err != nil || l != mock.StubLogData {
+		t.Errorf("unexpected archived job log %q: %v", l, err)
+	}
+
+	if _, err := store.GetBuild(context.TODO(), "missing"); err != errGone {
+		t.Errorf("expected the error of the store for a build that was not archived, got %v", err)
+	}
+
+	if err := archiver.DeleteBuild(context.TODO(), mock.StubBuild1.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetBuild(context.TODO(), mock.StubBuild1.ID); err != errGone {
+		t.Errorf("expected the archived build to be deleted, got %v", err)
+	}
+	if _, err := store.GetJobLog(context.TODO(), job); err != errGone {
+		t.Errorf("expected the archived job log to be deleted, got %v", err)
+	}
+}
+
+// nilStore returns neither a build nor an error.
+type nilStore struct {
+	*mock.Store
+}
+
+func (s nilStore) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
+	return nil, nil
+}
+
+func TestArchiveBuild_Missing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archiver := &Archiver{Store: goneStore{mock.New()}, Sink: NewFileSink(dir)}
+	if err := archiver.ArchiveBuild(context.TODO(), mock.StubBuild1.ID); err != errGone {
+		t.Errorf("expected the error of the store, got %v", err)
+	}
+	archiver.Store = nilStore{mock.New()}
+	if err := archiver.ArchiveBuild(context.TODO(), mock.StubBuild1.ID); err == nil {
+		t.Error("expected an error for a build the store did not return")
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		valid bool
+	}{
+		{"file", "file:///var/lib/brigade/archive", true},
+		{"path", "/var/lib/brigade/archive", true},
+		{"s3", "s3://brigade/archive?endpoint=localhost:9000&insecure=true", true},
+		{"unsupported", "ftp://example.com/archive", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSink(tt.url)
+			if tt.valid && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
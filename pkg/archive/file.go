@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileSink archives to a directory of the local filesystem.
+type FileSink struct {
+	dir string
+}
+
+// NewFileSink returns a sink writing below the given directory.
+func NewFileSink(dir string) *FileSink {
+	return &FileSink{dir: dir}
+}
+
+// Put writes the data to a file, creating its directory if needed.
+func (s *FileSink) Put(key string, data []byte) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that readers never see partial data.
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Get opens the file of the key.
+func (s *FileSink) Get(key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file of the key.
+func (s *FileSink) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileSink) path(key string) string {
+	// Clean the key as an absolute path so that it cannot escape the directory.
+	return filepath.Join(s.dir, filepath.FromSlash(filepath.Clean("/"+key)))
+}
@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testSink(t, NewFileSink(filepath.Join(dir, "archive")))
+
+	sink := NewFileSink(filepath.Join(dir, "archive"))
+	if err := sink.Put("../../escaped.log", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "archive", "escaped.log")); err != nil {
+		t.Errorf("expected the key to be kept inside the archive: %s", err)
+	}
+}
+
+// testSink checks the behavior all sinks share.
+func testSink(t *testing.T, sink Sink) {
+	if _, err := sink.Get("builds/missing/build.json"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := sink.Put("builds/b1/worker.log", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Put("builds/b1/worker.log", []byte("hello world")); err != nil {
+		t.Fatal(err)
+	}
+	s, err := getString(sink, "builds/b1/worker.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "hello world" {
+		t.Errorf("expected %q, got %q", "hello world", s)
+	}
+	if err := sink.Delete("builds/b1/worker.log"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Delete("builds/b1/worker.log"); err != nil {
+		t.Errorf("expected deleting a missing key to succeed, got %s", err)
+	}
+	if _, err := sink.Get("builds/b1/worker.log"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
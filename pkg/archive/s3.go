@@ -0,0 +1,92 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go"
+)
+
+// S3Config configures an S3Sink.
+type S3Config struct {
+	// Endpoint is the host[:port] of the S3-compatible service.
+	Endpoint string
+	// Region of the bucket. Setting it saves a bucket location lookup.
+	Region string
+	// Bucket the archive is written to.
+	Bucket string
+	// Prefix is prepended to all keys.
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Insecure disables TLS.
+	Insecure bool
+}
+
+// S3Sink archives to a bucket of Amazon S3 or of an S3-compatible service
+// such as MinIO.
+type S3Sink struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Sink returns a sink writing to the configured bucket.
+func NewS3Sink(config S3Config) (*S3Sink, error) {
+	client, err := minio.NewWithRegion(config.Endpoint, config.AccessKeyID, config.SecretAccessKey, !config.Insecure, config.Region)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Sink{
+		client: client,
+		bucket: config.Bucket,
+		prefix: strings.Trim(config.Prefix, "/"),
+	}, nil
+}
+
+// Put uploads the data as an object.
+func (s *S3Sink) Put(key string, data []byte) error {
+	_, err := s.client.PutObject(s.bucket, s.key(key), bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType(key),
+	})
+	return err
+}
+
+// Get downloads an object.
+func (s *S3Sink) Get(key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(s.bucket, s.key(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	// GetObject is lazy, Stat makes sure that the object exists.
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, s3Error(err)
+	}
+	return obj, nil
+}
+
+// Delete removes an object.
+func (s *S3Sink) Delete(key string) error {
+	return s3Error(s.client.RemoveObject(s.bucket, s.key(key)))
+}
+
+func (s *S3Sink) key(key string) string {
+	return path.Join(s.prefix, key)
+}
+
+func s3Error(err error) error {
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
+
+func contentType(key string) string {
+	if strings.HasSuffix(key, ".json") {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
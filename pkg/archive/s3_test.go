@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a minimal stand-in for an S3-compatible service using path-style
+// requests.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+			data = decodeChunks(data)
+		}
+		f.objects[key] = data
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodHead, http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			}
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeChunks returns the payload of a body signed with aws-chunked streaming
+// signatures, made of "<hex size>;chunk-signature=<sig>\r\n<data>\r\n" chunks.
+func decodeChunks(body []byte) []byte {
+	var data []byte
+	for len(body) > 0 {
+		i := bytes.Index(body, []byte("\r\n"))
+		if i < 0 {
+			break
+		}
+		size, err := strconv.ParseInt(strings.SplitN(string(body[:i]), ";", 2)[0], 16, 64)
+		if err != nil || size == 0 {
+			break
+		}
+		body = body[i+2:]
+		data = append(data, body[:size]...)
+		body = body[size+2:]
+	}
+	return data
+}
+
+func TestS3Sink(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	sink, err := NewS3Sink(S3Config{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		Region:          "us-east-1",
+		Bucket:          "brigade",
+		Prefix:          "/archive/",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		Insecure:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSink(t, sink)
+
+	if err := sink.Put("jobs/j1.json", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["/brigade/archive/jobs/j1.json"]; !ok {
+		t.Errorf("expected the object to be written below the prefix, got %v", fake.objects)
+	}
+}
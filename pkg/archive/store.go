@@ -0,0 +1,178 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// store reads builds, jobs and logs from the archive once they are gone from
+// the underlying store.
+type store struct {
+	storage.ContextStore
+	sink Sink
+}
+
+// NewStore wraps a store so that archived builds remain readable after they
+// were deleted.
+func NewStore(s storage.ContextStore, sink Sink) storage.ContextStore {
+	return &store{ContextStore: s, sink: sink}
+}
+
+func (s *store) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
+	build, err := s.ContextStore.GetBuild(ctx, id)
+	if err == nil {
+		return build, nil
+	}
+	archived := &brigade.Build{}
+	if getJSON(s.sink, buildKey(id), archived) != nil {
+		return build, err
+	}
+	return archived, nil
+}
+
+func (s *store) GetWorker(ctx context.Context, buildID string) (*brigade.Worker, error) {
+	worker, err := s.ContextStore.GetWorker(ctx, buildID)
+	if err == nil {
+		return worker, nil
+	}
+	archived := &brigade.Build{}
+	if getJSON(s.sink, buildKey(buildID), archived) != nil || archived.Worker == nil {
+		return worker, err
+	}
+	return archived.Worker, nil
+}
+
+func (s *store) GetBuildJobs(ctx context.Context, build *brigade.Build) ([]*brigade.Job, error) {
+	jobs, err := s.ContextStore.GetBuildJobs(ctx, build)
+	if err != nil || len(jobs) > 0 {
+		return jobs, err
+	}
+	var archived []*brigade.Job
+	if getJSON(s.sink, buildJobsKey(build.ID), &archived) != nil {
+		return jobs, nil
+	}
+	return archived, nil
+}
+
+func (s *store) GetJob(ctx context.Context, id string) (*brigade.Job, error) {
+	job, err := s.ContextStore.GetJob(ctx, id)
+	if err == nil {
+		return job, nil
+	}
+	archived := &brigade.Job{}
+	if getJSON(s.sink, jobKey(id), archived) != nil {
+		return job, err
+	}
+	return archived, nil
+}
+
+func (s *store) GetJobLog(ctx context.Context, job *brigade.Job) (string, error) {
+	l, err := s.ContextStore.GetJobLog(ctx, job)
+	if err == nil {
+		return l, nil
+	}
+	if archived, aerr := getString(s.sink, jobLogKey(job.ID)); aerr == nil {
+		return archived, nil
+	}
+	return l, err
+}
+
+func (s *store) GetJobLogStream(ctx context.Context, job *brigade.Job) (io.ReadCloser, error) {
+	r, err := s.ContextStore.GetJobLogStream(ctx, job)
+	if err == nil {
+		return r, nil
+	}
+	if archived, aerr := s.sink.Get(jobLogKey(job.ID)); aerr == nil {
+		return archived, nil
+	}
+	return r, err
+}
+
+func (s *store) GetWorkerInitLog(ctx context.Context, worker *brigade.Worker) (string, error) {
+	l, err := s.ContextStore.GetWorkerInitLog(ctx, worker)
+	if err == nil {
+		return l, nil
+	}
+	if archived, aerr := getString(s.sink, workerInitLogKey(worker.BuildID)); aerr == nil {
+		return archived, nil
+	}
+	return l, err
+}
+
+func (s *store) GetWorkerLog(ctx context.Context, worker *brigade.Worker) (string, error) {
+	l, err := s.ContextStore.GetWorkerLog(ctx, worker)
+	if err == nil {
+		return l, nil
+	}
+	if archived, aerr := getString(s.sink, workerLogKey(worker.BuildID)); aerr == nil {
+		return archived, nil
+	}
+	return l, err
+}
+
+func (s *store) GetWorkerLogStream(ctx context.Context, worker *brigade.Worker) (io.ReadCloser, error) {
+	r, err := s.ContextStore.GetWorkerLogStream(ctx, worker)
+	if err == nil {
+		return r, nil
+	}
+	if archived, aerr := s.sink.Get(workerLogKey(worker.BuildID)); aerr == nil {
+		return archived, nil
+	}
+	return r, err
+}
+
+func (s *store) GetJobLogStreamWithOptions(ctx context.Context, job *brigade.Job, opts storage.LogOptions) (io.ReadCloser, error) {
+	r, err := s.ContextStore.GetJobLogStreamWithOptions(ctx, job, opts)
+	if err == nil {
+		return r, nil
+	}
+	if opts.Container != "" {
+		return r, err
+	}
+	if archived, aerr := s.archivedLog(jobLogKey(job.ID), opts); aerr == nil {
+		return archived, nil
+	}
+	return r, err
+}
+
+func (s *store) GetWorkerLogStreamWithOptions(ctx context.Context, worker *brigade.Worker, opts storage.LogOptions) (io.ReadCloser, error) {
+	r, err := s.ContextStore.GetWorkerLogStreamWithOptions(ctx, worker, opts)
+	if err == nil {
+		return r, nil
+	}
+	key := workerLogKey(worker.BuildID)
+	switch opts.Container {
+	case "":
+	case "vcs-sidecar":
+		key = workerInitLogKey(worker.BuildID)
+	default:
+		return r, err
+	}
+	if archived, aerr := s.archivedLog(key, opts); aerr == nil {
+		return archived, nil
+	}
+	return r, err
+}
+
+// archivedLog applies the tail and grep options to an archived log. Archived
+// logs have no timestamps, so they cannot be read by time.
+func (s *store) archivedLog(key string, opts storage.LogOptions) (io.ReadCloser, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.HasTimeWindow() {
+		return nil, errors.New("archived logs have no timestamps")
+	}
+	r, err := s.sink.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if r, err = storage.TailLog(r, opts.TailLines); err != nil {
+		return nil, err
+	}
+	return storage.GrepLog(r, opts.Grep)
+}
@@ -0,0 +1,87 @@
+// Package artifacts stores the files jobs upload during builds, and keeps them
+// until the builds are deleted.
+package artifacts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when the requested artifact does not exist.
+var ErrNotFound = errors.New("artifact not found")
+
+// ErrInvalidPath is returned by a Store when a build ID or an artifact path
+// cannot be stored.
+var ErrInvalidPath = errors.New("invalid artifact path")
+
+// Artifact describes a file uploaded during a build.
+type Artifact struct {
+	// BuildID is the ID of the build the artifact belongs to.
+	BuildID string `json:"build_id"`
+	// Path is the slash-separated path of the artifact, e.g. reports/coverage.html.
+	Path string `json:"path"`
+	// Size is the size of the artifact in bytes.
+	Size int64 `json:"size"`
+	// ContentType is the media type of the artifact.
+	ContentType string `json:"content_type"`
+	// SHA256 is the hex digest of the artifact.
+	SHA256 string `json:"sha256"`
+	// Created is the time the artifact was uploaded.
+	Created time.Time `json:"created"`
+}
+
+// Store stores the artifacts of builds.
+type Store interface {
+	// Put stores the content of an artifact, replacing any previous artifact
+	// with the same path.
+	Put(buildID, path, contentType string, content io.Reader) (*Artifact, error)
+	// List returns the artifacts of a build, sorted by path.
+	List(buildID string) ([]*Artifact, error)
+	// Get returns an artifact and its content, or ErrNotFound.
+	Get(buildID, path string) (*Artifact, io.ReadCloser, error)
+	// Builds returns the IDs of the builds that have artifacts.
+	Builds() ([]string, error)
+	// DeleteBuild removes the artifacts of a build, if any.
+	DeleteBuild(buildID string) error
+}
+
+// NewStore returns the store described by the given URL.
+//
+// Supported URLs are:
+//
+//	file:///var/lib/brigade/artifacts
+func NewStore(rawurl string) (Store, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifacts URL %q: %s", rawurl, err)
+	}
+	switch u.Scheme {
+	case "file", "":
+		return NewFileStore(u.Path), nil
+	default:
+		return nil, fmt.Errorf("unsupported artifacts URL scheme %q", u.Scheme)
+	}
+}
+
+// CleanPath returns the canonical form of an artifact path: slash-separated,
+// relative and without "." or ".." elements.
+func CleanPath(p string) (string, error) {
+	p = strings.TrimPrefix(path.Clean("/"+strings.Replace(p, "\\", "/", -1)), "/")
+	if p == "" {
+		return "", ErrInvalidPath
+	}
+	return p, nil
+}
+
+// validBuildID checks that a build ID can be used as a directory name.
+func validBuildID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return ErrInvalidPath
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// metaSuffix is the suffix of the files holding the metadata of artifacts.
+const metaSuffix = ".json"
+
+// FileStore stores artifacts in a directory of the local filesystem.
+//
+// The content of an artifact is stored in <dir>/<build>/files/<path>, and its
+// metadata in <dir>/<build>/meta/<path>.json.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a store writing below the given directory.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Put writes the content of an artifact, then its metadata.
+func (s *FileStore) Put(buildID, p, contentType string, content io.Reader) (*Artifact, error) {
+	p, err := CleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := validBuildID(buildID); err != nil {
+		return nil, err
+	}
+	file := s.filePath(buildID, p)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	// Write to a temporary file first so that readers never see partial content.
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".upload-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	digest := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, digest), content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return nil, err
+	}
+
+	a := &Artifact{
+		BuildID:     buildID,
+		Path:        p,
+		Size:        size,
+		ContentType: contentType,
+		SHA256:      hex.EncodeToString(digest.Sum(nil)),
+		Created:     time.Now().UTC(),
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	meta := s.metaPath(buildID, p)
+	if err := os.MkdirAll(filepath.Dir(meta), 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(meta+".tmp", data, 0644); err != nil {
+		return nil, err
+	}
+	return a, os.Rename(meta+".tmp", meta)
+}
+
+// List reads the metadata of the artifacts of a build.
+func (s *FileStore) List(buildID string) ([]*Artifact, error) {
+	if err := validBuildID(buildID); err != nil {
+		return nil, err
+	}
+	list := []*Artifact{}
+	root := filepath.Join(s.dir, buildID, "meta")
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, metaSuffix) {
+			return nil
+		}
+		a, err := readMeta(p)
+		if err != nil {
+			return err
+		}
+		list = append(list, a)
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, err
+}
+
+// Get opens the content of an artifact.
+func (s *FileStore) Get(buildID, p string) (*Artifact, io.ReadCloser, error) {
+	p, err := CleanPath(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validBuildID(buildID); err != nil {
+		return nil, nil, err
+	}
+	a, err := readMeta(s.metaPath(buildID, p))
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(s.filePath(buildID, p))
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	}
+	return a, f, err
+}
+
+// Builds lists the directories of the builds.
+func (s *FileStore) Builds() ([]string, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			ids = append(ids, e.Name())
+		}
+	}
+	return ids, nil
+}
+
+// DeleteBuild removes the directory of a build.
+func (s *FileStore) DeleteBuild(buildID string) error {
+	if err := validBuildID(buildID); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.dir, buildID))
+}
+
+func (s *FileStore) filePath(buildID, p string) string {
+	return filepath.Join(s.dir, buildID, "files", filepath.FromSlash(p))
+}
+
+func (s *FileStore) metaPath(buildID, p string) string {
+	return filepath.Join(s.dir, buildID, "meta", filepath.FromSlash(p)+metaSuffix)
+}
+
+func readMeta(p string) (*Artifact, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	a := &Artifact{}
+	return a, json.Unmarshal(data, a)
+}
@@ -0,0 +1,93 @@
+package artifacts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := NewFileStore(filepath.Join(dir, "artifacts"))
+
+	if _, _, err := store.Get("b1", "report.html"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if list, err := store.List("b1"); err != nil || len(list) != 0 {
+		t.Errorf("expected no artifacts, got %v, %v", list, err)
+	}
+	if _, err := store.Put("b1", "reports/report.html", "text/html", strings.NewReader("draft")); err != nil {
+		t.Fatal(err)
+	}
+	a, err := store.Put("b1", "/reports/../reports/report.html", "text/html", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Path != "reports/report.html" || a.Size != 5 || a.SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected artifact %+v", a)
+	}
+	if _, err := store.Put("b1", "app.tar.gz", "", strings.NewReader("binary")); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := store.List("b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Path != "app.tar.gz" || list[1].Path != "reports/report.html" {
+		t.Errorf("unexpected artifacts %v", list)
+	}
+	got, r, err := store.Get("b1", "reports/report.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(r)
+	r.Close()
+	if string(data) != "hello" || got.ContentType != "text/html" {
+		t.Errorf("unexpected artifact %+v with content %q", got, data)
+	}
+
+	if builds, err := store.Builds(); err != nil || len(builds) != 1 || builds[0] != "b1" {
+		t.Errorf("expected build b1, got %v, %v", builds, err)
+	}
+	if err := store.DeleteBuild("b1"); err != nil {
+		t.Fatal(err)
+	}
+	if builds, err := store.Builds(); err != nil || len(builds) != 0 {
+		t.Errorf("expected no builds, got %v, %v", builds, err)
+	}
+}
+
+func TestFileStore_InvalidPaths(t *testing.T) {
+	store := NewFileStore("/nonexistent")
+	for _, tt := range []struct{ build, path string }{
+		{"b1", ""},
+		{"b1", "/"},
+		{"b1", "../.."},
+		{"..", "report.html"},
+		{"b1/../b2", "report.html"},
+	} {
+		if _, err := store.Put(tt.build, tt.path, "", strings.NewReader("")); err != ErrInvalidPath {
+			t.Errorf("%s %q: expected ErrInvalidPath, got %v", tt.build, tt.path, err)
+		}
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	for in, expect := range map[string]string{
+		"report.html":           "report.html",
+		"/reports//report.html": "reports/report.html",
+		"../../etc/passwd":      "etc/passwd",
+		`reports\report.html`:   "reports/report.html",
+	} {
+		if got, err := CleanPath(in); err != nil || got != expect {
+			t.Errorf("%q: expected %q, got %q (%v)", in, expect, got, err)
+		}
+	}
+}
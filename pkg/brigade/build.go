@@ -45,6 +45,21 @@ type Build struct {
 	// LogLevel determines what level of logging from the Javascript
 	// to print to console.
 	LogLevel string `json:"log_level,omitempty"`
+	// Attempt is the attempt number of a build created by the controller to
+	// retry a failed worker (2 for the first retry, and so on). It is not set
+	// on the first attempt of a build.
+	Attempt int `json:"attempt,omitempty"`
+	// RetryOf is the ID of the build this build is a retry of, if any.
+	RetryOf string `json:"retry_of,omitempty"`
+	// RetriedBy is the ID of the build that was created to retry this build, if any.
+	RetriedBy string `json:"retried_by,omitempty"`
+	// TriggerChain are the builds that led to this build through the triggers
+	// of their projects, the first one first. It is empty unless the build was
+	// created by a trigger.
+	TriggerChain []TriggerLink `json:"trigger_chain,omitempty"`
+	// UploadToken authorizes the upload of artifacts and test results to the
+	// build. It is generated by the controller, and never serialized.
+	UploadToken string `json:"-"`
 }
 
 // Revision describes a vcs revision.
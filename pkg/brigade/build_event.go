@@ -0,0 +1,22 @@
+package brigade
+
+import "time"
+
+// BuildEvent is a Kubernetes Event recorded by the controller about a build
+// or its worker.
+type BuildEvent struct {
+	// Type is either `Normal` or `Warning`.
+	Type string `json:"type"`
+	// Reason is a short, machine understandable reason for the event (e.g. WorkerCreated).
+	Reason string `json:"reason"`
+	// Message is a human-readable description of the event.
+	Message string `json:"message"`
+	// Object is the kind and name of the object the event is about (e.g. Pod/brigade-worker-01d...).
+	Object string `json:"object"`
+	// Count is the number of times the event occurred.
+	Count int32 `json:"count"`
+	// FirstTime is the time the event was first recorded.
+	FirstTime time.Time `json:"first_time"`
+	// LastTime is the time the event was last recorded.
+	LastTime time.Time `json:"last_time"`
+}
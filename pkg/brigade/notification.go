@@ -0,0 +1,83 @@
+package brigade
+
+// These are the build outcomes a notification rule can be sent on.
+const (
+	// NotifyOnFailure notifies the builds that failed.
+	NotifyOnFailure = "failure"
+	// NotifyOnRecovery notifies the builds that succeeded after the previous
+	// build of the project failed.
+	NotifyOnRecovery = "recovery"
+	// NotifyOnAlways notifies every build.
+	NotifyOnAlways = "always"
+)
+
+// NotifyOn are all the build outcomes a notification rule can be sent on.
+var NotifyOn = []string{NotifyOnFailure, NotifyOnRecovery, NotifyOnAlways}
+
+// These are the outcomes of completed builds.
+const (
+	// OutcomeSuccess means that the build succeeded, as did the previous build of the project.
+	OutcomeSuccess = "success"
+	// OutcomeFailure means that the build failed.
+	OutcomeFailure = "failure"
+	// OutcomeRecovery means that the build succeeded after the previous build of the project failed.
+	OutcomeRecovery = "recovery"
+)
+
+// Notification is a rule for notifying the completion of the builds of a
+// project. Exactly one of Webhook, Slack and Email must be set.
+type Notification struct {
+	// On are the build outcomes that are notified: failure, recovery or always.
+	// Only failures are notified if empty.
+	On []string `json:"on,omitempty"`
+	// Webhook posts a JSON document to a URL.
+	Webhook *WebhookNotification `json:"webhook,omitempty"`
+	// Slack posts a message to a Slack incoming webhook.
+	Slack *SlackNotification `json:"slack,omitempty"`
+	// Email sends an email through the SMTP server of the controller.
+	Email *EmailNotification `json:"email,omitempty"`
+}
+
+// WebhookNotification posts a JSON document to a URL.
+type WebhookNotification struct {
+	// URL is the URL the document is posted to.
+	URL string `json:"url"`
+	// Template is the Go template of the document. The build is posted as JSON if empty.
+	Template string `json:"template,omitempty"`
+	// Headers are added to the request, e.g. for authentication.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// SlackNotification posts a message to a Slack incoming webhook.
+type SlackNotification struct {
+	// WebhookURL is the URL of the incoming webhook.
+	WebhookURL string `json:"webhookURL"`
+	// Channel overrides the channel of the incoming webhook.
+	Channel string `json:"channel,omitempty"`
+	// Template is the Go template of the text of the message. A summary of the build if empty.
+	Template string `json:"template,omitempty"`
+}
+
+// EmailNotification sends an email.
+type EmailNotification struct {
+	// To are the recipients of the email.
+	To []string `json:"to"`
+	// Subject is the Go template of the subject. A summary of the build if empty.
+	Subject string `json:"subject,omitempty"`
+	// Template is the Go template of the body. The details of the build if empty.
+	Template string `json:"template,omitempty"`
+}
+
+// Notifies returns true if the rule notifies a build with the given outcome.
+func (n Notification) Notifies(outcome string) bool {
+	on := n.On
+	if len(on) == 0 {
+		on = []string{NotifyOnFailure}
+	}
+	for _, o := range on {
+		if o == NotifyOnAlways || o == outcome {
+			return true
+		}
+	}
+	return false
+}
@@ -36,6 +36,17 @@ type Project struct {
 	Secrets SecretsMap `json:"secrets"`
 	// Worker holds a set of project-specific worker settings which takes precedence over brigade-wide settings
 	Worker WorkerConfig `json:"worker"`
+	// Retry is the policy for retrying builds whose worker failed for infrastructure reasons
+	Retry RetryPolicy `json:"retry"`
+	// Retention is the policy brigade-vacuum applies to the builds of the project
+	Retention RetentionPolicy `json:"retention"`
+	// Notifications are the rules for notifying the completion of builds. They
+	// are not exposed, as they hold the URLs of webhooks.
+	Notifications []Notification `json:"-"`
+	// Schedules are the builds the cron gateway creates periodically.
+	Schedules []Schedule `json:"schedules,omitempty"`
+	// Triggers create builds of the project when builds of other projects complete.
+	Triggers []Trigger `json:"triggers,omitempty"`
 
 	// InitGitSubmodules initializes Git submodules in VCS if true.
 	InitGitSubmodules bool `json:"initGitSubmodules"`
@@ -136,4 +147,12 @@ type Kubernetes struct {
 	AllowSecretKeyRef bool `json:"allowSecretKeyRef"`
 	// ServiceAccount is the service account to use for this project
 	ServiceAccount string `json:"serviceAccount"`
+	// VCSSidecarRequestsCPU is the amount of CPU requested for the VCS sidecar
+	VCSSidecarRequestsCPU string `json:"vcsSidecarRequestsCPU"`
+	// VCSSidecarRequestsMemory is the amount of memory requested for the VCS sidecar
+	VCSSidecarRequestsMemory string `json:"vcsSidecarRequestsMemory"`
+	// VCSSidecarLimitsCPU is the maximum amount of CPU the VCS sidecar may use
+	VCSSidecarLimitsCPU string `json:"vcsSidecarLimitsCPU"`
+	// VCSSidecarLimitsMemory is the maximum amount of memory the VCS sidecar may use
+	VCSSidecarLimitsMemory string `json:"vcsSidecarLimitsMemory"`
 }
@@ -0,0 +1,32 @@
+package brigade
+
+import "time"
+
+// RetentionPolicy describes which builds of a project brigade-vacuum keeps.
+//
+// A zero value keeps every build. Limits can be combined, a build is deleted
+// as soon as it exceeds one of them, unless it is one of the KeepSuccessful
+// most recent successful builds.
+type RetentionPolicy struct {
+	// MaxBuilds is the maximum number of builds kept.
+	MaxBuilds int `json:"maxBuilds,omitempty"`
+	// MaxAge is the maximum age of a build (e.g. `720h`).
+	MaxAge string `json:"maxAge,omitempty"`
+	// MaxFailedAge is the maximum age of a failed build (e.g. `168h`).
+	MaxFailedAge string `json:"maxFailedAge,omitempty"`
+	// KeepSuccessful is the number of most recent successful builds that are
+	// always kept.
+	KeepSuccessful int `json:"keepSuccessful,omitempty"`
+}
+
+// IsZero returns true if the policy does not limit the builds kept.
+func (r RetentionPolicy) IsZero() bool {
+	return r == RetentionPolicy{}
+}
+
+// Ages returns the parsed MaxAge and MaxFailedAge, zero if not set or invalid.
+func (r RetentionPolicy) Ages() (maxAge, maxFailedAge time.Duration) {
+	maxAge, _ = time.ParseDuration(r.MaxAge)
+	maxFailedAge, _ = time.ParseDuration(r.MaxFailedAge)
+	return maxAge, maxFailedAge
+}
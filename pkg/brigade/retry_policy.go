@@ -0,0 +1,50 @@
+package brigade
+
+import "time"
+
+// These are the worker failure reasons a RetryPolicy can retry.
+const (
+	// RetryReasonNodeLost means the node running the worker went away.
+	RetryReasonNodeLost = "NodeLost"
+	// RetryReasonEvicted means the worker was evicted from its node, for example
+	// because the node ran out of resources.
+	RetryReasonEvicted = "Evicted"
+	// RetryReasonImagePull means the worker or VCS sidecar image could not be pulled.
+	RetryReasonImagePull = "ImagePull"
+)
+
+// RetryReasons are all the worker failure reasons that can be retried.
+var RetryReasons = []string{RetryReasonNodeLost, RetryReasonEvicted, RetryReasonImagePull}
+
+// RetryPolicy describes how builds whose worker failed for infrastructure
+// reasons are retried by the controller.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts for a build, including the
+	// first one. A value lower than 2 disables retries.
+	MaxAttempts int `json:"maxAttempts"`
+	// Reasons are the worker failure reasons that are retried. All of RetryReasons if empty.
+	Reasons []string `json:"reasons"`
+	// Backoff is the delay before the first retry (e.g. `30s`). It doubles with every further attempt.
+	Backoff string `json:"backoff"`
+}
+
+// Retries returns true if a worker that failed for the given reason on the
+// given attempt should be retried.
+func (r RetryPolicy) Retries(reason string, attempt int) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	if len(r.Reasons) == 0 {
+		return contains(RetryReasons, reason)
+	}
+	return contains(r.Reasons, reason)
+}
+
+// Delay returns how long to wait before starting the attempt following the given one.
+func (r RetryPolicy) Delay(attempt int) time.Duration {
+	backoff, err := time.ParseDuration(r.Backoff)
+	if err != nil || backoff <= 0 || attempt < 1 {
+		return 0
+	}
+	return backoff << uint(attempt-1)
+}
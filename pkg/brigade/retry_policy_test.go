@@ -0,0 +1,43 @@
+package brigade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		reason  string
+		attempt int
+		retries bool
+	}{
+		{"disabled", RetryPolicy{}, RetryReasonEvicted, 1, false},
+		{"default reasons", RetryPolicy{MaxAttempts: 3}, RetryReasonNodeLost, 1, true},
+		{"unknown reason", RetryPolicy{MaxAttempts: 3}, "Error", 1, false},
+		{"reason not listed", RetryPolicy{MaxAttempts: 3, Reasons: []string{RetryReasonEvicted}}, RetryReasonImagePull, 1, false},
+		{"reason listed", RetryPolicy{MaxAttempts: 3, Reasons: []string{RetryReasonEvicted}}, RetryReasonEvicted, 2, true},
+		{"attempts exhausted", RetryPolicy{MaxAttempts: 3}, RetryReasonEvicted, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Retries(tt.reason, tt.attempt); got != tt.retries {
+				t.Errorf("expected %t, got %t", tt.retries, got)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{Backoff: "10s"}
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second} {
+		if got := policy.Delay(attempt); got != want {
+			t.Errorf("attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+	if got := (RetryPolicy{}).Delay(1); got != 0 {
+		t.Errorf("expected no delay without a backoff, got %s", got)
+	}
+}
@@ -0,0 +1,79 @@
+package brigade
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// These are the policies for the runs of a schedule that were missed while the
+// cron gateway was not running.
+const (
+	// MissedRunsSkip starts only the runs that are less than a minute late.
+	MissedRunsSkip = "skip"
+	// MissedRunsCatchUp starts every run that was missed.
+	MissedRunsCatchUp = "catchup"
+)
+
+// MissedRunsPolicies are all the policies for missed runs.
+var MissedRunsPolicies = []string{MissedRunsSkip, MissedRunsCatchUp}
+
+// ScheduleProvider is the provider of the builds of schedules.
+const ScheduleProvider = "cron"
+
+// DefaultScheduleEventType is the event type of the builds of the schedules
+// without an event type.
+const DefaultScheduleEventType = "cron"
+
+// cronParser parses the standard five field cron specs, and descriptors like
+// @hourly and @every 90m.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Schedule describes builds the cron gateway creates periodically for a project.
+type Schedule struct {
+	// Name identifies the schedule within the project.
+	Name string `json:"name"`
+	// Cron is the cron spec of the schedule, e.g. `0 3 * * *` or `@hourly`.
+	Cron string `json:"cron"`
+	// Timezone is the IANA time zone the spec is in, e.g. Europe/Paris. UTC if empty.
+	Timezone string `json:"timezone,omitempty"`
+	// EventType is the event type of the builds. DefaultScheduleEventType if empty.
+	EventType string `json:"eventType,omitempty"`
+	// Payload is the payload of the builds. A JSON document with the name and
+	// the time of the run if empty.
+	Payload string `json:"payload,omitempty"`
+	// Ref is the VCS ref the builds run on, e.g. refs/heads/master.
+	Ref string `json:"ref,omitempty"`
+	// MissedRuns is the policy for the runs missed while the cron gateway was
+	// not running: skip or catchup. skip if empty.
+	MissedRuns string `json:"missedRuns,omitempty"`
+}
+
+// Location returns the time zone of the schedule.
+func (s Schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Next returns the time of the first run of the schedule after the given time.
+func (s Schedule) Next(after time.Time) (time.Time, error) {
+	spec, err := cronParser.Parse(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := s.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return spec.Next(after.In(loc)), nil
+}
+
+// Event returns the event type of the builds of the schedule.
+func (s Schedule) Event() string {
+	if s.EventType == "" {
+		return DefaultScheduleEventType
+	}
+	return s.EventType
+}
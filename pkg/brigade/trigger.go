@@ -0,0 +1,76 @@
+package brigade
+
+// These are the results of upstream builds a Trigger can create a build on.
+const (
+	// TriggerOnSuccess triggers on the builds that succeeded.
+	TriggerOnSuccess = "success"
+	// TriggerOnFailure triggers on the builds that failed.
+	TriggerOnFailure = "failure"
+	// TriggerOnAlways triggers on every completed build.
+	TriggerOnAlways = "always"
+)
+
+// TriggerOn are all the results a Trigger can create a build on.
+var TriggerOn = []string{TriggerOnSuccess, TriggerOnFailure, TriggerOnAlways}
+
+// TriggerProvider is the provider of the builds created by triggers.
+const TriggerProvider = "brigade"
+
+// DefaultTriggerEventType is the event type of the builds of the triggers
+// without an event type.
+const DefaultTriggerEventType = "trigger"
+
+// MaxTriggerDepth is the maximum number of builds in a trigger chain. Builds
+// at the end of a longer chain do not trigger other builds.
+var MaxTriggerDepth = 10
+
+// Trigger creates a build of a project when a build of another (upstream)
+// project completes.
+type Trigger struct {
+	// Project is the name or the ID of the upstream project.
+	Project string `json:"project"`
+	// EventType is the event type of the upstream builds. Any if empty.
+	EventType string `json:"eventType,omitempty"`
+	// On are the results of the upstream builds that trigger a build: success,
+	// failure or always. success if empty.
+	On []string `json:"on,omitempty"`
+	// Event is the event type of the triggered builds. DefaultTriggerEventType if empty.
+	Event string `json:"event,omitempty"`
+	// Ref is the VCS ref the triggered builds run on, e.g. refs/heads/master.
+	Ref string `json:"ref,omitempty"`
+}
+
+// TriggerLink is a build of a trigger chain.
+type TriggerLink struct {
+	ProjectID string `json:"project_id"`
+	BuildID   string `json:"build_id"`
+}
+
+// Matches returns true if the trigger creates a build when the given build of
+// the given project completes with the given result.
+func (t Trigger) Matches(upstream *Project, build *Build, result string) bool {
+	if ProjectID(t.Project) != upstream.ID {
+		return false
+	}
+	if t.EventType != "" && t.EventType != build.Type {
+		return false
+	}
+	on := t.On
+	if len(on) == 0 {
+		on = []string{TriggerOnSuccess}
+	}
+	for _, o := range on {
+		if o == TriggerOnAlways || o == result {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildType returns the event type of the builds created by the trigger.
+func (t Trigger) BuildType() string {
+	if t.Event == "" {
+		return DefaultTriggerEventType
+	}
+	return t.Event
+}
@@ -0,0 +1,27 @@
+package brigade
+
+import "testing"
+
+func TestTrigger_Matches(t *testing.T) {
+	upstream := &Project{ID: ProjectID("ahab/harpoons"), Name: "ahab/harpoons"}
+	release := &Build{Type: "release"}
+	for _, tt := range []struct {
+		name    string
+		trigger Trigger
+		build   *Build
+		result  string
+		expect  bool
+	}{
+		{"success by default", Trigger{Project: "ahab/harpoons"}, release, TriggerOnSuccess, true},
+		{"not failure by default", Trigger{Project: "ahab/harpoons"}, release, TriggerOnFailure, false},
+		{"by ID", Trigger{Project: upstream.ID}, release, TriggerOnSuccess, true},
+		{"other project", Trigger{Project: "ahab/whalebone"}, release, TriggerOnSuccess, false},
+		{"event type", Trigger{Project: "ahab/harpoons", EventType: "release"}, release, TriggerOnSuccess, true},
+		{"other event type", Trigger{Project: "ahab/harpoons", EventType: "push"}, release, TriggerOnSuccess, false},
+		{"always", Trigger{Project: "ahab/harpoons", On: []string{TriggerOnAlways}}, release, TriggerOnFailure, true},
+	} {
+		if got := tt.trigger.Matches(upstream, tt.build, tt.result); got != tt.expect {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.expect, got)
+		}
+	}
+}
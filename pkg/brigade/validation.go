@@ -0,0 +1,298 @@
+package brigade
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// imageRefRegex loosely matches a docker image reference of the form
+// [registry[:port]/]repository[/more][:tag][@digest].
+var imageRefRegex = regexp.MustCompile(`^([a-zA-Z0-9.-]+(:[0-9]+)?/)?[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-]+[a-z0-9]+)*)*(:[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// genericGatewaySecretRegex matches the characters allowed in a generic gateway secret.
+var genericGatewaySecretRegex = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+// pullPolicies are the image pull policies accepted by Kubernetes.
+var pullPolicies = []string{"Always", "IfNotPresent", "Never"}
+
+// ValidationError describes a single invalid field of a project.
+type ValidationError struct {
+	// Field is the name of the offending field, as stored in the project secret.
+	Field string `json:"field"`
+	// Message explains why the value is not valid.
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors is a list of validation errors for a project.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid project: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationErrors) add(field, format string, args ...interface{}) {
+	*e = append(*e, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks that the project configuration is usable by the controller
+// and the worker.
+//
+// A nil error is returned if the project is valid, otherwise the error is
+// of type ValidationErrors and lists every invalid field.
+func (p *Project) Validate() error {
+	errs := ValidationErrors{}
+
+	if p.Name == "" {
+		errs.add("name", "project name is required")
+	}
+	if p.ID != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(p.ID) {
+			errs.add("id", msg)
+		}
+	}
+
+	validatePath(&errs, "brigadejsPath", p.BrigadejsPath)
+	validatePath(&errs, "brigadeConfigPath", p.BrigadeConfigPath)
+
+	if p.Kubernetes.VCSSidecar != "" && !imageRefRegex.MatchString(p.Kubernetes.VCSSidecar) {
+		errs.add("vcsSidecar", "%q is not a valid image reference", p.Kubernetes.VCSSidecar)
+	}
+	if p.Worker.Name != "" {
+		image := p.Worker.Name
+		if p.Worker.Registry != "" {
+			image = p.Worker.Registry + "/" + image
+		}
+		if p.Worker.Tag != "" {
+			image = image + ":" + p.Worker.Tag
+		}
+		if !imageRefRegex.MatchString(image) {
+			errs.add("worker.name", "%q is not a valid image reference", image)
+		}
+	} else if p.Worker.Registry != "" || p.Worker.Tag != "" {
+		errs.add("worker.name", "worker image name is required when a registry or tag is set")
+	}
+	if p.Worker.PullPolicy != "" && !contains(pullPolicies, p.Worker.PullPolicy) {
+		errs.add("worker.pullPolicy", "%q must be one of %s", p.Worker.PullPolicy, strings.Join(pullPolicies, ", "))
+	}
+
+	validateQuantity(&errs, "buildStorageSize", p.Kubernetes.BuildStorageSize)
+	validateQuantity(&errs, "worker.requests.cpu", p.Worker.RequestsCPU)
+	validateQuantity(&errs, "worker.requests.memory", p.Worker.RequestsMemory)
+	validateQuantity(&errs, "worker.limits.cpu", p.Worker.LimitsCPU)
+	validateQuantity(&errs, "worker.limits.memory", p.Worker.LimitsMemory)
+	validateQuantity(&errs, "vcsSidecarResources.requests.cpu", p.Kubernetes.VCSSidecarRequestsCPU)
+	validateQuantity(&errs, "vcsSidecarResources.requests.memory", p.Kubernetes.VCSSidecarRequestsMemory)
+	validateQuantity(&errs, "vcsSidecarResources.limits.cpu", p.Kubernetes.VCSSidecarLimitsCPU)
+	validateQuantity(&errs, "vcsSidecarResources.limits.memory", p.Kubernetes.VCSSidecarLimitsMemory)
+
+	if p.Retry.MaxAttempts < 0 {
+		errs.add("retry.maxAttempts", "must not be negative")
+	}
+	for _, reason := range p.Retry.Reasons {
+		if !contains(RetryReasons, reason) {
+			errs.add("retry.reasons", "%q must be one of %s", reason, strings.Join(RetryReasons, ", "))
+		}
+	}
+	validateDuration(&errs, "retry.backoff", p.Retry.Backoff)
+
+	if p.Retention.MaxBuilds < 0 {
+		errs.add("retention.maxBuilds", "must not be negative")
+	}
+	if p.Retention.KeepSuccessful < 0 {
+		errs.add("retention.keepSuccessful", "must not be negative")
+	}
+	validateDuration(&errs, "retention.maxAge", p.Retention.MaxAge)
+	validateDuration(&errs, "retention.maxFailedAge", p.Retention.MaxFailedAge)
+
+	for i, n := range p.Notifications {
+		validateNotification(&errs, fmt.Sprintf("notifications[%d]", i), n)
+	}
+	for i, t := range p.Triggers {
+		field := fmt.Sprintf("triggers[%d]", i)
+		if t.Project == "" {
+			errs.add(field+".project", "must not be empty")
+		} else if p.Name != "" && ProjectID(t.Project) == ProjectID(p.Name) {
+			errs.add(field+".project", "a project cannot trigger itself")
+		}
+		for _, on := range t.On {
+			if !contains(TriggerOn, on) {
+				errs.add(field+".on", "%q must be one of %s", on, strings.Join(TriggerOn, ", "))
+			}
+		}
+	}
+	names := map[string]bool{}
+	for i, s := range p.Schedules {
+		validateSchedule(&errs, fmt.Sprintf("schedules[%d]", i), s)
+		if names[s.Name] {
+			errs.add(fmt.Sprintf("schedules[%d].name", i), "%q is not unique", s.Name)
+		}
+		names[s.Name] = true
+	}
+
+	if p.Kubernetes.ServiceAccount != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(p.Kubernetes.ServiceAccount) {
+			errs.add("serviceAccount", msg)
+		}
+	}
+	if p.ImagePullSecrets != "" {
+		for _, name := range strings.Split(p.ImagePullSecrets, ",") {
+			for _, msg := range validation.IsDNS1123Subdomain(strings.TrimSpace(name)) {
+				errs.add("imagePullSecrets", "%q: %s", name, msg)
+			}
+		}
+	}
+	if p.GenericGatewaySecret != "" && !genericGatewaySecretRegex.MatchString(p.GenericGatewaySecret) {
+		errs.add("genericGatewaySecret", "must contain only alphanumeric characters")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateServiceAccount checks that the project service account matches the
+// given regex, as the worker does before creating jobs.
+//
+// It is kept apart from Validate because the regex is part of the controller
+// configuration: the admission webhook and the API apply it when configured
+// with the same regex, while brig, which does not know it, does not.
+//
+// An empty regex or service account is always valid.
+func (p *Project) ValidateServiceAccount(regex string) error {
+	if regex == "" || p.Kubernetes.ServiceAccount == "" {
+		return nil
+	}
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return fmt.Errorf("invalid service account regex %q: %s", regex, err)
+	}
+	if !re.MatchString(p.Kubernetes.ServiceAccount) {
+		return ValidationErrors{{
+			Field:   "serviceAccount",
+			Message: fmt.Sprintf("%q does not match %q", p.Kubernetes.ServiceAccount, regex),
+		}}
+	}
+	return nil
+}
+
+// validateNotification checks the outcomes and the sink of a notification rule.
+func validateNotification(errs *ValidationErrors, field string, n Notification) {
+	for _, on := range n.On {
+		if !contains(NotifyOn, on) {
+			errs.add(field+".on", "%q must be one of %s", on, strings.Join(NotifyOn, ", "))
+		}
+	}
+	sinks := 0
+	if n.Webhook != nil {
+		sinks++
+		validateURL(errs, field+".webhook.url", n.Webhook.URL)
+	}
+	if n.Slack != nil {
+		sinks++
+		validateURL(errs, field+".slack.webhookURL", n.Slack.WebhookURL)
+	}
+	if n.Email != nil {
+		sinks++
+		if len(n.Email.To) == 0 {
+			errs.add(field+".email.to", "must not be empty")
+		}
+		for _, to := range n.Email.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				errs.add(field+".email.to", "%q: %s", to, err)
+			}
+		}
+	}
+	if sinks != 1 {
+		errs.add(field, "must have exactly one of webhook, slack and email")
+	}
+}
+
+// validateSchedule checks the spec, the time zone and the missed runs policy of
+// a schedule.
+func validateSchedule(errs *ValidationErrors, field string, s Schedule) {
+	// The name is part of the keys of the state of the cron gateway.
+	for _, msg := range validation.IsDNS1123Label(s.Name) {
+		errs.add(field+".name", "%q: %s", s.Name, msg)
+	}
+	if _, err := cronParser.Parse(s.Cron); err != nil {
+		errs.add(field+".cron", "%q: %s", s.Cron, err)
+	}
+	if _, err := s.Location(); err != nil {
+		errs.add(field+".timezone", "%q is not a known time zone", s.Timezone)
+	}
+	if s.MissedRuns != "" && !contains(MissedRunsPolicies, s.MissedRuns) {
+		errs.add(field+".missedRuns", "%q must be one of %s", s.MissedRuns, strings.Join(MissedRunsPolicies, ", "))
+	}
+}
+
+// validateURL checks that a value is an http or https URL.
+func validateURL(errs *ValidationErrors, field, value string) {
+	u, err := url.Parse(value)
+	if err != nil {
+		errs.add(field, "%s", err)
+		return
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(field, "%q must be an http or https URL", value)
+	}
+}
+
+// validatePath checks that a repository path is relative and stays inside the repository.
+func validatePath(errs *ValidationErrors, field, path string) {
+	if path == "" {
+		return
+	}
+	if filepath.IsAbs(path) {
+		errs.add(field, "%q must be a path relative to the repository root", path)
+		return
+	}
+	if clean := filepath.Clean(path); clean == ".." || strings.HasPrefix(clean, "../") {
+		errs.add(field, "%q must not point outside of the repository", path)
+	}
+}
+
+// validateQuantity checks that a non-empty value is a Kubernetes resource quantity.
+func validateQuantity(errs *ValidationErrors, field, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := resource.ParseQuantity(value); err != nil {
+		errs.add(field, "%q is not a valid quantity", value)
+	}
+}
+
+// validateDuration checks that a non-empty value is a non-negative duration.
+func validateDuration(errs *ValidationErrors, field, value string) {
+	if value == "" {
+		return
+	}
+	if d, err := time.ParseDuration(value); err != nil || d < 0 {
+		errs.add(field, "%q is not a valid duration", value)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package brigade
+
+import (
+	"testing"
+)
+
+func TestProjectValidate(t *testing.T) {
+	valid := func() *Project {
+		return &Project{
+			ID:   "brigade-830c16d4aaf6f5490937ad719afd8490a5bcbef064d397411043ac",
+			Name: "brigadecore/empty-testbed",
+			Kubernetes: Kubernetes{
+				VCSSidecar:       "brigadecore/git-sidecar:latest",
+				BuildStorageSize: "50Mi",
+				ServiceAccount:   "brigade-worker",
+			},
+			Worker: WorkerConfig{
+				Registry:   "quay.io/brigadecore",
+				Name:       "brigade-worker",
+				Tag:        "v1.2.3",
+				PullPolicy: "IfNotPresent",
+			},
+			BrigadejsPath:    "ci/brigade.js",
+			ImagePullSecrets: "regcred, other-regcred",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *Project)
+		fields []string
+	}{
+		{"valid", func(p *Project) {}, nil},
+		{"missing name", func(p *Project) { p.Name = "" }, []string{"name"}},
+		{"bad id", func(p *Project) { p.ID = "Brigade_ID" }, []string{"id"}},
+		{"absolute brigadejsPath", func(p *Project) { p.BrigadejsPath = "/brigade.js" }, []string{"brigadejsPath"}},
+		{"escaping brigadeConfigPath", func(p *Project) { p.BrigadeConfigPath = "ci/../../brigade.json" }, []string{"brigadeConfigPath"}},
+		{"bad sidecar image", func(p *Project) { p.Kubernetes.VCSSidecar = "git sidecar" }, []string{"vcsSidecar"}},
+		{"sidecar with registry port and digest", func(p *Project) {
+			p.Kubernetes.VCSSidecar = "localhost:5000/git-sidecar@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		}, nil},
+		{"bad worker tag", func(p *Project) { p.Worker.Tag = "-v1" }, []string{"worker.name"}},
+		{"worker tag without name", func(p *Project) { p.Worker.Name = "" }, []string{"worker.name"}},
+		{"bad pull policy", func(p *Project) { p.Worker.PullPolicy = "Sometimes" }, []string{"worker.pullPolicy"}},
+		{"bad quantities", func(p *Project) {
+			p.Kubernetes.BuildStorageSize = "50MB"
+			p.Worker.LimitsMemory = "lots"
+			p.Kubernetes.VCSSidecarRequestsCPU = "1 cpu"
+		}, []string{"buildStorageSize", "worker.limits.memory", "vcsSidecarResources.requests.cpu"}},
+		{"bad service account", func(p *Project) { p.Kubernetes.ServiceAccount = "Brigade Worker" }, []string{"serviceAccount"}},
+		{"bad image pull secret", func(p *Project) { p.ImagePullSecrets = "regcred,," }, []string{"imagePullSecrets", "imagePullSecrets"}},
+		{"bad retry policy", func(p *Project) {
+			p.Retry = RetryPolicy{MaxAttempts: -1, Reasons: []string{"Error"}, Backoff: "soon"}
+		}, []string{"retry.maxAttempts", "retry.reasons", "retry.backoff"}},
+		{"bad retention policy", func(p *Project) {
+			p.Retention = RetentionPolicy{MaxBuilds: -1, MaxAge: "30d", MaxFailedAge: "-1h", KeepSuccessful: -2}
+		}, []string{"retention.maxBuilds", "retention.keepSuccessful", "retention.maxAge", "retention.maxFailedAge"}},
+		{"valid notifications", func(p *Project) {
+			p.Notifications = []Notification{
+				{On: []string{NotifyOnFailure, NotifyOnRecovery}, Slack: &SlackNotification{WebhookURL: "https://hooks.slack.com/services/T0/B0/x"}},
+				{Email: &EmailNotification{To: []string{"Ahab <ahab@pequod.example>"}}},
+			}
+		}, nil},
+		{"bad notifications", func(p *Project) {
+			p.Notifications = []Notification{
+				{On: []string{"success"}, Webhook: &WebhookNotification{URL: "ftp://example.com"}},
+				{Email: &EmailNotification{To: []string{"ahab"}}, Slack: &SlackNotification{WebhookURL: "https://hooks.slack.com"}},
+				{},
+			}
+		}, []string{"notifications[0].on", "notifications[0].webhook.url", "notifications[1].email.to", "notifications[1]", "notifications[2]"}},
+		{"valid triggers", func(p *Project) {
+			p.Triggers = []Trigger{
+				{Project: "ahab/harpoons", EventType: "release", Event: "deploy"},
+				{Project: ProjectID("ahab/whalebone"), On: []string{TriggerOnFailure, TriggerOnAlways}},
+			}
+		}, nil},
+		{"bad triggers", func(p *Project) {
+			p.Triggers = []Trigger{
+				{Project: p.Name},
+				{On: []string{"recovery"}},
+			}
+		}, []string{"triggers[0].project", "triggers[1].project", "triggers[1].on"}},
+		{"valid schedules", func(p *Project) {
+			p.Schedules = []Schedule{
+				{Name: "nightly", Cron: "0 3 * * *", Timezone: "Europe/Paris", MissedRuns: MissedRunsCatchUp},
+				{Name: "hourly", Cron: "@hourly"},
+			}
+		}, nil},
+		{"bad schedules", func(p *Project) {
+			p.Schedules = []Schedule{
+				{Name: "nightly", Cron: "0 3 * *", Timezone: "Mars/Olympus_Mons"},
+				{Name: "nightly", Cron: "@hourly", MissedRuns: "never"},
+				{Cron: "@daily"},
+			}
+		}, []string{"schedules[0].cron", "schedules[0].timezone", "schedules[1].missedRuns", "schedules[1].name", "schedules[2].name"}},
+		{"bad generic gateway secret", func(p *Project) { p.GenericGatewaySecret = "s3cr3t!" }, []string{"genericGatewaySecret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.modify(p)
+			err := p.Validate()
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("expected project to be valid, got %s", err)
+				}
+				return
+			}
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("expected ValidationErrors, got %#v", err)
+			}
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("expected %d errors, got %d: %s", len(tt.fields), len(errs), errs)
+			}
+			for i, field := range tt.fields {
+				if errs[i].Field != field {
+					t.Errorf("expected error %d on field %q, got %q", i, field, errs[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectValidateServiceAccount(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceAccount string
+		regex          string
+		valid          bool
+	}{
+		{"no regex", "anything", "", true},
+		{"no service account", "", "^brigade-.*$", true},
+		{"matching", "brigade-worker", "^brigade-.*$", true},
+		{"not matching", "default", "^brigade-.*$", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Project{Kubernetes: Kubernetes{ServiceAccount: tt.serviceAccount}}
+			err := p.ValidateServiceAccount(tt.regex)
+			if tt.valid && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	p := &Project{Kubernetes: Kubernetes{ServiceAccount: "brigade-worker"}}
+	if err := p.ValidateServiceAccount("("); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+}
@@ -15,6 +15,14 @@ type WorkerConfig struct {
 	Tag string `json:"tag"`
 	// PullPolicy specifies when you want to pull the docker image for brigade-worker
 	PullPolicy string `json:"pullPolicy"`
+	// RequestsCPU is the amount of CPU requested for the worker container (e.g. `500m`)
+	RequestsCPU string `json:"requestsCPU"`
+	// RequestsMemory is the amount of memory requested for the worker container (e.g. `256Mi`)
+	RequestsMemory string `json:"requestsMemory"`
+	// LimitsCPU is the maximum amount of CPU the worker container may use (e.g. `1`)
+	LimitsCPU string `json:"limitsCPU"`
+	// LimitsMemory is the maximum amount of memory the worker container may use (e.g. `1Gi`)
+	LimitsMemory string `json:"limitsMemory"`
 }
 
 // Image returns the full worker image name
@@ -0,0 +1,157 @@
+// Package commitstatus reports the state of builds back to GitHub as commit
+// statuses.
+package commitstatus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+	"golang.org/x/oauth2"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// These are the states of a commit status.
+const (
+	StatePending = "pending"
+	StateSuccess = "success"
+	StateFailure = "failure"
+)
+
+// DefaultTimeout is the maximum duration of a report of a Reporter without a
+// timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Reporter posts the state of builds as commit statuses, with the token and
+// the GitHub (Enterprise) API of their project.
+type Reporter struct {
+	// DashboardURL is the URL of the dashboard the statuses link to, e.g.
+	// https://kashti.example.com. Statuses have no link if empty.
+	DashboardURL string
+	// Client is the HTTP client the authenticated clients are built on. A
+	// client with the timeout of the reporter if nil.
+	Client *http.Client
+	// Timeout is the maximum duration of each report, DefaultTimeout if 0.
+	Timeout time.Duration
+}
+
+// timeout returns the maximum duration of each report.
+func (r *Reporter) timeout() time.Duration {
+	if r.Timeout > 0 {
+		return r.Timeout
+	}
+	return DefaultTimeout
+}
+
+// Reports returns true if the state of the build can be reported: it has a
+// commit and its project has a GitHub token.
+func Reports(proj *brigade.Project, build *brigade.Build) bool {
+	return proj.Github.Token != "" && build.Revision != nil && build.Revision.Commit != ""
+}
+
+// StateOf returns the state of a completed build.
+func StateOf(build *brigade.Build) string {
+	if build.Worker != nil && build.Worker.Status == brigade.JobSucceeded {
+		return StateSuccess
+	}
+	return StateFailure
+}
+
+// Report posts the state of the build on its commit within the timeout of the
+// reporter. Builds that cannot be reported are ignored.
+func (r *Reporter) Report(ctx context.Context, proj *brigade.Project, build *brigade.Build, state string) error {
+	if !Reports(proj, build) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout())
+	defer cancel()
+	owner, repo, err := ownerAndRepo(proj.Repo.Name)
+	if err != nil {
+		return err
+	}
+	client, err := r.client(ctx, proj)
+	if err != nil {
+		return err
+	}
+	status := &github.RepoStatus{
+		State:       github.String(state),
+		Context:     github.String(Context(build)),
+		Description: github.String(description(build, state)),
+	}
+	if u := r.TargetURL(build); u != "" {
+		status.TargetURL = github.String(u)
+	}
+	if _, _, err := client.Repositories.CreateStatus(ctx, owner, repo, build.Revision.Commit, status); err != nil {
+		return fmt.Errorf("could not report the status of build %s to %s/%s: %s", build.ID, owner, repo, err)
+	}
+	return nil
+}
+
+// Context returns the context of the statuses of a build, which is the same
+// for all the builds of an event type so that a retry or a new build of the
+// same commit replaces the status of the previous one.
+func Context(build *brigade.Build) string {
+	return "brigade/" + build.Type
+}
+
+// TargetURL returns the URL of the build in the dashboard, if any.
+func (r *Reporter) TargetURL(build *brigade.Build) string {
+	if r.DashboardURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(r.DashboardURL, "/") + "/builds/" + build.ID
+}
+
+// description returns the description of a status.
+func description(build *brigade.Build, state string) string {
+	switch state {
+	case StatePending:
+		return fmt.Sprintf("Build %s is running", build.ID)
+	case StateSuccess:
+		return fmt.Sprintf("Build %s succeeded", build.ID)
+	default:
+		return fmt.Sprintf("Build %s failed", build.ID)
+	}
+}
+
+// client returns a GitHub client authenticated with the token of the project,
+// for its GitHub Enterprise server if it has a base URL.
+func (r *Reporter) client(ctx context.Context, proj *brigade.Project) (*github.Client, error) {
+	base := r.Client
+	if base == nil {
+		base = &http.Client{Timeout: r.timeout()}
+	}
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpClient := &http.Client{
+		Transport: &oauth2.Transport{
+			Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: proj.Github.Token}),
+			Base:   transport,
+		},
+		Timeout: base.Timeout,
+	}
+	if proj.Github.BaseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+	uploadURL := proj.Github.UploadURL
+	if uploadURL == "" {
+		uploadURL = proj.Github.BaseURL
+	}
+	return github.NewEnterpriseClient(proj.Github.BaseURL, uploadURL, httpClient)
+}
+
+// ownerAndRepo returns the owner and the name of a repository from its name,
+// e.g. github.com/brigadecore/brigade.
+func ownerAndRepo(name string) (string, string, error) {
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", fmt.Errorf("repository %q is not of the form github.com/owner/name", name)
+	}
+	return parts[len(parts)-2], strings.TrimSuffix(parts[len(parts)-1], ".git"), nil
+}
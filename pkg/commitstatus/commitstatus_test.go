@@ -0,0 +1,135 @@
+package commitstatus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+func TestReport(t *testing.T) {
+	var paths, auths []string
+	var statuses []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+			t.Error(err)
+		}
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		auths = append(auths, r.Header.Get("Authorization"))
+		statuses = append(statuses, status)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	proj := &brigade.Project{
+		Name:   "ahab/pequod",
+		Repo:   brigade.Repo{Name: "ghe.example.com/ahab/pequod"},
+		Github: brigade.Github{Token: "s3cr3t", BaseURL: ts.URL},
+	}
+	build := &brigade.Build{
+		ID:       "01e9z0",
+		Type:     "push",
+		Revision: &brigade.Revision{Commit: "c0ffee"},
+		Worker:   &brigade.Worker{Status: brigade.JobFailed},
+	}
+	r := &Reporter{DashboardURL: "https://kashti.example.com/"}
+	if err := r.Report(context.Background(), proj, build, StatePending); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Report(context.Background(), proj, build, StateOf(build)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(paths))
+	}
+	if paths[0] != "POST /api/v3/repos/ahab/pequod/statuses/c0ffee" {
+		t.Errorf("expected the status to be posted to the GitHub Enterprise API, got %s", paths[0])
+	}
+	if auths[0] != "Bearer s3cr3t" {
+		t.Errorf("expected the token of the project, got %q", auths[0])
+	}
+	expect := map[string]string{
+		"state":       "failure",
+		"context":     "brigade/push",
+		"description": "Build 01e9z0 failed",
+		"target_url":  "https://kashti.example.com/builds/01e9z0",
+	}
+	for k, v := range expect {
+		if statuses[1][k] != v {
+			t.Errorf("expected %s %q, got %q", k, v, statuses[1][k])
+		}
+	}
+	if statuses[0]["state"] != "pending" {
+		t.Errorf("expected a pending status first, got %v", statuses[0])
+	}
+
+	// Builds without a commit, and projects without a token, are not reported.
+	build.Revision.Commit = ""
+	if err := r.Report(context.Background(), proj, build, StatePending); err != nil || len(paths) != 2 {
+		t.Errorf("expected the build without a commit to be ignored, got %v", err)
+	}
+}
+
+func TestReport_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer ts.Close()
+
+	proj := &brigade.Project{
+		Repo:   brigade.Repo{Name: "github.com/ahab/pequod.git"},
+		Github: brigade.Github{Token: "s3cr3t", BaseURL: ts.URL},
+	}
+	build := &brigade.Build{ID: "01e9z0", Type: "push", Revision: &brigade.Revision{Commit: "c0ffee"}}
+	if err := (&Reporter{}).Report(context.Background(), proj, build, StatePending); err == nil {
+		t.Error("expected the error of the API")
+	}
+}
+
+func TestOwnerAndRepo(t *testing.T) {
+	for _, tt := range []struct {
+		name, owner, repo string
+		ok                bool
+	}{
+		{"github.com/brigadecore/brigade", "brigadecore", "brigade", true},
+		{"ghe.example.com/ahab/pequod.git", "ahab", "pequod", true},
+		{"pequod", "", "", false},
+		{"github.com//pequod", "", "", false},
+	} {
+		owner, repo, err := ownerAndRepo(tt.name)
+		if owner != tt.owner || repo != tt.repo || (err == nil) != tt.ok {
+			t.Errorf("%s: expected %s/%s (ok %t), got %s/%s (%v)", tt.name, tt.owner, tt.repo, tt.ok, owner, repo, err)
+		}
+	}
+}
+
+func TestReport_Timeout(t *testing.T) {
+	hang := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer ts.Close()
+	defer close(hang)
+
+	proj := &brigade.Project{
+		Repo:   brigade.Repo{Name: "ghe.example.com/ahab/pequod"},
+		Github: brigade.Github{Token: "s3cr3t", BaseURL: ts.URL},
+	}
+	build := &brigade.Build{ID: "01e9z0", Type: "push", Revision: &brigade.Revision{Commit: "c0ffee"}}
+	r := &Reporter{Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	if err := r.Report(context.Background(), proj, build, StatePending); err == nil {
+		t.Error("expected the GitHub server that does not answer to time out")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected the report to give up after its timeout, took %s", d)
+	}
+}
@@ -0,0 +1,368 @@
+// Package logging writes leveled, structured log lines as text or JSON, so
+// that log pipelines can index the events of Brigade.
+//
+// Every line has a time, a level and a message, and may carry fields. The
+// standard fields identify the project, build, event type, provider and
+// request a line is about:
+//
+//	logging.WithBuild(build).Infof("started worker %s", pod.Name)
+//
+// writes, in the text format:
+//
+//	time=2020-06-01T15:04:05Z level=info msg="started worker brigade-worker-01e9" project_id=brigade-83f1 build_id=01e9 event_type=push provider=github
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// Level is the severity of a log line.
+type Level int
+
+// The levels, from the most verbose.
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < DebugLevel || l > ErrorLevel {
+		return strconv.Itoa(int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses the name of a level: debug, info, warn or error.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q, must be one of %s", s, strings.Join(levelNames, ", "))
+}
+
+// Format is the format of the log lines.
+type Format string
+
+const (
+	// TextFormat writes logfmt lines, key=value pairs separated by spaces.
+	TextFormat Format = "text"
+	// JSONFormat writes a JSON object per line.
+	JSONFormat Format = "json"
+)
+
+// ParseFormat parses the name of a format: text or json.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case TextFormat, JSONFormat:
+		return f, nil
+	}
+	return TextFormat, fmt.Errorf("unknown log format %q, must be %s or %s", s, TextFormat, JSONFormat)
+}
+
+// The standard fields.
+const (
+	ProjectIDKey = "project_id"
+	BuildIDKey   = "build_id"
+	EventTypeKey = "event_type"
+	ProviderKey  = "provider"
+	RequestIDKey = "request_id"
+)
+
+// output is where the loggers derived from a logger write.
+type output struct {
+	mu     sync.Mutex
+	w      io.Writer
+	level  Level
+	format Format
+	now    func() time.Time
+}
+
+// field is a key and its value.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// Logger writes log lines with its fields. Loggers are safe for concurrent
+// use.
+type Logger struct {
+	out    *output
+	fields []field
+}
+
+// New returns a logger writing the lines of at least a level to w.
+func New(w io.Writer, level Level, format Format) *Logger {
+	return &Logger{out: &output{w: w, level: level, format: format, now: time.Now}}
+}
+
+// With returns a logger adding a field to the lines of l. Fields with an
+// empty value are left out.
+func (l *Logger) With(key string, value interface{}) *Logger {
+	if s, ok := value.(string); ok && s == "" {
+		return l
+	}
+	fields := make([]field, 0, len(l.fields)+1)
+	for _, f := range l.fields {
+		if f.key != key {
+			fields = append(fields, f)
+		}
+	}
+	return &Logger{out: l.out, fields: append(fields, field{key, value})}
+}
+
+// WithBuild returns a logger adding the project ID, build ID, event type and
+// provider of a build to the lines of l.
+func (l *Logger) WithBuild(b *brigade.Build) *Logger {
+	if b == nil {
+		return l
+	}
+	return l.With(ProjectIDKey, b.ProjectID).
+		With(BuildIDKey, b.ID).
+		With(EventTypeKey, b.Type).
+		With(ProviderKey, b.Provider)
+}
+
+// WithRequestID returns a logger adding a request ID to the lines of l.
+func (l *Logger) WithRequestID(id string) *Logger {
+	return l.With(RequestIDKey, id)
+}
+
+// Enabled tells whether l writes the lines of a level.
+func (l *Logger) Enabled(level Level) bool {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	return level >= l.out.level
+}
+
+// Debugf logs a debug message.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.log(DebugLevel, fmt.Sprintf(format, args...))
+}
+
+// Infof logs an informational message.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(InfoLevel, fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a warning.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(WarnLevel, fmt.Sprintf(format, args...))
+}
+
+// Errorf logs an error.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+// Fatalf logs an error and exits with status 1.
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.log(ErrorLevel, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+// Writer returns a writer logging every line written to it as a message of a
+// level, to redirect loggers of other packages.
+func (l *Logger) Writer(level Level) io.Writer {
+	return &lineWriter{logger: l, level: level}
+}
+
+func (l *Logger) log(level Level, msg string) {
+	o := l.out
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if level < o.level {
+		return
+	}
+	var buf bytes.Buffer
+	t := o.now().UTC().Format(time.RFC3339Nano)
+	msg = strings.TrimRight(msg, "\n")
+	if o.format == JSONFormat {
+		writeJSON(&buf, t, level, msg, l.fields)
+	} else {
+		writeText(&buf, t, level, msg, l.fields)
+	}
+	o.w.Write(buf.Bytes())
+}
+
+func writeText(buf *bytes.Buffer, t string, level Level, msg string, fields []field) {
+	fmt.Fprintf(buf, "time=%s level=%s msg=%s", t, level, textValue(msg))
+	for _, f := range fields {
+		fmt.Fprintf(buf, " %s=%s", f.key, textValue(fmt.Sprint(f.value)))
+	}
+	buf.WriteByte('\n')
+}
+
+// textValue quotes a value if it is empty, has spaces or equal signs, or
+// characters that need escaping.
+func textValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " =") || strconv.Quote(s) != `"`+s+`"` {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func writeJSON(buf *bytes.Buffer, t string, level Level, msg string, fields []field) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, t)
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, msg)
+	for _, f := range fields {
+		buf.WriteByte(',')
+		writeJSONValue(buf, f.key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, f.value)
+	}
+	buf.WriteString("}\n")
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+// lineWriter logs the lines written to it.
+type lineWriter struct {
+	logger *Logger
+	level  Level
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w.logger.log(w.level, line)
+	}
+	return len(p), nil
+}
+
+// std is the logger of the package level functions.
+var std = New(os.Stderr, InfoLevel, TextFormat)
+
+// Default returns the logger of the package level functions.
+func Default() *Logger {
+	return std
+}
+
+// Configure sets the level and the format of the package level logger and of
+// the loggers derived from it, and redirects the standard log package to it.
+func Configure(level, format string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	f, err := ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	std.out.mu.Lock()
+	std.out.level = lvl
+	std.out.format = f
+	std.out.mu.Unlock()
+
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(std.Writer(InfoLevel))
+	return nil
+}
+
+// SetOutput sets where the package level logger writes.
+func SetOutput(w io.Writer) {
+	std.out.mu.Lock()
+	defer std.out.mu.Unlock()
+	std.out.w = w
+}
+
+// DefaultLevel returns the log level of $BRIGADE_LOG_LEVEL, info by default.
+func DefaultLevel() string {
+	if v, ok := os.LookupEnv("BRIGADE_LOG_LEVEL"); ok {
+		return v
+	}
+	return InfoLevel.String()
+}
+
+// DefaultFormat returns the log format of $BRIGADE_LOG_FORMAT, text by
+// default.
+func DefaultFormat() string {
+	if v, ok := os.LookupEnv("BRIGADE_LOG_FORMAT"); ok {
+		return v
+	}
+	return string(TextFormat)
+}
+
+// With returns a logger adding a field to the lines of the package level
+// logger.
+func With(key string, value interface{}) *Logger {
+	return std.With(key, value)
+}
+
+// WithBuild returns a logger adding the fields of a build to the lines of the
+// package level logger.
+func WithBuild(b *brigade.Build) *Logger {
+	return std.WithBuild(b)
+}
+
+// Debugf logs a debug message with the package level logger.
+func Debugf(format string, args ...interface{}) {
+	std.log(DebugLevel, fmt.Sprintf(format, args...))
+}
+
+// Infof logs an informational message with the package level logger.
+func Infof(format string, args ...interface{}) {
+	std.log(InfoLevel, fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a warning with the package level logger.
+func Warnf(format string, args ...interface{}) {
+	std.log(WarnLevel, fmt.Sprintf(format, args...))
+}
+
+// Errorf logs an error with the package level logger.
+func Errorf(format string, args ...interface{}) {
+	std.log(ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+// Fatalf logs an error with the package level logger and exits with status 1.
+func Fatalf(format string, args ...interface{}) {
+	std.log(ErrorLevel, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying a logger, typically with the ID of the
+// request the context belongs to.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of a context, or the package level logger.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return std
+}
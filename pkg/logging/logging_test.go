@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+func testLogger(level Level, format Format) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	l := New(&buf, level, format)
+	l.out.now = func() time.Time {
+		return time.Date(2020, 6, 1, 15, 4, 5, 0, time.UTC)
+	}
+	return l, &buf
+}
+
+var testBuild = &brigade.Build{
+	ID:        "01e9yvdxfytw1ek0t3sg7ywbwr",
+	ProjectID: "brigade-83f1",
+	Type:      "push",
+	Provider:  "github",
+}
+
+func TestText(t *testing.T) {
+	l, buf := testLogger(InfoLevel, TextFormat)
+	l.WithBuild(testBuild).WithRequestID("42").Infof("started worker %s", "brigade-worker-01e9")
+	l.With("path", "").Warnf("empty")
+	l.With("reason", "no space left").Errorf("deploy=failed")
+
+	expect := `time=2020-06-01T15:04:05Z level=info msg="started worker brigade-worker-01e9" project_id=brigade-83f1 build_id=01e9yvdxfytw1ek0t3sg7ywbwr event_type=push provider=github request_id=42
+time=2020-06-01T15:04:05Z level=warn msg=empty
+time=2020-06-01T15:04:05Z level=error msg="deploy=failed" reason="no space left"
+`
+	if buf.String() != expect {
+		t.Errorf("expected\n%s\ngot\n%s", expect, buf.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	l, buf := testLogger(InfoLevel, JSONFormat)
+	l.WithBuild(testBuild).With("error", errors.New("boom")).With("attempt", 2).Errorf("worker \"%s\" failed\n", "w")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %s", buf.String(), err)
+	}
+	expect := map[string]interface{}{
+		"time":       "2020-06-01T15:04:05Z",
+		"level":      "error",
+		"msg":        `worker "w" failed`,
+		"project_id": "brigade-83f1",
+		"build_id":   "01e9yvdxfytw1ek0t3sg7ywbwr",
+		"event_type": "push",
+		"provider":   "github",
+		"error":      "boom",
+		"attempt":    float64(2),
+	}
+	for k, v := range expect {
+		if line[k] != v {
+			t.Errorf("expected %s %v, got %v", k, v, line[k])
+		}
+	}
+	if len(line) != len(expect) {
+		t.Errorf("expected %d fields, got %v", len(expect), line)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	l, buf := testLogger(WarnLevel, TextFormat)
+	l.Debugf("debug")
+	l.Infof("info")
+	if buf.Len() != 0 {
+		t.Errorf("expected lines below warn to be dropped, got %q", buf.String())
+	}
+	l.Warnf("warn")
+	if buf.Len() == 0 {
+		t.Error("expected warnings to be logged")
+	}
+	if l.Enabled(InfoLevel) || !l.Enabled(ErrorLevel) {
+		t.Error("expected only warn and above to be enabled")
+	}
+
+	for _, tt := range []struct {
+		name  string
+		level Level
+		ok    bool
+	}{
+		{"debug", DebugLevel, true},
+		{"WARN", WarnLevel, true},
+		{"verbose", InfoLevel, false},
+	} {
+		level, err := ParseLevel(tt.name)
+		if (err == nil) != tt.ok || level != tt.level {
+			t.Errorf("%s: expected level %s (ok %t), got %s (%v)", tt.name, tt.level, tt.ok, level, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	l, buf := testLogger(InfoLevel, TextFormat)
+	l.Writer(InfoLevel).Write([]byte("first\nsecond\n"))
+	expect := "time=2020-06-01T15:04:05Z level=info msg=first\ntime=2020-06-01T15:04:05Z level=info msg=second\n"
+	if buf.String() != expect {
+		t.Errorf("expected %q, got %q", expect, buf.String())
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != Default() {
+		t.Error("expected the default logger without a logger in the context")
+	}
+	l := Default().WithRequestID("42")
+	if FromContext(NewContext(context.Background(), l)) != l {
+		t.Error("expected the logger of the context")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	id := RequestID(r)
+	if len(id) != 32 || id == RequestID(r) {
+		t.Errorf("expected random request IDs, got %q", id)
+	}
+	r.Header.Set(RequestIDHeader, "42")
+	if id := RequestID(r); id != "42" {
+		t.Errorf("expected the request ID of the header, got %q", id)
+	}
+}
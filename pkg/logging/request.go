@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying the ID of a request, so that the
+// lines of a request can be correlated across services.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID returns the ID of a request from its header, or a new random ID
+// if it has none.
+func RequestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
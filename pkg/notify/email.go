@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// SMTPConfig is the server email notifications are sent through.
+type SMTPConfig struct {
+	// Address is the host:port of the server.
+	Address string
+	// From is the sender of the emails.
+	From string
+	// Username and Password authenticate to the server, if a username is set.
+	Username string
+	Password string
+}
+
+// EmailSink sends an email.
+type EmailSink struct {
+	SMTP   SMTPConfig
+	Config brigade.EmailNotification
+}
+
+// defaultEmailTemplate is the body of the emails of the rules without a template.
+const defaultEmailTemplate = `{{.Summary}}
+
+Project:  {{.Project}} ({{.ProjectID}})
+Build:    {{.BuildID}}
+Event:    {{.Type}} ({{.Provider}})
+{{- if .Commit}}
+Commit:   {{.Commit}}{{if .Ref}} ({{.Ref}}){{end}}
+{{- end}}
+Status:   {{.Status}} (exit code {{.ExitCode}})
+Duration: {{.Duration}}
+`
+
+// Notify sends the email of the event.
+func (s *EmailSink) Notify(ctx context.Context, e *Event) error {
+	subject := e.Summary()
+	if s.Config.Subject != "" {
+		text, err := render("subject", s.Config.Subject, e)
+		if err != nil {
+			return fmt.Errorf("invalid email subject: %s", err)
+		}
+		subject = text
+	}
+	tmpl := s.Config.Template
+	if tmpl == "" {
+		tmpl = defaultEmailTemplate
+	}
+	body, err := render("email", tmpl, e)
+	if err != nil {
+		return fmt.Errorf("invalid email template: %s", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.SMTP.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.Config.To, ", "))
+	// Line breaks in the subject would add headers.
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.Join(strings.Fields(subject), " "))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	var auth smtp.Auth
+	if s.SMTP.Username != "" {
+		host, _, err := net.SplitHostPort(s.SMTP.Address)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %s", s.SMTP.Address, err)
+		}
+		auth = smtp.PlainAuth("", s.SMTP.Username, s.SMTP.Password, host)
+	}
+	from, err := mail.ParseAddress(s.SMTP.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %s", s.SMTP.From, err)
+	}
+	to := make([]string, 0, len(s.Config.To))
+	for _, rcpt := range s.Config.To {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %s", rcpt, err)
+		}
+		to = append(to, addr.Address)
+	}
+	return sendMail(ctx, s.SMTP.Address, auth, from.Address, to, msg.Bytes())
+}
+
+// sendMail is smtp.SendMail with a deadline: the one of the context, or
+// DefaultTimeout from now if it has none. Unlike smtp.SendMail, a server that
+// does not answer cannot block it forever.
+func sendMail(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %s", addr, err)
+	}
+	conn, err := net.DialTimeout("tcp", addr, time.Until(deadline))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP server %s does not support authentication", addr)
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
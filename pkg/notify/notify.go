@@ -0,0 +1,189 @@
+// Package notify notifies the completion of builds to webhooks, Slack and
+// email, following the notification rules of their project.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// Event describes a completed build to the notification sinks and templates.
+type Event struct {
+	// Outcome is success, failure or recovery.
+	Outcome   string        `json:"outcome"`
+	ProjectID string        `json:"project_id"`
+	Project   string        `json:"project"`
+	BuildID   string        `json:"build_id"`
+	Type      string        `json:"type"`
+	Provider  string        `json:"provider"`
+	Commit    string        `json:"commit,omitempty"`
+	Ref       string        `json:"ref,omitempty"`
+	Status    string        `json:"status"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Duration  time.Duration `json:"duration"`
+	ExitCode  int32         `json:"exit_code"`
+}
+
+// NewEvent returns the event of a completed build with the given outcome.
+func NewEvent(outcome string, proj *brigade.Project, build *brigade.Build) *Event {
+	e := &Event{
+		Outcome:   outcome,
+		ProjectID: proj.ID,
+		Project:   proj.Name,
+		BuildID:   build.ID,
+		Type:      build.Type,
+		Provider:  build.Provider,
+	}
+	if build.Revision != nil {
+		e.Commit = build.Revision.Commit
+		e.Ref = build.Revision.Ref
+	}
+	if w := build.Worker; w != nil {
+		e.Status = w.Status.String()
+		e.StartTime = w.StartTime
+		e.EndTime = w.EndTime
+		e.ExitCode = w.ExitCode
+		if !w.StartTime.IsZero() && w.EndTime.After(w.StartTime) {
+			e.Duration = w.EndTime.Sub(w.StartTime)
+		}
+	}
+	return e
+}
+
+// Summary returns a one line description of the event, e.g.
+// "Build 01e9yv of ahab/pequod failed (push, github)".
+func (e *Event) Summary() string {
+	var verb string
+	switch e.Outcome {
+	case brigade.OutcomeFailure:
+		verb = "failed"
+	case brigade.OutcomeRecovery:
+		verb = "recovered"
+	default:
+		verb = "succeeded"
+	}
+	return fmt.Sprintf("Build %s of %s %s (%s, %s)", e.BuildID, e.Project, verb, e.Type, e.Provider)
+}
+
+// Sink sends the notification of an event.
+type Sink interface {
+	Notify(ctx context.Context, e *Event) error
+}
+
+// DefaultTimeout is the maximum duration of a notification of a Notifier
+// without a timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Notifier notifies the completion of builds according to the notification
+// rules of their project.
+type Notifier struct {
+	// Store is used to find the previous build of a project.
+	Store storage.ContextStore
+	// Client sends the webhook and Slack requests. A client with the timeout
+	// of the notifier if nil.
+	Client *http.Client
+	// SMTP is the server the emails are sent through.
+	SMTP SMTPConfig
+	// Timeout is the maximum duration of each notification, DefaultTimeout if 0.
+	Timeout time.Duration
+}
+
+// timeout returns the maximum duration of each notification.
+func (n *Notifier) timeout() time.Duration {
+	if n.Timeout > 0 {
+		return n.Timeout
+	}
+	return DefaultTimeout
+}
+
+// Outcome returns the outcome of a completed build: failure if it failed,
+// recovery if it succeeded after the previous completed build of the project
+// failed, and success otherwise.
+func (n *Notifier) Outcome(ctx context.Context, proj *brigade.Project, build *brigade.Build) (string, error) {
+	if build.Worker == nil || build.Worker.Status != brigade.JobSucceeded {
+		return brigade.OutcomeFailure, nil
+	}
+	builds, err := n.Store.GetProjectBuilds(ctx, proj)
+	if err != nil {
+		return "", err
+	}
+	// Build IDs are ULIDs, which sort by creation time.
+	var previous *brigade.Build
+	for _, b := range builds {
+		if b.ID >= build.ID || b.Worker == nil || b.RetriedBy != "" {
+			continue
+		}
+		if b.Worker.Status != brigade.JobSucceeded && b.Worker.Status != brigade.JobFailed {
+			continue
+		}
+		if previous == nil || b.ID > previous.ID {
+			previous = b
+		}
+	}
+	if previous != nil && previous.Worker.Status == brigade.JobFailed {
+		return brigade.OutcomeRecovery, nil
+	}
+	return brigade.OutcomeSuccess, nil
+}
+
+// NotifyBuild sends the notifications of a completed build. Every rule that
+// matches the outcome of the build is attempted within the timeout of the
+// notifier, and their errors are returned together.
+func (n *Notifier) NotifyBuild(ctx context.Context, proj *brigade.Project, build *brigade.Build) error {
+	if len(proj.Notifications) == 0 {
+		return nil
+	}
+	outcome, err := n.Outcome(ctx, proj, build)
+	if err != nil {
+		return fmt.Errorf("could not find the outcome of build %s: %s", build.ID, err)
+	}
+	event := NewEvent(outcome, proj, build)
+
+	var errs []string
+	for i, rule := range proj.Notifications {
+		if !rule.Notifies(outcome) {
+			continue
+		}
+		sink, err := n.sink(rule)
+		if err == nil {
+			sinkCtx, cancel := context.WithTimeout(ctx, n.timeout())
+			err = sink.Notify(sinkCtx, event)
+			cancel()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("notifications[%d]: %s", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to notify build %s: %s", build.ID, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// sink returns the sink of a notification rule.
+func (n *Notifier) sink(rule brigade.Notification) (Sink, error) {
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: n.timeout()}
+	}
+	switch {
+	case rule.Webhook != nil:
+		return &WebhookSink{Client: client, Config: *rule.Webhook}, nil
+	case rule.Slack != nil:
+		return &SlackSink{Client: client, Config: *rule.Slack}, nil
+	case rule.Email != nil:
+		if n.SMTP.Address == "" {
+			return nil, fmt.Errorf("no SMTP server is configured for email notifications")
+		}
+		return &EmailSink{SMTP: n.SMTP, Config: *rule.Email}, nil
+	default:
+		return nil, fmt.Errorf("no sink")
+	}
+}
@@ -0,0 +1,309 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/mock"
+)
+
+// testBuild returns a completed build of mock.StubProject.
+func testBuild(id string, status brigade.JobStatus) *brigade.Build {
+	start := time.Date(2020, 6, 1, 15, 4, 5, 0, time.UTC)
+	return &brigade.Build{
+		ID:        id,
+		ProjectID: mock.StubProject.ID,
+		Type:      "push",
+		Provider:  "github",
+		Revision:  &brigade.Revision{Commit: "c0ffee", Ref: "refs/heads/master"},
+		Worker: &brigade.Worker{
+			ID:        "brigade-worker-" + id,
+			BuildID:   id,
+			StartTime: start,
+			EndTime:   start.Add(90 * time.Second),
+			Status:    status,
+		},
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		builds []*brigade.Build
+		build  *brigade.Build
+		expect string
+	}{
+		{"failure", nil, testBuild("01e9z0", brigade.JobFailed), brigade.OutcomeFailure},
+		{"first success", nil, testBuild("01e9z0", brigade.JobSucceeded), brigade.OutcomeSuccess},
+		{"recovery", []*brigade.Build{
+			testBuild("01e9x0", brigade.JobSucceeded),
+			testBuild("01e9y0", brigade.JobFailed),
+			testBuild("01e9y1", brigade.JobRunning),
+		}, testBuild("01e9z0", brigade.JobSucceeded), brigade.OutcomeRecovery},
+		{"success after success", []*brigade.Build{
+			testBuild("01e9x0", brigade.JobFailed),
+			testBuild("01e9y0", brigade.JobSucceeded),
+			// Later builds are not taken into account.
+			testBuild("01e9z9", brigade.JobFailed),
+		}, testBuild("01e9z0", brigade.JobSucceeded), brigade.OutcomeSuccess},
+	} {
+		n := &Notifier{Store: &mock.Store{Builds: tt.builds}}
+		outcome, err := n.Outcome(context.Background(), mock.StubProject, tt.build)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outcome != tt.expect {
+			t.Errorf("%s: expected outcome %s, got %s", tt.name, tt.expect, outcome)
+		}
+	}
+}
+
+func TestNotifyBuild(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	proj := *mock.StubProject
+	proj.Name = "ahab/pequod"
+	proj.Notifications = []brigade.Notification{
+		{Webhook: &brigade.WebhookNotification{
+			URL:      ts.URL + "/hook",
+			Template: `{"build": {{json .BuildID}}, "outcome": "{{.Outcome}}"}`,
+			Headers:  map[string]string{"Authorization": "Bearer s3cr3t"},
+		}},
+		{On: []string{brigade.NotifyOnRecovery}, Webhook: &brigade.WebhookNotification{URL: ts.URL + "/recovery"}},
+		{On: []string{brigade.NotifyOnAlways}, Slack: &brigade.SlackNotification{WebhookURL: ts.URL + "/slack", Channel: "#builds"}},
+		{Webhook: &brigade.WebhookNotification{URL: ts.URL + "/broken"}},
+	}
+
+	n := &Notifier{Store: &mock.Store{}}
+	err := n.NotifyBuild(context.Background(), &proj, testBuild("01e9z0", brigade.JobFailed))
+	if err == nil || !strings.Contains(err.Error(), "notifications[3]: unexpected response status 500") {
+		t.Errorf("expected the error of the broken webhook, got %v", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(requests))
+	}
+
+	if requests[0].URL.Path != "/hook" || requests[0].Header.Get("Authorization") != "Bearer s3cr3t" {
+		t.Errorf("expected the webhook request with its headers, got %s %v", requests[0].URL, requests[0].Header)
+	}
+	if bodies[0] != `{"build": "01e9z0", "outcome": "failure"}` {
+		t.Errorf("unexpected webhook body %s", bodies[0])
+	}
+
+	var msg slackMessage
+	if err := json.Unmarshal([]byte(bodies[1]), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "Build 01e9z0 of ahab/pequod failed (push, github)"; msg.Text != expect || msg.Channel != "#builds" {
+		t.Errorf("expected the Slack message %q to #builds, got %+v", expect, msg)
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(bodies[2]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.BuildID != "01e9z0" || event.Duration != 90*time.Second || event.Commit != "c0ffee" {
+		t.Errorf("expected the event as the default webhook body, got %s", bodies[2])
+	}
+}
+
+func TestNotifyBuild_Timeout(t *testing.T) {
+	hang := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer ts.Close()
+	defer close(hang)
+
+	proj := *mock.StubProject
+	proj.Notifications = []brigade.Notification{
+		{Webhook: &brigade.WebhookNotification{URL: ts.URL + "/hook"}},
+	}
+	n := &Notifier{Store: &mock.Store{}, Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	if err := n.NotifyBuild(context.Background(), &proj, testBuild("01e9z0", brigade.JobFailed)); err == nil {
+		t.Error("expected the webhook that does not answer to time out")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected the notification to give up after its timeout, took %s", d)
+	}
+}
+
+func TestNotifyBuild_Recovery(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer ts.Close()
+
+	proj := *mock.StubProject
+	proj.Notifications = []brigade.Notification{
+		{Webhook: &brigade.WebhookNotification{URL: ts.URL + "/failure"}},
+		{On: []string{brigade.NotifyOnRecovery}, Webhook: &brigade.WebhookNotification{URL: ts.URL + "/recovery"}},
+	}
+	n := &Notifier{Store: &mock.Store{Builds: []*brigade.Build{testBuild("01e9y0", brigade.JobFailed)}}}
+	if err := n.NotifyBuild(context.Background(), &proj, testBuild("01e9z0", brigade.JobSucceeded)); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/recovery" {
+		t.Errorf("expected only the recovery to be notified, got %v", paths)
+	}
+}
+
+func TestEmailSink(t *testing.T) {
+	server := newTestSMTPServer(t)
+	defer server.Close()
+
+	sink := &EmailSink{
+		SMTP: SMTPConfig{Address: server.Addr(), From: "Brigade <brigade@example.com>"},
+		Config: brigade.EmailNotification{
+			To:      []string{"Ahab <ahab@example.com>", "starbuck@example.com"},
+			Subject: "[{{.Project}}] {{.Outcome}}",
+		},
+	}
+	proj := &brigade.Project{ID: "brigade-83f1", Name: "ahab/pequod"}
+	if err := sink.Notify(context.Background(), NewEvent(brigade.OutcomeFailure, proj, testBuild("01e9z0", brigade.JobFailed))); err != nil {
+		t.Fatal(err)
+	}
+
+	mail := <-server.mails
+	if mail.from != "brigade@example.com" {
+		t.Errorf("unexpected sender %q", mail.from)
+	}
+	if strings.Join(mail.to, ",") != "ahab@example.com,starbuck@example.com" {
+		t.Errorf("unexpected recipients %v", mail.to)
+	}
+	for _, expect := range []string{
+		"Subject: [ahab/pequod] failure\r\n",
+		"To: Ahab <ahab@example.com>, starbuck@example.com\r\n",
+		"Build 01e9z0 of ahab/pequod failed (push, github)\r\n",
+		"Commit:   c0ffee (refs/heads/master)\r\n",
+		"Duration: 1m30s\r\n",
+	} {
+		if !strings.Contains(mail.data, expect) {
+			t.Errorf("expected the email to contain %q, got:\n%s", expect, mail.data)
+		}
+	}
+}
+
+func TestEmailSink_Timeout(t *testing.T) {
+	// The server accepts connections but never greets the client.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	sink := &EmailSink{
+		SMTP:   SMTPConfig{Address: l.Addr().String(), From: "brigade@example.com"},
+		Config: brigade.EmailNotification{To: []string{"ahab@example.com"}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	proj := &brigade.Project{ID: "brigade-83f1", Name: "ahab/pequod"}
+	if err := sink.Notify(ctx, NewEvent(brigade.OutcomeFailure, proj, testBuild("01e9z0", brigade.JobFailed))); err == nil {
+		t.Error("expected the SMTP server that does not answer to time out")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("expected the email to give up after the deadline, took %s", d)
+	}
+}
+
+// testMail is an email received by a testSMTPServer.
+type testMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// testSMTPServer is a local SMTP server that accepts every email.
+type testSMTPServer struct {
+	listener net.Listener
+	mails    chan testMail
+}
+
+func newTestSMTPServer(t *testing.T) *testSMTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSMTPServer{listener: l, mails: make(chan testMail, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *testSMTPServer) Addr() string { return s.listener.Addr().String() }
+
+func (s *testSMTPServer) Close() { s.listener.Close() }
+
+func (s *testSMTPServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var mail testMail
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case cmd == "EHLO" || cmd == "HELO":
+			reply("250 localhost")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			mail.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			mail.to = append(mail.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			mail.data = data.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			s.mails <- mail
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
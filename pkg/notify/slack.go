@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// SlackSink posts a message to a Slack incoming webhook.
+type SlackSink struct {
+	Client *http.Client
+	Config brigade.SlackNotification
+}
+
+// slackMessage is the payload of an incoming webhook.
+type slackMessage struct {
+	Text    string `json:"text"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// Notify posts the message of the event.
+func (s *SlackSink) Notify(ctx context.Context, e *Event) error {
+	msg := slackMessage{Text: e.Summary(), Channel: s.Config.Channel}
+	if s.Config.Template != "" {
+		text, err := render("slack", s.Config.Template, e)
+		if err != nil {
+			return fmt.Errorf("invalid slack template: %s", err)
+		}
+		msg.Text = text
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.Client, s.Config.WebhookURL, nil, body)
+}
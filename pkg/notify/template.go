@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+)
+
+// funcs are the functions available to the notification templates, in
+// addition to the built-in ones.
+var funcs = template.FuncMap{
+	// json quotes a value as JSON, e.g. {"text": {{json .Summary}}}.
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// render executes a notification template on an event.
+func render(name, text string, e *Event) (string, error) {
+	t, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, e); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
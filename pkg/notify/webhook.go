@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// WebhookSink posts a JSON document to a URL. The document is the event, or
+// the output of the template of the rule.
+type WebhookSink struct {
+	Client *http.Client
+	Config brigade.WebhookNotification
+}
+
+// Notify posts the document of the event.
+func (s *WebhookSink) Notify(ctx context.Context, e *Event) error {
+	var body []byte
+	if s.Config.Template == "" {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		body = b
+	} else {
+		text, err := render("webhook", s.Config.Template, e)
+		if err != nil {
+			return fmt.Errorf("invalid webhook template: %s", err)
+		}
+		body = []byte(text)
+	}
+	return post(ctx, s.Client, s.Config.URL, s.Config.Headers, body)
+}
+
+// post sends a JSON document and fails unless the response is a success.
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error may contain the URL, which often is a secret.
+		return fmt.Errorf("request failed")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
@@ -43,7 +43,7 @@ func NewDelegatedRunner(c kubernetes.Interface, namespace string) (*Runner, erro
 
 // Runner represents an object that delegates running a script on Kubernetes
 type Runner struct {
-	store     storage.Store
+	store     storage.ContextStore
 	kc        kubernetes.Interface
 	namespace string
 
@@ -55,9 +55,10 @@ type Runner struct {
 	Verbose    bool
 }
 
-// SendBuild creates and runs a given Brigade build
-func (a *Runner) SendBuild(b *brigade.Build) error {
-	if err := a.store.CreateBuild(b); err != nil {
+// SendBuild creates and runs a given Brigade build. Waiting for the worker and
+// streaming its logs stop when the context is done.
+func (a *Runner) SendBuild(ctx context.Context, b *brigade.Build) error {
+	if err := a.store.CreateBuild(ctx, b); err != nil {
 		return err
 	}
 
@@ -69,26 +70,81 @@ func (a *Runner) SendBuild(b *brigade.Build) error {
 	}
 	fmt.Fprintf(a.RunnerLogDestination, "Event created. Waiting for worker pod named %q.\n", podName)
 
-	if err := a.waitForWorker(b.ID); err != nil {
+	if err := a.waitForWorker(ctx, b.ID, 2*time.Minute); err != nil {
 		return err
 	}
 
 	fmt.Fprintf(a.RunnerLogDestination, "Build: %s, Worker: %s\n", b.ID, podName)
-	if err := a.podLog(podName, a.ScriptLogDestination); err != nil {
+	if err := a.podLog(ctx, podName, a.ScriptLogDestination); err != nil {
 		return err
 	}
 
-	// Now that everything is complete, get the pod status. If the pod failed, exit 1.
-	// Fortunately, the worker pod is marked "failed" if one of the jobs
-	// in the build fails and the error isn't handled with a .catch().
-	pod, err := a.kc.CoreV1().Pods(a.namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	return a.buildResult(ctx, b.ID)
+}
+
+// FollowBuild streams the log of the worker of an existing build until it
+// completes, waiting for the worker first if the build is still queued. With
+// jobs, the logs of the jobs are streamed too as they start, every line
+// prefixed with its job. Like SendBuild, it returns a BuildFailure if the
+// build failed.
+func (a *Runner) FollowBuild(ctx context.Context, buildID string, jobs bool, opts storage.BuildLogOptions) error {
+	b, err := a.store.GetBuild(ctx, buildID)
+	// The build of a worker that was not created yet is returned with the
+	// error of the worker.
+	if b == nil {
+		return err
+	}
+
+	podName := fmt.Sprintf("brigade-worker-%s", b.ID)
+	if b.Worker == nil || b.Worker.Status == brigade.JobPending {
+		fmt.Fprintf(a.RunnerLogDestination, "Build is queued. Waiting for worker pod named %q.\n", podName)
+		// A queued build may wait for a while, the context bounds the wait.
+		if err := a.waitForWorker(ctx, b.ID, 0); err != nil {
+			return err
+		}
+		if b.Worker, err = a.store.GetWorker(ctx, b.ID); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(a.RunnerLogDestination, "Build: %s, Worker: %s\n", b.ID, podName)
+	opts.Follow = true
+	var logs io.ReadCloser
+	if jobs {
+		logs, err = storage.BuildLogs(ctx, a.store, b, opts)
+	} else {
+		logs, err = a.store.GetWorkerLogStreamWithOptions(ctx, b.Worker, opts.LogOptions)
+	}
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	if !a.NoProgress {
+		progressLogs(a.ScriptLogDestination, logs)
+	}
+	if _, err := io.Copy(a.ScriptLogDestination, logs); err != nil {
+		return err
+	}
+
+	return a.buildResult(ctx, b.ID)
+}
+
+// buildResult returns a BuildFailure with the exit code of the worker if the
+// build failed. Fortunately, the worker pod is marked "failed" if one of the
+// jobs in the build fails and the error isn't handled with a .catch().
+func (a *Runner) buildResult(ctx context.Context, buildID string) error {
+	podName := fmt.Sprintf("brigade-worker-%s", buildID)
+	pod, err := a.kc.CoreV1().Pods(a.namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 
 	podPhase := pod.Status.Phase
 	if podPhase == v1.PodFailed || podPhase == v1.PodUnknown {
-		return NewBuildFailure("build failed. (Build ID: %s)", b.ID)
+		f := NewBuildFailure("build failed. (Build ID: %s)", buildID)
+		f.ExitCode = kube.NewWorkerFromPod(*pod).ExitCode
+		return f
 	}
 
 	return nil
@@ -97,10 +153,10 @@ func (a *Runner) SendBuild(b *brigade.Build) error {
 // SendScript converts a script into a Brigade Build object and submits it
 // TODO: ripe for refactor to reduce amount of params... create/use Script struct?
 // https://github.com/brigadecore/brigade/issues/1021
-func (a *Runner) SendScript(projectName string, data []byte, config []byte, event, commitish, ref string, payload []byte, logLevel string) error {
+func (a *Runner) SendScript(ctx context.Context, projectName string, data []byte, config []byte, event, commitish, ref string, payload []byte, logLevel string) error {
 
 	projectID := brigade.ProjectID(projectName)
-	if _, err := a.store.GetProject(projectID); err != nil {
+	if _, err := a.store.GetProject(ctx, projectID); err != nil {
 		return fmt.Errorf("could not find the project %q: %s", projectName, err)
 	}
 
@@ -117,22 +173,27 @@ func (a *Runner) SendScript(projectName string, data []byte, config []byte, even
 		Config:   config,
 		LogLevel: logLevel,
 	}
-	return a.SendBuild(b)
+	return a.SendBuild(ctx, b)
 }
 
-// waitForWorker waits until the worker has started.
-func (a *Runner) waitForWorker(buildID string) error {
+// waitForWorker waits until the worker has started, for at most timeout if it
+// is not 0.
+func (a *Runner) waitForWorker(ctx context.Context, buildID string, timeout time.Duration) error {
 	opts := metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("heritage=brigade,component=build,build=%s", buildID),
 	}
-	req, err := a.kc.CoreV1().Pods(a.namespace).Watch(context.TODO(), opts)
+	req, err := a.kc.CoreV1().Pods(a.namespace).Watch(ctx, opts)
 	if err != nil {
 		return err
 	}
 	res := req.ResultChan()
 
 	// Now we block until the Pod is ready
-	timeout := time.After(2 * time.Minute)
+	var waiting string
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
 	for {
 		select {
 		case e := <-res:
@@ -156,24 +217,66 @@ func (a *Runner) waitForWorker(buildID string) error {
 					return nil
 				case "Failed":
 					req.Stop()
+					// A worker that ran has a log and an exit code.
+					if started(pod) {
+						return nil
+					}
 					return fmt.Errorf("pod failed to schedule: %s", pod.Status.Reason)
+				case "Pending":
+					// Tell why the worker does not start, e.g. ImagePullBackOff,
+					// every time it changes.
+					if w := waitingReason(pod); w != waiting {
+						waiting = w
+						if w != "" {
+							fmt.Fprintf(a.RunnerLogDestination, "Worker is waiting: %s\n", w)
+						}
+					}
 				}
 			}
-		case <-timeout:
+		case <-expired:
 			req.Stop()
 			return fmt.Errorf("timeout waiting for build %s to start", buildID)
+		case <-ctx.Done():
+			req.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// waitingReason returns the reason and the message of the first waiting
+// container of a pod, init containers first, or "" if none is waiting.
+func waitingReason(pod *v1.Pod) string {
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, cs := range statuses {
+			if w := cs.State.Waiting; w != nil && w.Reason != "" {
+				if w.Message == "" {
+					return w.Reason
+				}
+				return fmt.Sprintf("%s: %s", w.Reason, w.Message)
+			}
+		}
+	}
+	return ""
+}
+
+// started returns true if a container of the pod ran.
+func started(pod *v1.Pod) bool {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Terminated != nil || cs.State.Running != nil {
+			return true
 		}
 	}
+	return false
 }
 
-func (a *Runner) podLog(name string, w io.Writer) error {
+func (a *Runner) podLog(ctx context.Context, name string, w io.Writer) error {
 	tailAllLines := int64(math.MaxInt64)
 	req := a.kc.CoreV1().Pods(a.namespace).GetLogs(name, &v1.PodLogOptions{
 		Follow:    true,
 		TailLines: &tailAllLines,
 	})
 
-	readCloser, err := req.Timeout(waitTimeout).Stream(context.TODO())
+	readCloser, err := req.Timeout(waitTimeout).Stream(ctx)
 	if err != nil {
 		return err
 	}
@@ -188,8 +291,8 @@ func (a *Runner) podLog(name string, w io.Writer) error {
 }
 
 // GetBuild retrieves a given build by id from storage
-func (a *Runner) GetBuild(bid string) (*brigade.Build, error) {
-	return a.store.GetBuild(bid)
+func (a *Runner) GetBuild(ctx context.Context, bid string) (*brigade.Build, error) {
+	return a.store.GetBuild(ctx, bid)
 }
 
 func progressLogs(w io.Writer, r io.Reader) {
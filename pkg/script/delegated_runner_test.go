@@ -0,0 +1,126 @@
+package script
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/storage/kube"
+)
+
+// followStore reads builds from the fake clientset, which cannot stream logs.
+type followStore struct {
+	storage.ContextStore
+}
+
+func (followStore) GetWorkerLogStreamWithOptions(ctx context.Context, w *brigade.Worker, opts storage.LogOptions) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader("the worker log\n")), nil
+}
+
+var followLabels = map[string]string{"heritage": "brigade", "component": "build", "project": "ahab", "build": "queequeg"}
+
+func followBuild() *v1.Secret {
+	return &v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: followLabels}}
+}
+
+func failedWorker(exitCode int32) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "brigade-worker-queequeg", Namespace: v1.NamespaceDefault, Labels: followLabels},
+		Status: v1.PodStatus{
+			Phase:     v1.PodFailed,
+			StartTime: &meta.Time{Time: time.Now()},
+			ContainerStatuses: []v1.ContainerStatus{{
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: exitCode}},
+			}},
+		},
+	}
+}
+
+func newFollowRunner(client *fake.Clientset, out *bytes.Buffer) *Runner {
+	return &Runner{
+		store:                followStore{kube.NewDirect(client, v1.NamespaceDefault)},
+		kc:                   client,
+		namespace:            v1.NamespaceDefault,
+		ScriptLogDestination: out,
+		RunnerLogDestination: out,
+		NoProgress:           true,
+	}
+}
+
+func TestRunner_FollowBuild_Failed(t *testing.T) {
+	client := fake.NewSimpleClientset(followBuild(), failedWorker(2))
+	out := new(bytes.Buffer)
+
+	err := newFollowRunner(client, out).FollowBuild(context.TODO(), "queequeg", false, storage.BuildLogOptions{})
+	f, ok := err.(BuildFailure)
+	if !ok {
+		t.Fatalf("expected a build failure, got %v", err)
+	}
+	if f.ExitCode != 2 {
+		t.Errorf("expected exit code 2, got %d", f.ExitCode)
+	}
+	if !strings.Contains(out.String(), "the worker log") {
+		t.Errorf("expected the log of the worker, got %q", out)
+	}
+}
+
+func TestRunner_FollowBuild_Queued(t *testing.T) {
+	client := fake.NewSimpleClientset(followBuild())
+	out := new(bytes.Buffer)
+	done := make(chan error, 1)
+	go func() {
+		done <- newFollowRunner(client, out).FollowBuild(context.TODO(), "queequeg", false, storage.BuildLogOptions{})
+	}()
+
+	// The worker is created once the runner watches for it.
+	err := wait.Poll(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		for _, a := range client.Actions() {
+			if a.GetVerb() == "watch" {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal("expected the runner to watch the worker")
+	}
+	pods := client.CoreV1().Pods(v1.NamespaceDefault)
+	worker := failedWorker(3)
+	worker.Status = v1.PodStatus{
+		Phase: v1.PodPending,
+		ContainerStatuses: []v1.ContainerStatus{{
+			State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+		}},
+	}
+	if _, err := pods.Create(context.TODO(), worker, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pods.Update(context.TODO(), failedWorker(3), meta.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if f, ok := err.(BuildFailure); !ok || f.ExitCode != 3 {
+			t.Errorf("expected a build failure with exit code 3, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the runner to follow the worker until it completes")
+	}
+	for _, want := range []string{"Build is queued", "Worker is waiting: ImagePullBackOff: Back-off pulling image", "the worker log"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
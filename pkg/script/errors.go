@@ -5,6 +5,9 @@ import "fmt"
 // BuildFailure represents a failure from a Brigade build
 type BuildFailure struct {
 	msg string
+
+	// ExitCode is the exit code of the worker, 0 if it is unknown.
+	ExitCode int32
 }
 
 func (f BuildFailure) Error() string {
@@ -0,0 +1,355 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// BuildLogOptions selects the logs combined by BuildLogs. The log options
+// apply to the log of the worker and of every job, except for the container.
+type BuildLogOptions struct {
+	LogOptions
+	// Color colors the prefix of the lines of every job.
+	Color bool
+	// PollInterval is how often to look for new jobs when following, one
+	// second if 0.
+	PollInterval time.Duration
+}
+
+// WorkerLogPrefix prefixes the lines of the worker in a combined build log.
+const WorkerLogPrefix = "worker"
+
+// prefixColors are the ANSI colors of the prefixes, in the order they are
+// assigned to the worker and the jobs.
+var prefixColors = []string{"36", "33", "32", "35", "34", "31"}
+
+// BuildLogs returns the logs of the worker and of the jobs of a build as a
+// single stream, every line prefixed with the name of the job that logged it.
+//
+// When following, the logs of the jobs are followed as soon as the jobs start,
+// and the stream ends once the worker completed and the logs of its jobs were
+// read. Otherwise, the lines are interleaved in the order they were logged.
+func BuildLogs(ctx context.Context, s ContextStore, build *brigade.Build, opts BuildLogOptions) (io.ReadCloser, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// The grep expression matches the text of the lines, without the prefix
+	// or the timestamp. The timestamps are always read to interleave the
+	// lines, and only written if asked for.
+	grep, _ := regexp.Compile(opts.Grep)
+	c := &combiner{
+		store:      s,
+		opts:       opts,
+		grep:       grep,
+		timestamps: opts.Timestamps,
+		prefixes:   map[string]string{},
+	}
+	c.opts.Grep = ""
+	c.opts.Container = ""
+	c.opts.Timestamps = true
+
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	c.w = pw
+	if opts.Follow {
+		go func() {
+			pw.CloseWithError(c.follow(ctx, build))
+		}()
+	} else {
+		sources, err := c.open(ctx, build)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		go func() {
+			pw.CloseWithError(c.merge(sources))
+		}()
+	}
+	return &cancelReader{PipeReader: pr, cancel: cancel}, nil
+}
+
+// cancelReader cancels the reading of the logs when it is closed.
+type cancelReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Close() error {
+	r.cancel()
+	return r.PipeReader.Close()
+}
+
+// combiner writes the lines of several logs to a single stream.
+type combiner struct {
+	store      ContextStore
+	opts       BuildLogOptions
+	grep       *regexp.Regexp
+	timestamps bool
+
+	mu       sync.Mutex
+	w        io.Writer
+	prefixes map[string]string
+}
+
+// follow follows the logs of the worker and of every job that starts until
+// the worker completes.
+func (c *combiner) follow(ctx context.Context, build *brigade.Build) error {
+	interval := c.opts.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Wait for the worker to start, its log cannot be read before.
+	worker := build.Worker
+	for worker == nil || worker.Status == brigade.JobPending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		worker, _ = c.store.GetWorker(ctx, build.ID)
+	}
+
+	opts := c.opts.LogOptions
+	opts.Follow = true
+	r, err := c.store.GetWorkerLogStreamWithOptions(ctx, worker, opts)
+	if err != nil {
+		return err
+	}
+	workerDone := make(chan struct{})
+	go func() {
+		c.copy(WorkerLogPrefix, r)
+		close(workerDone)
+	}()
+
+	var wg sync.WaitGroup
+	following := map[string]bool{}
+	followJobs := func() {
+		jobs, err := c.store.GetBuildJobs(ctx, build)
+		if err != nil {
+			return
+		}
+		for _, job := range jobs {
+			if following[job.ID] || job.Status == brigade.JobPending {
+				continue
+			}
+			r, err := c.store.GetJobLogStreamWithOptions(ctx, job, opts)
+			if err != nil {
+				// Try again on the next poll.
+				continue
+			}
+			following[job.ID] = true
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				c.copy(name, r)
+			}(jobLogPrefix(job))
+		}
+	}
+
+	for {
+		followJobs()
+		select {
+		case <-workerDone:
+			// Pick up the jobs that started since the last poll.
+			followJobs()
+			wg.Wait()
+			return nil
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// copy writes the lines of a log until it ends, and closes it.
+func (c *combiner) copy(prefix string, r io.ReadCloser) {
+	defer r.Close()
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			ts, text := splitTimestamp(line)
+			if c.writeLine(prefix, ts, text) != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// logSource is a log read line by line for merging.
+type logSource struct {
+	prefix string
+	r      io.ReadCloser
+	br     *bufio.Reader
+	ts     []byte
+	text   []byte
+	time   time.Time
+	done   bool
+}
+
+// next reads the next line of the log.
+func (s *logSource) next() {
+	line, err := s.br.ReadBytes('\n')
+	if len(line) == 0 && err != nil {
+		s.done = true
+		s.r.Close()
+		return
+	}
+	s.ts, s.text = splitTimestamp(line)
+	// Lines without a timestamp keep the time of the previous line, so that
+	// they stay in order.
+	if t, err := time.Parse(time.RFC3339Nano, string(s.ts)); err == nil {
+		s.time = t
+	}
+}
+
+// open opens the logs of the worker and of the jobs that started.
+func (c *combiner) open(ctx context.Context, build *brigade.Build) ([]*logSource, error) {
+	if build.Worker == nil {
+		return nil, fmt.Errorf("build %s has no worker", build.ID)
+	}
+	var sources []*logSource
+	r, err := c.openLog(func(opts LogOptions) (io.ReadCloser, error) {
+		return c.store.GetWorkerLogStreamWithOptions(ctx, build.Worker, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, &logSource{prefix: WorkerLogPrefix, r: r})
+
+	jobs, err := c.store.GetBuildJobs(ctx, build)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.Status == brigade.JobPending {
+			continue
+		}
+		job := job
+		r, err := c.openLog(func(opts LogOptions) (io.ReadCloser, error) {
+			return c.store.GetJobLogStreamWithOptions(ctx, job, opts)
+		})
+		if err != nil {
+			// The log of the job is gone, combine the others.
+			continue
+		}
+		sources = append(sources, &logSource{prefix: jobLogPrefix(job), r: r})
+	}
+	return sources, nil
+}
+
+// openLog opens a log with timestamps to interleave its lines, or without
+// them if the log has none, like archived logs.
+func (c *combiner) openLog(open func(LogOptions) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	r, err := open(c.opts.LogOptions)
+	if err != nil {
+		opts := c.opts.LogOptions
+		opts.Timestamps = false
+		if r, rerr := open(opts); rerr == nil {
+			return r, nil
+		}
+	}
+	return r, err
+}
+
+// merge writes the lines of the logs in the order they were logged.
+func (c *combiner) merge(sources []*logSource) error {
+	for _, s := range sources {
+		s.br = bufio.NewReader(s.r)
+		s.next()
+	}
+	for {
+		var first *logSource
+		for _, s := range sources {
+			if !s.done && (first == nil || s.time.Before(first.time)) {
+				first = s
+			}
+		}
+		if first == nil {
+			return nil
+		}
+		if err := c.writeLine(first.prefix, first.ts, first.text); err != nil {
+			for _, s := range sources {
+				if !s.done {
+					s.r.Close()
+				}
+			}
+			return err
+		}
+		first.next()
+	}
+}
+
+// writeLine writes a line with its prefix, unless it does not match the grep
+// expression.
+func (c *combiner) writeLine(prefix string, ts, text []byte) error {
+	text = bytes.TrimSuffix(text, []byte{'\n'})
+	if !c.grep.Match(text) {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var buf bytes.Buffer
+	buf.WriteString(c.prefix(prefix))
+	if c.timestamps && len(ts) > 0 {
+		buf.Write(ts)
+		buf.WriteByte(' ')
+	}
+	buf.Write(text)
+	buf.WriteByte('\n')
+	_, err := c.w.Write(buf.Bytes())
+	return err
+}
+
+// prefix returns the prefix of the lines of a job, in the next color if
+// colors are enabled. It must be called with the lock held.
+func (c *combiner) prefix(name string) string {
+	if p, ok := c.prefixes[name]; ok {
+		return p
+	}
+	p := "[" + name + "] "
+	if c.opts.Color {
+		color := prefixColors[len(c.prefixes)%len(prefixColors)]
+		p = "\x1b[" + color + "m[" + name + "]\x1b[0m "
+	}
+	c.prefixes[name] = p
+	return p
+}
+
+// jobLogPrefix returns the prefix of the lines of a job.
+func jobLogPrefix(job *brigade.Job) string {
+	if job.Name != "" {
+		return job.Name
+	}
+	return job.ID
+}
+
+// splitTimestamp splits the RFC3339 timestamp Kubernetes adds to log lines
+// from their text. It returns no timestamp if the line has none.
+func splitTimestamp(line []byte) ([]byte, []byte) {
+	i := bytes.IndexByte(line, ' ')
+	if i < 0 {
+		return nil, line
+	}
+	if _, err := time.Parse(time.RFC3339Nano, string(line[:i])); err != nil {
+		return nil, line
+	}
+	return line[:i], line[i+1:]
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// logStore serves the logs of a worker and of its jobs.
+type logStore struct {
+	ContextStore
+
+	mu      sync.Mutex
+	worker  *brigade.Worker
+	jobs    []*brigade.Job
+	logs    map[string]string
+	options []LogOptions
+}
+
+func (s *logStore) GetWorker(ctx context.Context, bid string) (*brigade.Worker, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.worker, nil
+}
+
+func (s *logStore) GetBuildJobs(ctx context.Context, b *brigade.Build) ([]*brigade.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs, nil
+}
+
+func (s *logStore) GetWorkerLogStreamWithOptions(ctx context.Context, w *brigade.Worker, opts LogOptions) (io.ReadCloser, error) {
+	return s.log(w.ID, opts), nil
+}
+
+func (s *logStore) GetJobLogStreamWithOptions(ctx context.Context, j *brigade.Job, opts LogOptions) (io.ReadCloser, error) {
+	return s.log(j.ID, opts), nil
+}
+
+func (s *logStore) log(id string, opts LogOptions) io.ReadCloser {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options = append(s.options, opts)
+	return ioutil.NopCloser(strings.NewReader(s.logs[id]))
+}
+
+func newLogStore() *logStore {
+	return &logStore{
+		worker: &brigade.Worker{ID: "worker-1", Status: brigade.JobRunning},
+		jobs: []*brigade.Job{
+			{ID: "build-1", Name: "build", Status: brigade.JobSucceeded},
+			{ID: "test-1", Name: "test", Status: brigade.JobRunning},
+			{ID: "deploy-1", Name: "deploy", Status: brigade.JobPending},
+		},
+		logs: map[string]string{
+			"worker-1": "2020-06-01T15:00:00.1Z starting\n2020-06-01T15:00:03Z done\n",
+			"build-1":  "2020-06-01T15:00:01Z compiling\n2020-06-01T15:00:02.5Z error: no space left\n",
+			"test-1":   "2020-06-01T15:00:02Z testing",
+			"deploy-1": "2020-06-01T15:00:04Z deploying\n",
+		},
+	}
+}
+
+func readBuildLogs(t *testing.T, s ContextStore, build *brigade.Build, opts BuildLogOptions) string {
+	t.Helper()
+	r, err := BuildLogs(context.Background(), s, build, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBuildLogs(t *testing.T) {
+	s := newLogStore()
+	build := &brigade.Build{ID: "1", Worker: s.worker}
+
+	for _, tt := range []struct {
+		name   string
+		opts   BuildLogOptions
+		expect string
+	}{
+		{
+			name:   "interleaved",
+			expect: "[worker] starting\n[build] compiling\n[test] testing\n[build] error: no space left\n[worker] done\n",
+		},
+		{
+			name:   "grep",
+			opts:   BuildLogOptions{LogOptions: LogOptions{Grep: "^(s|e)"}},
+			expect: "[worker] starting\n[build] error: no space left\n",
+		},
+		{
+			name:   "timestamps",
+			opts:   BuildLogOptions{LogOptions: LogOptions{Timestamps: true, Grep: "ing$"}},
+			expect: "[worker] 2020-06-01T15:00:00.1Z starting\n[build] 2020-06-01T15:00:01Z compiling\n[test] 2020-06-01T15:00:02Z testing\n",
+		},
+		{
+			name:   "color",
+			opts:   BuildLogOptions{Color: true, LogOptions: LogOptions{Grep: "ing$"}},
+			expect: "\x1b[36m[worker]\x1b[0m starting\n\x1b[33m[build]\x1b[0m compiling\n\x1b[32m[test]\x1b[0m testing\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readBuildLogs(t, s, build, tt.opts); got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+
+	for _, opts := range s.options {
+		if opts.Grep != "" || !opts.Timestamps {
+			t.Errorf("expected the stores to return all lines with timestamps, got %+v", opts)
+		}
+	}
+}
+
+func TestBuildLogs_NoWorker(t *testing.T) {
+	if _, err := BuildLogs(context.Background(), newLogStore(), &brigade.Build{ID: "1"}, BuildLogOptions{}); err == nil {
+		t.Error("expected an error for a build without worker")
+	}
+}
+
+func TestBuildLogs_Follow(t *testing.T) {
+	s := newLogStore()
+	s.worker.Status = brigade.JobPending
+	build := &brigade.Build{ID: "1"}
+
+	// Start the worker and the last job once the logs are followed.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.worker = &brigade.Worker{ID: "worker-1", Status: brigade.JobRunning}
+		s.jobs[2] = &brigade.Job{ID: "deploy-1", Name: "deploy", Status: brigade.JobRunning}
+	}()
+
+	got := readBuildLogs(t, s, build, BuildLogOptions{
+		LogOptions:   LogOptions{Follow: true},
+		PollInterval: 5 * time.Millisecond,
+	})
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	sort.Strings(lines)
+	expect := []string{
+		"[build] compiling",
+		"[build] error: no space left",
+		"[deploy] deploying",
+		"[test] testing",
+		"[worker] done",
+		"[worker] starting",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("expected lines %q, got %q", expect, lines)
+	}
+	for _, opts := range s.options {
+		if !opts.Follow {
+			t.Errorf("expected the logs to be followed, got %+v", opts)
+		}
+	}
+}
+
+func TestBuildLogs_Close(t *testing.T) {
+	s := newLogStore()
+	s.worker.Status = brigade.JobPending
+	r, err := BuildLogs(context.Background(), s, &brigade.Build{ID: "1"}, BuildLogOptions{
+		LogOptions:   LogOptions{Follow: true},
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(r)
+		done <- err
+	}()
+	r.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected closing the logs to stop waiting for the worker")
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// WithoutContext adapts a ContextStore to the Store interface. Every call uses
+// the background context.
+func WithoutContext(s ContextStore) Store {
+	return backgroundStore{s}
+}
+
+type backgroundStore struct {
+	s ContextStore
+}
+
+func (b backgroundStore) GetProjects() ([]*brigade.Project, error) {
+	return b.s.GetProjects(context.Background())
+}
+
+func (b backgroundStore) GetProject(id string) (*brigade.Project, error) {
+	return b.s.GetProject(context.Background(), id)
+}
+
+func (b backgroundStore) GetProjectBuilds(proj *brigade.Project) ([]*brigade.Build, error) {
+	return b.s.GetProjectBuilds(context.Background(), proj)
+}
+
+func (b backgroundStore) CreateProject(proj *brigade.Project) error {
+	return b.s.CreateProject(context.Background(), proj)
+}
+
+func (b backgroundStore) ReplaceProject(proj *brigade.Project) error {
+	return b.s.ReplaceProject(context.Background(), proj)
+}
+
+func (b backgroundStore) DeleteProject(id string) error {
+	return b.s.DeleteProject(context.Background(), id)
+}
+
+func (b backgroundStore) GetBuilds() ([]*brigade.Build, error) {
+	return b.s.GetBuilds(context.Background())
+}
+
+func (b backgroundStore) GetBuild(id string) (*brigade.Build, error) {
+	return b.s.GetBuild(context.Background(), id)
+}
+
+func (b backgroundStore) DeleteBuild(id string, options DeleteBuildOptions) error {
+	return b.s.DeleteBuild(context.Background(), id, options)
+}
+
+func (b backgroundStore) CreateBuild(build *brigade.Build) error {
+	return b.s.CreateBuild(context.Background(), build)
+}
+
+func (b backgroundStore) GetBuildEvents(id string) ([]*brigade.BuildEvent, error) {
+	return b.s.GetBuildEvents(context.Background(), id)
+}
+
+func (b backgroundStore) GetBuildJobs(build *brigade.Build) ([]*brigade.Job, error) {
+	return b.s.GetBuildJobs(context.Background(), build)
+}
+
+func (b backgroundStore) GetWorker(buildID string) (*brigade.Worker, error) {
+	return b.s.GetWorker(context.Background(), buildID)
+}
+
+func (b backgroundStore) GetJob(id string) (*brigade.Job, error) {
+	return b.s.GetJob(context.Background(), id)
+}
+
+func (b backgroundStore) GetJobLog(job *brigade.Job) (string, error) {
+	return b.s.GetJobLog(context.Background(), job)
+}
+
+func (b backgroundStore) GetJobLogStream(job *brigade.Job) (io.ReadCloser, error) {
+	return b.s.GetJobLogStream(context.Background(), job)
+}
+
+func (b backgroundStore) GetJobLogStreamFollow(job *brigade.Job) (io.ReadCloser, error) {
+	return b.s.GetJobLogStreamFollow(context.Background(), job)
+}
+
+func (b backgroundStore) GetWorkerInitLog(worker *brigade.Worker) (string, error) {
+	return b.s.GetWorkerInitLog(context.Background(), worker)
+}
+
+func (b backgroundStore) GetWorkerLog(worker *brigade.Worker) (string, error) {
+	return b.s.GetWorkerLog(context.Background(), worker)
+}
+
+func (b backgroundStore) GetWorkerLogStream(worker *brigade.Worker) (io.ReadCloser, error) {
+	return b.s.GetWorkerLogStream(context.Background(), worker)
+}
+
+func (b backgroundStore) GetWorkerLogStreamFollow(worker *brigade.Worker) (io.ReadCloser, error) {
+	return b.s.GetWorkerLogStreamFollow(context.Background(), worker)
+}
+
+func (b backgroundStore) GetStorageClassNames() ([]string, error) {
+	return b.s.GetStorageClassNames(context.Background())
+}
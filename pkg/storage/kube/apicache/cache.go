@@ -2,6 +2,7 @@ package apicache
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -23,15 +24,25 @@ type APICache interface {
 	GetSecretsFilteredBy(labelSelectors map[string]string) ([]v1.Secret, error)
 	// get cached pods filtered by label selectors k/v pairs
 	GetPodsFilteredBy(labelSelectors map[string]string) ([]v1.Pod, error)
+	// get a cached pod by name, the bool is false if the pod is not cached
+	GetPod(name string) (*v1.Pod, bool, error)
+	// add a secret that was just created, so that it is returned before the watch delivers it
+	AddSecret(secret *v1.Secret)
 }
 
 type apiCache struct {
 	// the kubernetes client
 	client kubernetes.Interface
-	// a ready to use cache.Store for secrets
-	secretStore cache.Store
-	// a ready to use cache.Store for pods
-	podStore cache.Store
+	// the kubernetes namespace to cache
+	namespace string
+	// how often to re-sync
+	resyncPeriod time.Duration
+	// starts the informers the first time the cache is used
+	start sync.Once
+	// a ready to use cache.Indexer for secrets
+	secretStore cache.Indexer
+	// a ready to use cache.Indexer for pods
+	podStore cache.Indexer
 	// a chan which is going to be closed after the APICache has initially synced all cache.Store's
 	hasSyncedInitially <-chan struct{}
 }
@@ -43,6 +54,8 @@ type storeConfig struct {
 	namespace string
 	// how often to re-sync
 	resyncPeriod time.Duration
+	// the label selector of the cached objects, all objects if empty
+	labelSelector string
 	// which type to expect when new synced objects arrive
 	expectedType runtime.Object
 	// implement the method invokind the kubernetes.Interface to return
@@ -53,24 +66,51 @@ type storeConfig struct {
 	watchFunc func(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (watch.Interface, error)
 }
 
-// New returns a new APICache
+// brigadeSelector restricts the cache to the objects managed by Brigade.
+const brigadeSelector = "heritage=brigade"
+
+// New returns a new APICache. The cache starts watching the namespace the
+// first time it is used, so that creating an APICache is cheap.
 func New(client kubernetes.Interface, namespace string, resyncPeriod time.Duration) APICache {
+	return &apiCache{
+		client:       client,
+		namespace:    namespace,
+		resyncPeriod: resyncPeriod,
+	}
+}
 
-	secretsSynced := make(chan struct{})
-	podsSynced := make(chan struct{})
+// run starts the informers unless they are already running.
+func (a *apiCache) run() {
+	a.start.Do(func() {
+		if a.secretStore != nil && a.podStore != nil {
+			return
+		}
+		secretsSynced := make(chan struct{})
+		podsSynced := make(chan struct{})
+		secretConfig := secretStoreConfig(a.namespace, a.resyncPeriod)
+		secretConfig.labelSelector = brigadeSelector
+		podConfig := podStoreConfig(a.namespace, a.resyncPeriod)
+		podConfig.labelSelector = brigadeSelector
 
-	return &apiCache{
-		client:             client,
-		hasSyncedInitially: merge.Channels(secretsSynced, podsSynced),
-		secretStore:        newSecretStore(client, namespace, resyncPeriod, secretsSynced),
-		podStore:           newPodStore(client, namespace, resyncPeriod, podsSynced),
+		a.hasSyncedInitially = merge.Channels(secretsSynced, podsSynced)
+		a.secretStore = newListStore(a.client, secretConfig, secretsSynced)
+		a.podStore = newListStore(a.client, podConfig, podsSynced)
+	})
+}
+
+// key returns the cache key of the object with the given name.
+func (a *apiCache) key(name string) string {
+	if a.namespace == "" {
+		return name
 	}
+	return a.namespace + "/" + name
 }
 
 // blockUntilAPICacheSynced blocks until all cache.Store's are synced
 // returns nil if all channels are closed
 // returns error if the timeout was reached (in case timeout > 0)
 func (a *apiCache) blockUntilAPICacheSynced(timeout time.Duration) error {
+	a.run()
 	if timeout < 0 {
 		<-a.hasSyncedInitially
 		return nil
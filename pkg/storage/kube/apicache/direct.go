@@ -0,0 +1,62 @@
+package apicache
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// directCache is an APICache that reads every object from the kubernetes api
+// instead of a cache.
+type directCache struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewDirect returns an APICache that does not cache anything, for callers that
+// must see the latest state of the objects, like the snapshots of completed
+// builds, and cannot afford the lag of a watch.
+func NewDirect(client kubernetes.Interface, namespace string) APICache {
+	return &directCache{client: client, namespace: namespace}
+}
+
+// GetSecretsFilteredBy lists the secrets with the given labels.
+func (d *directCache) GetSecretsFilteredBy(selectors map[string]string) ([]v1.Secret, error) {
+	list, err := d.client.CoreV1().Secrets(d.namespace).List(context.TODO(), d.listOptions(selectors))
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// GetPodsFilteredBy lists the pods with the given labels.
+func (d *directCache) GetPodsFilteredBy(selectors map[string]string) ([]v1.Pod, error) {
+	list, err := d.client.CoreV1().Pods(d.namespace).List(context.TODO(), d.listOptions(selectors))
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// GetPod gets a pod by name. It returns false if the pod does not exist.
+func (d *directCache) GetPod(name string) (*v1.Pod, bool, error) {
+	pod, err := d.client.CoreV1().Pods(d.namespace).Get(context.TODO(), name, metaV1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return pod, true, nil
+}
+
+// AddSecret does nothing, as created secrets are listed by the api.
+func (d *directCache) AddSecret(secret *v1.Secret) {}
+
+func (d *directCache) listOptions(selectors map[string]string) metaV1.ListOptions {
+	return metaV1.ListOptions{LabelSelector: labels.Set(selectors).AsSelector().String()}
+}
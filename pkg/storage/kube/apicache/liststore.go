@@ -1,6 +1,9 @@
 package apicache
 
 import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
@@ -8,19 +11,40 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
+// The cached objects are indexed by these labels.
+const (
+	buildIndex     = "build"
+	projectIndex   = "project"
+	componentIndex = "component"
+)
+
+// labelIndexes lists the indexes from the most to the least selective.
+var labelIndexes = []string{buildIndex, projectIndex, componentIndex}
+
 // convenience method to create listStores
-func newListStore(client kubernetes.Interface, config storeConfig, hasSynced chan struct{}) cache.Store {
+func newListStore(client kubernetes.Interface, config storeConfig, hasSynced chan struct{}) cache.Indexer {
 
 	listWatch := cache.ListWatch{
 		ListFunc: func(options metaV1.ListOptions) (runtime.Object, error) {
+			if config.labelSelector != "" {
+				options.LabelSelector = config.labelSelector
+			}
 			return config.listFunc(client, config.namespace, options)
 		},
 		WatchFunc: func(options metaV1.ListOptions) (watch.Interface, error) {
+			if config.labelSelector != "" {
+				options.LabelSelector = config.labelSelector
+			}
 			return config.watchFunc(client, config.namespace, options)
 		},
 	}
 
-	store, ctr := cache.NewInformer(
+	indexers := cache.Indexers{}
+	for _, label := range labelIndexes {
+		indexers[label] = labelIndexFunc(label)
+	}
+
+	store, ctr := cache.NewIndexerInformer(
 		&listWatch,
 		config.expectedType,
 		config.resyncPeriod,
@@ -28,31 +52,53 @@ func newListStore(client kubernetes.Interface, config storeConfig, hasSynced cha
 			AddFunc:    func(obj interface{}) {},
 			UpdateFunc: func(oldObj, newObj interface{}) {},
 			DeleteFunc: func(obj interface{}) {},
-		})
+		},
+		indexers)
 
 	// run the controller in a new goroutine, else this operation would block
 	// we currently don't supply a close chan as there is no need to
 	// this might change
 	go ctr.Run(nil)
 
-	// loop until the store is in sync, then close the chan to signal
-	go func(ctr cache.Controller) {
-		for {
-			if hasSynced == nil {
-				break
+	// wait until the store is in sync, then close the chan to signal
+	if hasSynced != nil {
+		go func(ctr cache.Controller) {
+			for !ctr.HasSynced() {
+				time.Sleep(10 * time.Millisecond)
 			}
+			// if this panics you might unintentionally closed this channel from outside
+			// please fix your code as this behavior is not not expected
+			close(hasSynced)
+		}(ctr)
+	}
 
-			if ctr.HasSynced() {
-				// if this panics you might unintentionally closed this channel from outside
-				// please fix your code as this behavior is not not expected
-				close(hasSynced)
-				hasSynced = nil
-				break
-			}
+	return store
+}
+
+// labelIndexFunc indexes objects by the value of a label.
+func labelIndexFunc(label string) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			// Not a kubernetes object, there is nothing to index.
+			return nil, nil
+		}
+		if v, ok := m.GetLabels()[label]; ok {
+			return []string{v}, nil
 		}
-	}(ctr)
+		return nil, nil
+	}
+}
 
-	return store
+// filterByLabels returns the objects of the indexer matching the selectors,
+// looking them up in the most selective index available.
+func filterByLabels(indexer cache.Indexer, selectors map[string]string) ([]interface{}, error) {
+	for _, index := range labelIndexes {
+		if v, ok := selectors[index]; ok {
+			return indexer.ByIndex(index, v)
+		}
+	}
+	return indexer.List(), nil
 }
 
 // returns true under these conditions:
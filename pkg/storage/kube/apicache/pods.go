@@ -12,9 +12,13 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
-// return a new cached store for secrets
-func newPodStore(client kubernetes.Interface, namespace string, resyncPeriod time.Duration, synced chan struct{}) cache.Store {
-	return newListStore(client, storeConfig{
+// return a new cached store for pods
+func newPodStore(client kubernetes.Interface, namespace string, resyncPeriod time.Duration, synced chan struct{}) cache.Indexer {
+	return newListStore(client, podStoreConfig(namespace, resyncPeriod), synced)
+}
+
+func podStoreConfig(namespace string, resyncPeriod time.Duration) storeConfig {
+	return storeConfig{
 		resource:     "pods",
 		namespace:    namespace,
 		resyncPeriod: resyncPeriod,
@@ -25,7 +29,21 @@ func newPodStore(client kubernetes.Interface, namespace string, resyncPeriod tim
 		watchFunc: func(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (watch.Interface, error) {
 			return client.CoreV1().Pods(namespace).Watch(context.TODO(), options)
 		},
-	}, synced)
+	}
+}
+
+// GetPod returns a cached pod by name. It returns false if the pod is not in
+// the cache, which happens for pods that were just created.
+func (a *apiCache) GetPod(name string) (*v1.Pod, bool, error) {
+	if err := a.blockUntilAPICacheSynced(defaultCacheSyncTimeout); err != nil {
+		return nil, false, err
+	}
+	raw, exists, err := a.podStore.GetByKey(a.key(name))
+	if err != nil || !exists {
+		return nil, false, err
+	}
+	pod, ok := raw.(*v1.Pod)
+	return pod, ok, nil
 }
 
 // GetPodsFilteredBy returns all pods filtered by a label selector
@@ -42,7 +60,11 @@ func (a *apiCache) GetPodsFilteredBy(selectors map[string]string) ([]v1.Pod, err
 		return filteredPods, err
 	}
 
-	for _, raw := range a.podStore.List() {
+	cached, err := filterByLabels(a.podStore, selectors)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range cached {
 
 		secret, ok := raw.(*v1.Pod)
 		if !ok {
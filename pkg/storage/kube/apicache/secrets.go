@@ -14,8 +14,12 @@ import (
 )
 
 // return a new cached store for secrets
-func newSecretStore(client kubernetes.Interface, namespace string, resyncPeriod time.Duration, synced chan struct{}) cache.Store {
-	return newListStore(client, storeConfig{
+func newSecretStore(client kubernetes.Interface, namespace string, resyncPeriod time.Duration, synced chan struct{}) cache.Indexer {
+	return newListStore(client, secretStoreConfig(namespace, resyncPeriod), synced)
+}
+
+func secretStoreConfig(namespace string, resyncPeriod time.Duration) storeConfig {
+	return storeConfig{
 		resource:     "secrets",
 		namespace:    namespace,
 		resyncPeriod: resyncPeriod,
@@ -26,7 +30,7 @@ func newSecretStore(client kubernetes.Interface, namespace string, resyncPeriod
 		watchFunc: func(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (watch.Interface, error) {
 			return client.CoreV1().Secrets(namespace).Watch(context.TODO(), options)
 		},
-	}, synced)
+	}
 }
 
 // GetSecretsFilteredBy returns all secrets filtered by a label selector
@@ -43,7 +47,11 @@ func (a *apiCache) GetSecretsFilteredBy(selectors map[string]string) ([]v1.Secre
 		return filteredSecrets, err
 	}
 
-	for _, raw := range a.secretStore.List() {
+	cached, err := filterByLabels(a.secretStore, selectors)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range cached {
 
 		secret, ok := raw.(*v1.Secret)
 		if !ok {
@@ -61,6 +69,17 @@ func (a *apiCache) GetSecretsFilteredBy(selectors map[string]string) ([]v1.Secre
 	return filteredSecrets, nil
 }
 
+// AddSecret adds a secret that was just created to the cache, so that it is
+// listed before the informer receives it. Secrets already in the cache are
+// left untouched as they may be more recent.
+func (a *apiCache) AddSecret(secret *v1.Secret) {
+	a.run()
+	if _, exists, _ := a.secretStore.GetByKey(a.key(secret.Name)); exists {
+		return
+	}
+	a.secretStore.Add(secret.DeepCopy())
+}
+
 // ByCreation sorts secrets by their creation timestamp.
 type ByCreation []v1.Secret
 
@@ -0,0 +1,163 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// The benchmarks compare the lookups through the informer cache to listing
+// the pods and secrets through the API, as the store used to do.
+
+const benchmarkBuilds = 500
+
+// benchmarkStore returns a store for a namespace with many builds, each with
+// a worker and two jobs, and the ID of the last build.
+func benchmarkStore(b *testing.B) (kubernetes.Interface, storage.ContextStore, string) {
+	var objects []runtime.Object
+	var bid string
+	for i := 0; i < benchmarkBuilds; i++ {
+		bid = fmt.Sprintf("build%04d", i)
+		labels := func(component string) map[string]string {
+			return map[string]string{"heritage": "brigade", "component": component, "build": bid, "project": stubProjectID}
+		}
+		objects = append(objects, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "brigade-worker-" + bid, Namespace: "default", Labels: labels("build")},
+		})
+		for _, name := range []string{"brigade-worker-" + bid, "test-" + bid, "lint-" + bid} {
+			component := "job"
+			if name == "brigade-worker-"+bid {
+				component = "build"
+			}
+			objects = append(objects, &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels(component)},
+				Spec:       v1.PodSpec{Containers: []v1.Container{{Image: "alpine:3.7"}}},
+				Status:     v1.PodStatus{Phase: v1.PodPending},
+			})
+		}
+	}
+	client := fake.NewSimpleClientset(objects...)
+	s := New(client, "default")
+	// Sync the cache outside of the measurements.
+	if _, err := s.GetBuilds(context.TODO()); err != nil {
+		b.Fatal(err)
+	}
+	return client, s, bid
+}
+
+func BenchmarkGetJob(b *testing.B) {
+	client, s, bid := benchmarkStore(b)
+	id := "test-" + bid
+
+	b.Run("cache", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetJob(context.TODO(), id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("list", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pods, err := client.CoreV1().Pods("default").List(context.TODO(), metav1.ListOptions{LabelSelector: "heritage=brigade"})
+			if err != nil {
+				b.Fatal(err)
+			}
+			for j := range pods.Items {
+				if pods.Items[j].Name == id {
+					NewJobFromPod(pods.Items[j])
+					break
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkGetBuild(b *testing.B) {
+	client, s, bid := benchmarkStore(b)
+
+	b.Run("cache", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetBuild(context.TODO(), bid); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("list", func(b *testing.B) {
+		selector := "heritage=brigade,component=build,build=" + bid
+		for i := 0; i < b.N; i++ {
+			secrets, err := client.CoreV1().Secrets("default").List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				b.Fatal(err)
+			}
+			pods, err := client.CoreV1().Pods("default").List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				b.Fatal(err)
+			}
+			build := NewBuildFromSecret(secrets.Items[0])
+			build.Worker = NewWorkerFromPod(pods.Items[0])
+		}
+	})
+}
+
+func BenchmarkGetBuildJobs(b *testing.B) {
+	client, s, bid := benchmarkStore(b)
+	build := &brigade.Build{ID: bid, ProjectID: stubProjectID}
+
+	b.Run("cache", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetBuildJobs(context.TODO(), build); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("list", func(b *testing.B) {
+		selector := fmt.Sprintf("heritage=brigade,component=job,build=%s,project=%s", bid, stubProjectID)
+		for i := 0; i < b.N; i++ {
+			pods, err := client.CoreV1().Pods("default").List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				b.Fatal(err)
+			}
+			for j := range pods.Items {
+				NewJobFromPod(pods.Items[j])
+			}
+		}
+	})
+}
+
+func BenchmarkGetBuilds(b *testing.B) {
+	client, s, _ := benchmarkStore(b)
+
+	b.Run("cache", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetBuilds(context.TODO()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("list", func(b *testing.B) {
+		lo := metav1.ListOptions{LabelSelector: "heritage=brigade,component=build"}
+		for i := 0; i < b.N; i++ {
+			secrets, err := client.CoreV1().Secrets("default").List(context.TODO(), lo)
+			if err != nil {
+				b.Fatal(err)
+			}
+			pods, err := client.CoreV1().Pods("default").List(context.TODO(), lo)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for j := range secrets.Items {
+				build := NewBuildFromSecret(secrets.Items[j])
+				build.Worker, _ = findWorker(build.ID, pods.Items)
+			}
+		}
+	})
+}
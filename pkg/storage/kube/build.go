@@ -2,9 +2,11 @@ package kube
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"math/rand"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,39 +15,65 @@ import (
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/tracing"
 )
 
 const secretTypeBuild = "brigade.sh/build"
 
-const jobFilter = "component in (build, job), heritage = brigade, build = %s"
+// RetriedByAnnotation is set on a build secret by the controller when it
+// retries the build. Its value is the ID of the new build.
+const RetriedByAnnotation = "retriedBy"
+
+// UploadTokenKey is the key of the build secret the controller keeps the
+// upload token of the build in.
+const UploadTokenKey = "upload_token"
+
+// buildResourcesFilter selects everything labelled with a build: the build
+// and job secrets, the worker and job pods and the build storage PVC.
+const buildResourcesFilter = "heritage = brigade, build = %s"
+
+// BuildResourcesSelector returns the label selector of the Kubernetes
+// resources that belong to a build.
+func BuildResourcesSelector(bid string) string {
+	return fmt.Sprintf(buildResourcesFilter, bid)
+}
 
 // GetBuild returns the build.
-func (s *store) GetBuild(id string) (*brigade.Build, error) {
+func (s *store) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
 	build := &brigade.Build{ID: id}
 
-	labels := fmt.Sprint("heritage=brigade,component=build,build=", build.ID)
-	listOption := meta.ListOptions{LabelSelector: labels}
-	secrets, err := s.client.CoreV1().Secrets(s.namespace).List(context.TODO(), listOption)
-	if err != nil {
-		return nil, err
+	labels := map[string]string{"heritage": "brigade", "component": "build", "build": build.ID}
+	secrets, err := s.apiCache.GetSecretsFilteredBy(labels)
+	if len(secrets) < 1 || err != nil {
+		// The build may have been created after the cache was synced.
+		listOption := meta.ListOptions{LabelSelector: fmt.Sprint("heritage=brigade,component=build,build=", build.ID)}
+		list, err := s.client.CoreV1().Secrets(s.namespace).List(ctx, listOption)
+		if err != nil {
+			return nil, err
+		}
+		secrets = list.Items
 	}
-	if len(secrets.Items) < 1 {
-		return nil, fmt.Errorf("could not find build %s: no secrets exist with labels %s", id, labels)
+	if len(secrets) < 1 {
+		return nil, fmt.Errorf("could not find build %s: no secrets exist with labels heritage=brigade,component=build,build=%s", id, id)
 	}
 	// Select the first secret as the build IDs are unique
-	b := NewBuildFromSecret(secrets.Items[0])
-	b.Worker, err = s.GetWorker(build.ID)
+	b := NewBuildFromSecret(secrets[0])
+	b.Worker, err = s.GetWorker(ctx, build.ID)
 	return b, err
 }
 
 // DeleteBuild deletes a build.
-func (s *store) DeleteBuild(bid string, options storage.DeleteBuildOptions) error {
+//
+// It deletes the pods, the secrets and the persistent volume claims labelled
+// with the build.
+func (s *store) DeleteBuild(ctx context.Context, bid string, options storage.DeleteBuildOptions) error {
 	opts := meta.ListOptions{
-		LabelSelector: fmt.Sprintf(jobFilter, bid),
+		LabelSelector: BuildResourcesSelector(bid),
 	}
 	delOpts := meta.NewDeleteOptions(0)
-	pods, err := s.client.CoreV1().Pods(s.namespace).List(context.TODO(), opts)
+	pods, err := s.client.CoreV1().Pods(s.namespace).List(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -53,45 +81,68 @@ func (s *store) DeleteBuild(bid string, options storage.DeleteBuildOptions) erro
 		for _, p := range pods.Items {
 			if p.Labels["component"] == "build" {
 				if p.Status.Phase == v1.PodRunning || p.Status.Phase == v1.PodPending {
-					log.Printf("skipping Build %s because its Status is %s", p.Labels["build"], p.Status.Phase)
+					logging.Infof("skipping Build %s because its Status is %s", p.Labels["build"], p.Status.Phase)
 					return nil
 				}
 			}
 		}
 	}
 	for _, p := range pods.Items {
-		log.Printf("Deleting pod %q", p.Name)
-		if err := s.client.CoreV1().Pods(s.namespace).Delete(context.TODO(), p.Name, *delOpts); err != nil {
-			log.Printf("failed to delete job pod %s (continuing): %s", p.Name, err)
+		logging.Infof("Deleting pod %q", p.Name)
+		if err := s.client.CoreV1().Pods(s.namespace).Delete(ctx, p.Name, *delOpts); err != nil {
+			logging.Errorf("failed to delete job pod %s (continuing): %s", p.Name, err)
 		}
 	}
 
-	secrets, err := s.client.CoreV1().Secrets(s.namespace).List(context.TODO(), opts)
+	secrets, err := s.client.CoreV1().Secrets(s.namespace).List(ctx, opts)
 	if err != nil {
 		return err
 	}
 	for _, sec := range secrets.Items {
-		log.Printf("Deleting secret %q", sec.Name)
-		if err := s.client.CoreV1().Secrets(s.namespace).Delete(context.TODO(), sec.Name, *delOpts); err != nil {
-			log.Printf("failed to delete job secret %s (continuing): %s", sec.Name, err)
+		logging.Infof("Deleting secret %q", sec.Name)
+		if err := s.client.CoreV1().Secrets(s.namespace).Delete(ctx, sec.Name, *delOpts); err != nil {
+			logging.Errorf("failed to delete job secret %s (continuing): %s", sec.Name, err)
 		}
 	}
 
-	// As a safety condition, we might also consider deleting PVCs.
+	// The worker normally deletes the build storage when it exits, but not if
+	// it crashed or was deleted.
+	pvcs, err := s.client.CoreV1().PersistentVolumeClaims(s.namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, pvc := range pvcs.Items {
+		logging.Infof("Deleting persistent volume claim %q", pvc.Name)
+		if err := s.client.CoreV1().PersistentVolumeClaims(s.namespace).Delete(ctx, pvc.Name, *delOpts); err != nil {
+			logging.Errorf("failed to delete build storage %s (continuing): %s", pvc.Name, err)
+		}
+	}
 	return nil
 }
 
 // CreateBuild creates a new Secret based on the build options and writes it to storage.
-func (s *store) CreateBuild(build *brigade.Build) error {
+//
+// The secret is annotated with the trace context of ctx, so that the worker
+// continues the trace of the request that created the build.
+func (s *store) CreateBuild(ctx context.Context, build *brigade.Build) error {
 	if build.ID == "" {
 		build.ID = genID()
 	}
 
 	buildName := fmt.Sprintf("brigade-worker-%s", build.ID)
 
+	var chain []byte
+	if len(build.TriggerChain) > 0 {
+		var err error
+		if chain, err = json.Marshal(build.TriggerChain); err != nil {
+			return err
+		}
+	}
+
 	secret := v1.Secret{
 		ObjectMeta: meta.ObjectMeta{
-			Name: buildName,
+			Name:        buildName,
+			Annotations: tracing.Annotations(ctx),
 			Labels: map[string]string{
 				"build":     build.ID,
 				"component": "build",
@@ -117,40 +168,50 @@ func (s *store) CreateBuild(build *brigade.Build) error {
 			"event_type":     build.Type,
 			"project_id":     build.ProjectID,
 			"log_level":      build.LogLevel,
+			"trigger_chain":  string(chain),
 		},
 	}
 
-	_, err := s.client.CoreV1().Secrets(s.namespace).Create(context.TODO(), &secret, meta.CreateOptions{})
-	return err
+	created, err := s.client.CoreV1().Secrets(s.namespace).Create(ctx, &secret, meta.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	s.apiCache.AddSecret(created)
+	return nil
 }
 
 // GetBuilds returns all the builds in storage.
-func (s *store) GetBuilds() ([]*brigade.Build, error) {
-	lo := meta.ListOptions{LabelSelector: "heritage=brigade,component=build"}
+func (s *store) GetBuilds(ctx context.Context) ([]*brigade.Build, error) {
+	labels := map[string]string{"heritage": "brigade", "component": "build"}
 
-	secretList, err := s.client.CoreV1().Secrets(s.namespace).List(context.TODO(), lo)
+	secrets, err := s.apiCache.GetSecretsFilteredBy(labels)
 	if err != nil {
 		return nil, err
 	}
-
-	podList, err := s.client.CoreV1().Pods(s.namespace).List(context.TODO(), lo)
+	pods, err := s.apiCache.GetPodsFilteredBy(labels)
 	if err != nil {
 		return nil, err
 	}
+	workers := make(map[string]*v1.Pod, len(pods))
+	for i := range pods {
+		workers[pods[i].Labels["build"]] = &pods[i]
+	}
 
-	buildList := make([]*brigade.Build, len(secretList.Items))
-	for i := range secretList.Items {
-		b := NewBuildFromSecret(secretList.Items[i])
-		// The error is ErrWorkerNotFound, and in that case, we just ignore
-		// it and assign nil to the worker.
-		b.Worker, _ = findWorker(b.ID, podList.Items)
+	// List the builds in the order of the API, the oldest first.
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	buildList := make([]*brigade.Build, len(secrets))
+	for i := range secrets {
+		b := NewBuildFromSecret(secrets[i])
+		if pod, ok := workers[b.ID]; ok {
+			b.Worker = NewWorkerFromPod(*pod)
+		}
 		buildList[i] = b
 	}
 	return buildList, nil
 }
 
 // GetProjectBuilds returns all the builds for the given project.
-func (s *store) GetProjectBuilds(proj *brigade.Project) ([]*brigade.Build, error) {
+func (s *store) GetProjectBuilds(ctx context.Context, proj *brigade.Project) ([]*brigade.Build, error) {
 
 	// Load the pods that ran as part of this build.
 	labelSelectorMap := map[string]string{
@@ -201,6 +262,13 @@ func findWorker(id string, pods []v1.Pod) (*brigade.Worker, bool) {
 func NewBuildFromSecret(secret v1.Secret) *brigade.Build {
 	lbs := secret.ObjectMeta.Labels
 	sv := SecretValues(secret.Data)
+	// The first attempt of a build does not record its attempt number.
+	attempt, _ := strconv.Atoi(sv.String("attempt"))
+	var chain []brigade.TriggerLink
+	if d := sv.Bytes("trigger_chain"); len(d) > 0 {
+		// A chain that cannot be read is dropped, it is only informative.
+		json.Unmarshal(d, &chain)
+	}
 	return &brigade.Build{
 		ID:         lbs["build"],
 		ProjectID:  lbs["project"],
@@ -213,8 +281,14 @@ func NewBuildFromSecret(secret v1.Secret) *brigade.Build {
 			Commit: sv.String("commit_id"),
 			Ref:    sv.String("commit_ref"),
 		},
-		Payload: sv.Bytes("payload"),
-		Script:  sv.Bytes("script"),
+		Payload:   sv.Bytes("payload"),
+		Script:    sv.Bytes("script"),
+		Attempt:   attempt,
+		RetryOf:   sv.String("retry_of"),
+		RetriedBy: secret.Annotations[RetriedByAnnotation],
+
+		TriggerChain: chain,
+		UploadToken:  sv.String(UploadTokenKey),
 	}
 }
 
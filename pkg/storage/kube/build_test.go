@@ -9,6 +9,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
 )
@@ -41,7 +42,7 @@ func TestNewBuildFromSecret(t *testing.T) {
 
 func TestCreateBuild(t *testing.T) {
 	k, s := fakeStore()
-	if err := s.CreateBuild(stubBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
 		t.Fatal(err)
 	}
 
@@ -53,7 +54,7 @@ func TestCreateBuild(t *testing.T) {
 
 func TestDeleteBuild(t *testing.T) {
 	k, s := fakeStore()
-	if err := s.CreateBuild(stubBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
 		t.Fatal(err)
 	}
 
@@ -62,7 +63,7 @@ func TestDeleteBuild(t *testing.T) {
 		t.Fatalf("Build was not stored as secret")
 	}
 
-	if err := s.DeleteBuild(stubBuild.ID, storage.DeleteBuildOptions{SkipRunningBuilds: true}); err != nil {
+	if err := s.DeleteBuild(context.TODO(), stubBuild.ID, storage.DeleteBuildOptions{SkipRunningBuilds: true}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -72,14 +73,57 @@ func TestDeleteBuild(t *testing.T) {
 	}
 }
 
+func TestDeleteBuild_Resources(t *testing.T) {
+	labels := func(component, build string) map[string]string {
+		l := map[string]string{"heritage": "brigade", "component": component, "project": stubProjectID}
+		if build != "" {
+			l["build"] = build
+		}
+		return l
+	}
+	pvc := func(name string, labels map[string]string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	}
+	secret := func(name string, labels map[string]string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	}
+	k := fake.NewSimpleClientset(
+		secret("brigade-worker-b1", labels("build", "b1")),
+		secret("b1-job", labels("job", "b1")),
+		secret("brigade-worker-b2", labels("build", "b2")),
+		pvc("brigade-worker-b1", labels("buildStorage", "b1")),
+		pvc("brigade-worker-b2", labels("buildStorage", "b2")),
+		pvc("project-cache", labels("jobCache", "")),
+	)
+	s := New(k, "default")
+
+	if err := s.DeleteBuild(context.TODO(), "b1", storage.DeleteBuildOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	secrets, _ := k.CoreV1().Secrets("default").List(context.TODO(), metav1.ListOptions{})
+	if len(secrets.Items) != 1 || secrets.Items[0].Name != "brigade-worker-b2" {
+		t.Errorf("expected only the secret of the other build to be left, got %v", secrets.Items)
+	}
+	pvcs, _ := k.CoreV1().PersistentVolumeClaims("default").List(context.TODO(), metav1.ListOptions{})
+	if len(pvcs.Items) != 2 {
+		t.Fatalf("expected 2 PVCs to be left, got %d", len(pvcs.Items))
+	}
+	for _, p := range pvcs.Items {
+		if p.Name == "brigade-worker-b1" {
+			t.Error("expected the build storage to be deleted")
+		}
+	}
+}
+
 func TestGetBuild(t *testing.T) {
 	k, s := fakeStore()
 	createFakeWorker(k, stubWorkerPod)
-	if err := s.CreateBuild(stubBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
 		t.Fatal(err)
 	}
 
-	b, err := s.GetBuild(stubBuild.ID)
+	b, err := s.GetBuild(context.TODO(), stubBuild.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -106,7 +150,7 @@ func TestGetBuild(t *testing.T) {
 func TestGetBuilds(t *testing.T) {
 	k, s := fakeStore()
 	createFakeWorker(k, stubWorkerPod)
-	if err := s.CreateBuild(stubBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
 		t.Fatal(err)
 	}
 
@@ -119,7 +163,7 @@ func TestGetBuilds(t *testing.T) {
 		Provider:  "mock",
 		Revision:  &brigade.Revision{Ref: "heads/refs/master"},
 	}
-	if err := s.CreateBuild(secondBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), secondBuild); err != nil {
 		t.Fatal(err)
 	}
 	w2 := v1.Pod{
@@ -150,7 +194,7 @@ func TestGetBuilds(t *testing.T) {
 	}
 	createFakeWorker(k, w2)
 
-	builds, err := s.GetBuilds()
+	builds, err := s.GetBuilds(context.TODO())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -167,7 +211,7 @@ func TestGetProjectBuilds(t *testing.T) {
 
 	k, s := fakeStore()
 	createFakeWorker(k, stubWorkerPod)
-	if err := s.CreateBuild(stubBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
 		t.Fatal(err)
 	}
 
@@ -180,7 +224,7 @@ func TestGetProjectBuilds(t *testing.T) {
 		Provider:  "mock",
 		Revision:  &brigade.Revision{Ref: "heads/refs/master"},
 	}
-	if err := s.CreateBuild(secondBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), secondBuild); err != nil {
 		t.Fatal(err)
 	}
 	w2 := v1.Pod{
@@ -215,7 +259,7 @@ func TestGetProjectBuilds(t *testing.T) {
 		ID: stubProjectID,
 	}
 
-	builds, err := s.GetProjectBuilds(proj)
+	builds, err := s.GetProjectBuilds(context.TODO(), proj)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -224,3 +268,22 @@ func TestGetProjectBuilds(t *testing.T) {
 		t.Fatalf("expected 2 builds, got %d", l)
 	}
 }
+
+func TestGetBuilds_JustCreated(t *testing.T) {
+	_, s := fakeStore()
+	// Sync the cache before the build exists.
+	if builds, err := s.GetBuilds(context.TODO()); err != nil || len(builds) != 0 {
+		t.Fatalf("expected no builds, got %v (%v)", builds, err)
+	}
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
+		t.Fatal(err)
+	}
+
+	builds, err := s.GetBuilds(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 1 || builds[0].ID != stubBuild.ID {
+		t.Fatalf("expected build %s, got %v", stubBuild.ID, builds)
+	}
+}
@@ -0,0 +1,50 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+// GetBuildEvents returns the events recorded for the build secret and the
+// worker pod of a build, oldest first.
+func (s *store) GetBuildEvents(ctx context.Context, bid string) ([]*brigade.BuildEvent, error) {
+	// The build secret and the worker pod share the same name.
+	name := fmt.Sprintf("brigade-worker-%s", bid)
+	selector := fields.OneTermEqualSelector("involvedObject.name", name).String()
+	list, err := s.client.CoreV1().Events(s.namespace).List(ctx, meta.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*brigade.BuildEvent{}
+	for _, e := range list.Items {
+		if e.InvolvedObject.Name != name {
+			continue
+		}
+		events = append(events, NewBuildEventFromEvent(e))
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastTime.Before(events[j].LastTime)
+	})
+	return events, nil
+}
+
+// NewBuildEventFromEvent creates a BuildEvent from a Kubernetes Event.
+func NewBuildEventFromEvent(e v1.Event) *brigade.BuildEvent {
+	return &brigade.BuildEvent{
+		Type:      e.Type,
+		Reason:    e.Reason,
+		Message:   e.Message,
+		Object:    fmt.Sprintf("%s/%s", e.InvolvedObject.Kind, e.InvolvedObject.Name),
+		Count:     e.Count,
+		FirstTime: e.FirstTimestamp.Time,
+		LastTime:  e.LastTimestamp.Time,
+	}
+}
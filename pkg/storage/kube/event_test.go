@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetBuildEvents(t *testing.T) {
+	k, s := fakeStore()
+	now := time.Now()
+
+	events := []v1.Event{
+		{
+			ObjectMeta:     meta.ObjectMeta{Name: "accepted", Namespace: "default"},
+			InvolvedObject: v1.ObjectReference{Kind: "Secret", Name: "brigade-worker-" + stubBuildID},
+			Type:           v1.EventTypeNormal,
+			Reason:         "BuildAccepted",
+			Message:        "Build accepted",
+			Count:          1,
+			FirstTimestamp: meta.NewTime(now.Add(-time.Minute)),
+			LastTimestamp:  meta.NewTime(now.Add(-time.Minute)),
+		},
+		{
+			ObjectMeta:     meta.ObjectMeta{Name: "created", Namespace: "default"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "brigade-worker-" + stubBuildID},
+			Type:           v1.EventTypeNormal,
+			Reason:         "WorkerCreated",
+			Message:        "Created worker",
+			Count:          2,
+			FirstTimestamp: meta.NewTime(now.Add(-2 * time.Minute)),
+			LastTimestamp:  meta.NewTime(now.Add(-2 * time.Minute)),
+		},
+		{
+			ObjectMeta:     meta.ObjectMeta{Name: "other", Namespace: "default"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "brigade-worker-other"},
+			Type:           v1.EventTypeWarning,
+			Reason:         "BuildCancelled",
+		},
+	}
+	for i := range events {
+		if _, err := k.CoreV1().Events("default").Create(context.TODO(), &events[i], meta.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := s.GetBuildEvents(context.TODO(), stubBuildID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(got))
+	}
+	if got[0].Reason != "WorkerCreated" || got[0].Object != "Pod/brigade-worker-"+stubBuildID || got[0].Count != 2 {
+		t.Errorf("unexpected first event %+v", got[0])
+	}
+	if got[1].Reason != "BuildAccepted" || got[1].Object != "Secret/brigade-worker-"+stubBuildID {
+		t.Errorf("unexpected second event %+v", got[1])
+	}
+}
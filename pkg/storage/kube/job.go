@@ -5,60 +5,66 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"math"
+	"sort"
 
 	v1 "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
 )
 
-func (s *store) GetJob(id string) (*brigade.Job, error) {
-	labels := labels.Set{"heritage": "brigade"}
-	listOption := meta.ListOptions{LabelSelector: labels.AsSelector().String()}
-	pods, err := s.client.CoreV1().Pods(s.namespace).List(context.TODO(), listOption)
-	if err != nil {
-		return nil, err
-	}
-	if len(pods.Items) < 1 {
-		return nil, fmt.Errorf("could not find job %s: no pod exists with label %s", id, labels.AsSelector().String())
-	}
-	for i := range pods.Items {
-		job := NewJobFromPod(pods.Items[i])
-		if job.ID == id {
-			return job, nil
+// GetJob returns the job with the given ID, which is the name of its pod.
+func (s *store) GetJob(ctx context.Context, id string) (*brigade.Job, error) {
+	pod, ok, err := s.apiCache.GetPod(id)
+	if !ok || err != nil {
+		// The pod may have been created after the cache was synced.
+		if pod, err = s.client.CoreV1().Pods(s.namespace).Get(ctx, id, meta.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("could not find job %s: %s", id, err)
 		}
 	}
-	return nil, fmt.Errorf("could not find job %s: no pod exists with that ID and label %s", id, labels.AsSelector().String())
+	if pod.Labels["heritage"] != "brigade" {
+		return nil, fmt.Errorf("could not find job %s: pod is not managed by brigade", id)
+	}
+	return NewJobFromPod(*pod), nil
 }
 
-func (s *store) GetBuildJobs(build *brigade.Build) ([]*brigade.Job, error) {
+// GetBuildJobs returns the jobs of a build.
+func (s *store) GetBuildJobs(ctx context.Context, build *brigade.Build) ([]*brigade.Job, error) {
 	// Load the pods that ran as part of this build.
-	lo := meta.ListOptions{LabelSelector: fmt.Sprintf("heritage=brigade,component=job,build=%s,project=%s", build.ID, build.ProjectID)}
-
-	podList, err := s.client.CoreV1().Pods(s.namespace).List(context.TODO(), lo)
+	pods, err := s.apiCache.GetPodsFilteredBy(map[string]string{
+		"heritage":  "brigade",
+		"component": "job",
+		"build":     build.ID,
+		"project":   build.ProjectID,
+	})
 	if err != nil {
 		return nil, err
 	}
-	jobList := make([]*brigade.Job, len(podList.Items))
-	for i := range podList.Items {
-		jobList[i] = NewJobFromPod(podList.Items[i])
+	// List the jobs in the order of the API.
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	jobList := make([]*brigade.Job, len(pods))
+	for i := range pods {
+		jobList[i] = NewJobFromPod(pods[i])
 	}
 	return jobList, nil
 }
 
-func (s *store) GetJobLogStream(job *brigade.Job) (io.ReadCloser, error) {
-	return s.getJobLogStream(false, job)
+func (s *store) GetJobLogStream(ctx context.Context, job *brigade.Job) (io.ReadCloser, error) {
+	return s.podLogStream(ctx, job.ID, storage.LogOptions{})
+}
+
+func (s *store) GetJobLogStreamFollow(ctx context.Context, job *brigade.Job) (io.ReadCloser, error) {
+	return s.podLogStream(ctx, job.ID, storage.LogOptions{Follow: true})
 }
 
-func (s *store) GetJobLogStreamFollow(job *brigade.Job) (io.ReadCloser, error) {
-	return s.getJobLogStream(true, job)
+func (s *store) GetJobLogStreamWithOptions(ctx context.Context, job *brigade.Job, opts storage.LogOptions) (io.ReadCloser, error) {
+	return s.podLogStream(ctx, job.ID, opts)
 }
 
-func (s *store) GetJobLog(job *brigade.Job) (string, error) {
+func (s *store) GetJobLog(ctx context.Context, job *brigade.Job) (string, error) {
 	buf := new(bytes.Buffer)
-	r, err := s.GetJobLogStream(job)
+	r, err := s.GetJobLogStream(ctx, job)
 	if err != nil {
 		return "", err
 	}
@@ -67,20 +73,6 @@ func (s *store) GetJobLog(job *brigade.Job) (string, error) {
 	return buf.String(), nil
 }
 
-func (s *store) getJobLogStream(follow bool, job *brigade.Job) (io.ReadCloser, error) {
-	tailAllLines := int64(math.MaxInt64)
-	req := s.client.CoreV1().Pods(s.namespace).GetLogs(job.ID, &v1.PodLogOptions{
-		Follow:    follow,
-		TailLines: &tailAllLines,
-	})
-
-	readCloser, err := req.Stream(context.TODO())
-	if err != nil {
-		return nil, err
-	}
-	return readCloser, nil
-}
-
 // NewJobFromPod parses the pod object metadata and deserializes it into a Job.
 func NewJobFromPod(pod v1.Pod) *brigade.Job {
 	job := &brigade.Job{
@@ -1,12 +1,14 @@
 package kube
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
 )
@@ -68,7 +70,7 @@ func TestGetJob(t *testing.T) {
 	k, s := fakeStore()
 	createFakeJob(k, stubJobPod)
 
-	job, err := s.GetJob(stubJobID)
+	job, err := s.GetJob(context.TODO(), stubJobID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -82,16 +84,16 @@ func TestGetBuildJob(t *testing.T) {
 	k, s := fakeStore()
 	createFakeWorker(k, stubWorkerPod)
 	createFakeJob(k, stubJobPod)
-	if err := s.CreateBuild(stubBuild); err != nil {
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
 		t.Fatal(err)
 	}
 
-	b, err := s.GetBuild(stubBuild.ID)
+	b, err := s.GetBuild(context.TODO(), stubBuild.ID)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	jobs, err := s.GetBuildJobs(b)
+	jobs, err := s.GetBuildJobs(context.TODO(), b)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -99,3 +101,53 @@ func TestGetBuildJob(t *testing.T) {
 		t.Fatal("Expected one job.")
 	}
 }
+
+func TestGetJob_JustCreated(t *testing.T) {
+	k, s := fakeStore()
+	// Sync the cache before the job exists.
+	if _, err := s.GetBuilds(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	createFakeJob(k, stubJobPod)
+
+	job, err := s.GetJob(context.TODO(), stubJobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != stubJobID {
+		t.Errorf("Expected job ID %s, got %s", stubJobID, job.ID)
+	}
+}
+
+func TestGetBuildJobs_Direct(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	s := NewDirect(client, "default")
+	createFakeWorker(client, stubWorkerPod)
+	if err := s.CreateBuild(context.TODO(), stubBuild); err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.GetBuild(context.TODO(), stubBuild.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A job created after the build was read is listed right away.
+	createFakeJob(client, stubJobPod)
+	jobs, err := s.GetBuildJobs(context.TODO(), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != stubJobID {
+		t.Fatalf("Expected job %s, got %v", stubJobID, jobs)
+	}
+}
+
+func TestGetJob_NotBrigade(t *testing.T) {
+	k, s := fakeStore()
+	pod := stubJobPod.DeepCopy()
+	delete(pod.Labels, "heritage")
+	createFakeJob(k, *pod)
+
+	if _, err := s.GetJob(context.TODO(), stubJobID); err == nil {
+		t.Error("expected an error for a pod not managed by brigade")
+	}
+}
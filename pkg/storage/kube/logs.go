@@ -0,0 +1,44 @@
+package kube
+
+import (
+	"context"
+	"io"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// podLogStream streams the part of the log of a pod selected by the options.
+// Kubernetes selects the lines, except for grep, which is applied here so that
+// the clients of the store only receive the matching lines.
+func (s *store) podLogStream(ctx context.Context, name string, opts storage.LogOptions) (io.ReadCloser, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	tailLines := int64(math.MaxInt64)
+	if opts.TailLines > 0 {
+		tailLines = opts.TailLines
+	}
+	podOpts := &v1.PodLogOptions{
+		Container:  opts.Container,
+		Follow:     opts.Follow,
+		TailLines:  &tailLines,
+		Timestamps: opts.Timestamps,
+	}
+	if !opts.SinceTime.IsZero() {
+		since := meta.NewTime(opts.SinceTime)
+		podOpts.SinceTime = &since
+	}
+	if opts.SinceSeconds > 0 {
+		podOpts.SinceSeconds = &opts.SinceSeconds
+	}
+
+	readCloser, err := s.client.CoreV1().Pods(s.namespace).GetLogs(name, podOpts).Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.GrepLog(readCloser, opts.Grep)
+}
@@ -18,9 +18,9 @@ import (
 const secretTypeProject = "brigade.sh/project"
 
 // GetProjects retrieves all projects from storage.
-func (s *store) GetProjects() ([]*brigade.Project, error) {
+func (s *store) GetProjects(ctx context.Context) ([]*brigade.Project, error) {
 	lo := meta.ListOptions{LabelSelector: "app=brigade,component=project"}
-	secretList, err := s.client.CoreV1().Secrets(s.namespace).List(context.TODO(), lo)
+	secretList, err := s.client.CoreV1().Secrets(s.namespace).List(ctx, lo)
 	if err != nil {
 		return nil, err
 	}
@@ -36,8 +36,8 @@ func (s *store) GetProjects() ([]*brigade.Project, error) {
 }
 
 // GetProject retrieves the project from storage.
-func (s *store) GetProject(id string) (*brigade.Project, error) {
-	return s.loadProjectConfig(brigade.ProjectID(id))
+func (s *store) GetProject(ctx context.Context, id string) (*brigade.Project, error) {
+	return s.loadProjectConfig(ctx, brigade.ProjectID(id))
 }
 
 // SecretFromProject takes a project and converts it to a Kubernetes Secret.
@@ -58,6 +58,27 @@ func SecretFromProject(project *brigade.Project) (v1.Secret, error) {
 		return v1.Secret{}, err
 	}
 
+	var notificationsJSON []byte
+	if len(project.Notifications) > 0 {
+		if notificationsJSON, err = json.Marshal(project.Notifications); err != nil {
+			return v1.Secret{}, err
+		}
+	}
+
+	var schedulesJSON []byte
+	if len(project.Schedules) > 0 {
+		if schedulesJSON, err = json.Marshal(project.Schedules); err != nil {
+			return v1.Secret{}, err
+		}
+	}
+
+	var triggersJSON []byte
+	if len(project.Triggers) > 0 {
+		if triggersJSON, err = json.Marshal(project.Triggers); err != nil {
+			return v1.Secret{}, err
+		}
+	}
+
 	bfmt := func(b bool) string { return fmt.Sprintf("%t", b) }
 
 	secret := v1.Secret{
@@ -100,6 +121,24 @@ func SecretFromProject(project *brigade.Project) (v1.Secret, error) {
 			"worker.tag":        project.Worker.Tag,
 			"worker.pullPolicy": project.Worker.PullPolicy,
 
+			"worker.requests.cpu":    project.Worker.RequestsCPU,
+			"worker.requests.memory": project.Worker.RequestsMemory,
+			"worker.limits.cpu":      project.Worker.LimitsCPU,
+			"worker.limits.memory":   project.Worker.LimitsMemory,
+
+			"retry.maxAttempts": strconv.Itoa(project.Retry.MaxAttempts),
+			"retry.reasons":     strings.Join(project.Retry.Reasons, ","),
+			"retry.backoff":     project.Retry.Backoff,
+
+			"retention.maxBuilds":      strconv.Itoa(project.Retention.MaxBuilds),
+			"retention.maxAge":         project.Retention.MaxAge,
+			"retention.maxFailedAge":   project.Retention.MaxFailedAge,
+			"retention.keepSuccessful": strconv.Itoa(project.Retention.KeepSuccessful),
+
+			"notifications": string(notificationsJSON),
+			"schedules":     string(schedulesJSON),
+			"triggers":      string(triggersJSON),
+
 			// These exist in the chart, but not in the brigade.Project
 			"initGitSubmodules":    bfmt(project.InitGitSubmodules),
 			"imagePullSecrets":     project.ImagePullSecrets,
@@ -113,6 +152,11 @@ func SecretFromProject(project *brigade.Project) (v1.Secret, error) {
 			"kubernetes.cacheStorageClass": project.Kubernetes.CacheStorageClass,
 			"kubernetes.buildStorageClass": project.Kubernetes.BuildStorageClass,
 			"kubernetes.allowSecretKeyRef": strconv.FormatBool(project.Kubernetes.AllowSecretKeyRef),
+
+			"vcsSidecarResources.requests.cpu":    project.Kubernetes.VCSSidecarRequestsCPU,
+			"vcsSidecarResources.requests.memory": project.Kubernetes.VCSSidecarRequestsMemory,
+			"vcsSidecarResources.limits.cpu":      project.Kubernetes.VCSSidecarLimitsCPU,
+			"vcsSidecarResources.limits.memory":   project.Kubernetes.VCSSidecarLimitsMemory,
 		},
 	}
 	return secret, nil
@@ -124,43 +168,49 @@ func SecretFromProject(project *brigade.Project) (v1.Secret, error) {
 // from project name. This is preferred.
 //
 // Note that project secrets are not redacted.
-func (s *store) CreateProject(project *brigade.Project) error {
+func (s *store) CreateProject(ctx context.Context, project *brigade.Project) error {
+	if err := project.Validate(); err != nil {
+		return err
+	}
 	secret, err := SecretFromProject(project)
 	if err != nil {
 		return err
 	}
-	_, err = s.client.CoreV1().Secrets(s.namespace).Create(context.TODO(), &secret, meta.CreateOptions{})
+	_, err = s.client.CoreV1().Secrets(s.namespace).Create(ctx, &secret, meta.CreateOptions{})
 	return err
 }
 
 // ReplaceProject replaces an existing project.
 //
 // Project ID is a required field. If empty, function will exit
-func (s *store) ReplaceProject(project *brigade.Project) error {
+func (s *store) ReplaceProject(ctx context.Context, project *brigade.Project) error {
 	if project.ID == "" {
 		return fmt.Errorf("Project ID is empty")
 	}
+	if err := project.Validate(); err != nil {
+		return err
+	}
 	secret, err := SecretFromProject(project)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.client.CoreV1().Secrets(s.namespace).Update(context.TODO(), &secret, meta.UpdateOptions{})
+	_, err = s.client.CoreV1().Secrets(s.namespace).Update(ctx, &secret, meta.UpdateOptions{})
 
 	return err
 }
 
 // DeleteProject deletes a project from storage.
-func (s *store) DeleteProject(id string) error {
-	return s.client.CoreV1().Secrets(s.namespace).Delete(context.TODO(), id, meta.DeleteOptions{})
+func (s *store) DeleteProject(ctx context.Context, id string) error {
+	return s.client.CoreV1().Secrets(s.namespace).Delete(ctx, id, meta.DeleteOptions{})
 }
 
 // loadProjectConfig loads a project config from inside of Kubernetes.
 //
 // The namespace is the namespace where the secret is stored.
-func (s *store) loadProjectConfig(id string) (*brigade.Project, error) {
+func (s *store) loadProjectConfig(ctx context.Context, id string) (*brigade.Project, error) {
 	// The project config is stored in a secret.
-	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(context.TODO(), id, meta.GetOptions{})
+	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(ctx, id, meta.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -186,6 +236,10 @@ func NewProjectFromSecret(secret *v1.Secret, namespace string) (*brigade.Project
 	proj.Kubernetes.BuildStorageClass = sv.String("kubernetes.buildStorageClass")
 	proj.Kubernetes.CacheStorageClass = sv.String("kubernetes.cacheStorageClass")
 	proj.Kubernetes.ServiceAccount = sv.String("serviceAccount")
+	proj.Kubernetes.VCSSidecarRequestsCPU = sv.String("vcsSidecarResources.requests.cpu")
+	proj.Kubernetes.VCSSidecarRequestsMemory = sv.String("vcsSidecarResources.requests.memory")
+	proj.Kubernetes.VCSSidecarLimitsCPU = sv.String("vcsSidecarResources.limits.cpu")
+	proj.Kubernetes.VCSSidecarLimitsMemory = sv.String("vcsSidecarResources.limits.memory")
 
 	if sv.String("kubernetes.allowSecretKeyRef") != "" {
 		if allowSecretKeyRef, err := strconv.ParseBool(sv.String("kubernetes.allowSecretKeyRef")); err == nil {
@@ -223,6 +277,60 @@ func NewProjectFromSecret(secret *v1.Secret, namespace string) (*brigade.Project
 		Name:       sv.String("worker.name"),
 		Tag:        sv.String("worker.tag"),
 		PullPolicy: sv.String("worker.pullPolicy"),
+
+		RequestsCPU:    sv.String("worker.requests.cpu"),
+		RequestsMemory: sv.String("worker.requests.memory"),
+		LimitsCPU:      sv.String("worker.limits.cpu"),
+		LimitsMemory:   sv.String("worker.limits.memory"),
+	}
+
+	if v := sv.String("retry.maxAttempts"); v != "" {
+		maxAttempts, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'retry.maxAttempts': %s", err.Error())
+		}
+		proj.Retry.MaxAttempts = maxAttempts
+	}
+	if v := sv.String("retry.reasons"); v != "" {
+		for _, reason := range strings.Split(v, ",") {
+			proj.Retry.Reasons = append(proj.Retry.Reasons, strings.TrimSpace(reason))
+		}
+	}
+	proj.Retry.Backoff = sv.String("retry.backoff")
+
+	if v := sv.String("retention.maxBuilds"); v != "" {
+		maxBuilds, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'retention.maxBuilds': %s", err.Error())
+		}
+		proj.Retention.MaxBuilds = maxBuilds
+	}
+	proj.Retention.MaxAge = sv.String("retention.maxAge")
+	proj.Retention.MaxFailedAge = sv.String("retention.maxFailedAge")
+	if v := sv.String("retention.keepSuccessful"); v != "" {
+		keepSuccessful, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'retention.keepSuccessful': %s", err.Error())
+		}
+		proj.Retention.KeepSuccessful = keepSuccessful
+	}
+
+	if d := sv.Bytes("notifications"); len(d) > 0 {
+		if err := json.Unmarshal(d, &proj.Notifications); err != nil {
+			return nil, fmt.Errorf("error parsing 'notifications': %s", err.Error())
+		}
+	}
+
+	if d := sv.Bytes("schedules"); len(d) > 0 {
+		if err := json.Unmarshal(d, &proj.Schedules); err != nil {
+			return nil, fmt.Errorf("error parsing 'schedules': %s", err.Error())
+		}
+	}
+
+	if d := sv.Bytes("triggers"); len(d) > 0 {
+		if err := json.Unmarshal(d, &proj.Triggers); err != nil {
+			return nil, fmt.Errorf("error parsing 'triggers': %s", err.Error())
+		}
 	}
 
 	// git submodules and host mounts are false by default. Priv jobs are true by default.
@@ -232,6 +340,7 @@ func NewProjectFromSecret(secret *v1.Secret, namespace string) (*brigade.Project
 	proj.ImagePullSecrets = sv.String("imagePullSecrets")
 
 	proj.BrigadejsPath = sv.String("brigadejsPath")
+	proj.BrigadeConfigPath = sv.String("brigadeConfigPath")
 	proj.WorkerCommand = sv.String("workerCommand")
 	return proj, nil
 }
@@ -15,7 +15,7 @@ import (
 func TestGetProjects(t *testing.T) {
 	k, s := fakeStore()
 	createFakeProject(k, stubProjectSecret)
-	projects, err := s.GetProjects()
+	projects, err := s.GetProjects(context.TODO())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -28,7 +28,7 @@ func TestGetProjects(t *testing.T) {
 func TestGetProject(t *testing.T) {
 	k, s := fakeStore()
 	createFakeProject(k, stubProjectSecret)
-	proj, err := s.GetProject(stubProjectID)
+	proj, err := s.GetProject(context.TODO(), stubProjectID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -81,13 +81,18 @@ func TestCreateProject(t *testing.T) {
 			Name:       "bobby",
 			Tag:        "millie",
 			PullPolicy: "Always",
+
+			RequestsCPU:    "500m",
+			RequestsMemory: "256Mi",
+			LimitsCPU:      "1",
+			LimitsMemory:   "1Gi",
 		},
 		InitGitSubmodules:   true,
 		AllowPrivilegedJobs: true,
 		AllowHostMounts:     true,
 		WorkerCommand:       "echo hello",
 	}
-	err := s.CreateProject(proj)
+	err := s.CreateProject(context.TODO(), proj)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -138,6 +143,10 @@ func TestCreateProject(t *testing.T) {
 		"worker.name":                  proj.Worker.Name,
 		"worker.tag":                   proj.Worker.Tag,
 		"worker.pullPolicy":            proj.Worker.PullPolicy,
+		"worker.requests.cpu":          proj.Worker.RequestsCPU,
+		"worker.requests.memory":       proj.Worker.RequestsMemory,
+		"worker.limits.cpu":            proj.Worker.LimitsCPU,
+		"worker.limits.memory":         proj.Worker.LimitsMemory,
 		"initGitSubmodules":            fmt.Sprintf("%t", proj.InitGitSubmodules),
 		"imagePullSecrets":             proj.ImagePullSecrets,
 		"allowPrivilegedJobs":          fmt.Sprintf("%t", proj.AllowPrivilegedJobs),
@@ -154,29 +163,29 @@ func TestCreateProject(t *testing.T) {
 
 func TestReplaceProject(t *testing.T) {
 	k, s := fakeStore()
-	p := &brigade.Project{Name: "fakeName", ID: "brigade-fakeID", Github: brigade.Github{
+	p := &brigade.Project{Name: "fakeName", ID: "brigade-fakeid", Github: brigade.Github{
 		Token:     "half-a-league",
 		BaseURL:   "http://example.com",
 		UploadURL: "http://up.example.com",
 	}}
 	// create the project
-	if err := s.CreateProject(p); err != nil {
+	if err := s.CreateProject(context.TODO(), p); err != nil {
 		t.Fatal(err)
 	}
 	// make sure it's there
-	if _, err := k.CoreV1().Secrets("default").Get(context.TODO(), "brigade-fakeID", meta.GetOptions{}); err != nil {
+	if _, err := k.CoreV1().Secrets("default").Get(context.TODO(), "brigade-fakeid", meta.GetOptions{}); err != nil {
 		t.Fatal(err)
 	}
 	// create another one with different ID
-	pNonExistent := &brigade.Project{Name: "fakeName", ID: "brigade-fakeIDNotExistent"}
+	pNonExistent := &brigade.Project{Name: "fakeName", ID: "brigade-fakeid-not-existent"}
 	// try to replace it, this will throw an error since it does not already exists
-	if err := s.ReplaceProject(pNonExistent); err == nil {
+	if err := s.ReplaceProject(context.TODO(), pNonExistent); err == nil {
 		t.Fatal("Err should not be nil, since project does not exist")
 	}
 	// create another one, this time using same ID and a couple of added/changed properties
 	p2 := &brigade.Project{
 		Name:          "fakeName",
-		ID:            "brigade-fakeID",
+		ID:            "brigade-fakeid",
 		DefaultScript: "new.js",
 		DefaultConfig: "new.json",
 		Github: brigade.Github{
@@ -185,11 +194,11 @@ func TestReplaceProject(t *testing.T) {
 			UploadURL: "http://up.example2.com",
 		}}
 	// replace it
-	if err := s.ReplaceProject(p2); err != nil {
+	if err := s.ReplaceProject(context.TODO(), p2); err != nil {
 		t.Fatal(err)
 	}
 	// make sure it worked, get it as well
-	updatedSecret, err := k.CoreV1().Secrets("default").Get(context.TODO(), "brigade-fakeID", meta.GetOptions{})
+	updatedSecret, err := k.CoreV1().Secrets("default").Get(context.TODO(), "brigade-fakeid", meta.GetOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -210,13 +219,13 @@ func TestReplaceProject(t *testing.T) {
 func TestDeleteProject(t *testing.T) {
 	k, s := fakeStore()
 	p := &brigade.Project{ID: "fake", Name: "fake"}
-	if err := s.CreateProject(p); err != nil {
+	if err := s.CreateProject(context.TODO(), p); err != nil {
 		t.Fatal(err)
 	}
 	if _, err := k.CoreV1().Secrets("default").Get(context.TODO(), "fake", meta.GetOptions{}); err != nil {
 		t.Fatal(err)
 	}
-	if err := s.DeleteProject("fake"); err != nil {
+	if err := s.DeleteProject(context.TODO(), "fake"); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -244,6 +253,23 @@ func TestConfigureProject(t *testing.T) {
 			"worker.name":       []byte("brigade-worker"),
 			"worker.tag":        []byte("canary"),
 			"worker.pullPolicy": []byte("Always"),
+
+			"worker.requests.cpu":    []byte("500m"),
+			"worker.requests.memory": []byte("256Mi"),
+			"worker.limits.cpu":      []byte("1"),
+			"worker.limits.memory":   []byte("1Gi"),
+
+			"retry.maxAttempts": []byte("3"),
+			"retry.reasons":     []byte("Evicted, NodeLost"),
+			"retry.backoff":     []byte("30s"),
+
+			"retention.maxBuilds":      []byte("20"),
+			"retention.maxFailedAge":   []byte("168h"),
+			"retention.keepSuccessful": []byte("3"),
+
+			"notifications": []byte(`[{"on": ["failure", "recovery"], "slack": {"webhookURL": "https://hooks.slack.com/services/T0/B0/x"}}]`),
+			"schedules":     []byte(`[{"name": "nightly", "cron": "0 3 * * *", "timezone": "Europe/Paris"}]`),
+			"triggers":      []byte(`[{"project": "ahab/harpoons", "eventType": "release", "event": "deploy"}]`),
 			// Intentionally skip cloneURL, test that this is ""
 			"buildStorageSize":             []byte("50Mi"),
 			"kubernetes.cacheStorageClass": []byte("hello"),
@@ -313,6 +339,40 @@ func TestConfigureProject(t *testing.T) {
 	if proj.Worker.PullPolicy != "Always" {
 		t.Fatalf("unexpected Worker.PullPolicy: %s != Always", proj.Worker.PullPolicy)
 	}
+	if proj.Worker.RequestsCPU != "500m" {
+		t.Errorf("unexpected Worker.RequestsCPU: %s != 500m", proj.Worker.RequestsCPU)
+	}
+	if proj.Worker.RequestsMemory != "256Mi" {
+		t.Errorf("unexpected Worker.RequestsMemory: %s != 256Mi", proj.Worker.RequestsMemory)
+	}
+	if proj.Worker.LimitsCPU != "1" {
+		t.Errorf("unexpected Worker.LimitsCPU: %s != 1", proj.Worker.LimitsCPU)
+	}
+	if proj.Worker.LimitsMemory != "1Gi" {
+		t.Errorf("unexpected Worker.LimitsMemory: %s != 1Gi", proj.Worker.LimitsMemory)
+	}
+	if proj.Retry.MaxAttempts != 3 {
+		t.Errorf("unexpected Retry.MaxAttempts: %d != 3", proj.Retry.MaxAttempts)
+	}
+	if len(proj.Retry.Reasons) != 2 || proj.Retry.Reasons[0] != "Evicted" || proj.Retry.Reasons[1] != "NodeLost" {
+		t.Errorf("unexpected Retry.Reasons: %v", proj.Retry.Reasons)
+	}
+	if proj.Retry.Backoff != "30s" {
+		t.Errorf("unexpected Retry.Backoff: %s != 30s", proj.Retry.Backoff)
+	}
+	expectedRetention := brigade.RetentionPolicy{MaxBuilds: 20, MaxFailedAge: "168h", KeepSuccessful: 3}
+	if proj.Retention != expectedRetention {
+		t.Errorf("unexpected Retention: %+v != %+v", proj.Retention, expectedRetention)
+	}
+	if len(proj.Notifications) != 1 || proj.Notifications[0].Slack == nil || !proj.Notifications[0].Notifies(brigade.OutcomeRecovery) {
+		t.Errorf("unexpected Notifications: %+v", proj.Notifications)
+	}
+	if len(proj.Schedules) != 1 || proj.Schedules[0].Name != "nightly" || proj.Schedules[0].Timezone != "Europe/Paris" {
+		t.Errorf("unexpected Schedules: %+v", proj.Schedules)
+	}
+	if len(proj.Triggers) != 1 || proj.Triggers[0].Project != "ahab/harpoons" || proj.Triggers[0].BuildType() != "deploy" {
+		t.Errorf("unexpected Triggers: %+v", proj.Triggers)
+	}
 	if proj.Kubernetes.BuildStorageSize != "50Mi" {
 		t.Fatalf("buildStorageSize is wrong %s", proj.Kubernetes.BuildStorageSize)
 	}
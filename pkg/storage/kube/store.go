@@ -17,10 +17,21 @@ type store struct {
 }
 
 // New initializes a new storage backend.
-func New(c kubernetes.Interface, namespace string) storage.Store {
+func New(c kubernetes.Interface, namespace string) storage.ContextStore {
 	return &store{
 		client:    c,
 		namespace: namespace,
 		apiCache:  apicache.New(c, namespace, time.Duration(60)*time.Second),
 	}
 }
+
+// NewDirect initializes a storage backend that reads every object from the
+// Kubernetes API instead of a cache. It is meant for one-off snapshots that
+// must not miss objects a lagging watch has not delivered yet.
+func NewDirect(c kubernetes.Interface, namespace string) storage.ContextStore {
+	return &store{
+		client:    c,
+		namespace: namespace,
+		apiCache:  apicache.NewDirect(c, namespace),
+	}
+}
@@ -146,7 +146,7 @@ var (
 )
 
 // fakeStore returns a fake Kubernetes client and a *store that wraps it.
-func fakeStore() (kubernetes.Interface, storage.Store) {
+func fakeStore() (kubernetes.Interface, storage.ContextStore) {
 	client := fake.NewSimpleClientset()
 	return client, New(client, "default")
 }
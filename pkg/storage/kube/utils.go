@@ -7,8 +7,8 @@ import (
 )
 
 // GetStorageClassNames returns the names of the StorageClass instances in the cluster
-func (s *store) GetStorageClassNames() ([]string, error) {
-	scl, err := s.client.StorageV1().StorageClasses().List(context.TODO(), meta.ListOptions{})
+func (s *store) GetStorageClassNames(ctx context.Context) ([]string, error) {
+	scl, err := s.client.StorageV1().StorageClasses().List(ctx, meta.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -13,7 +13,7 @@ func TestStorageClassNames(t *testing.T) {
 	k, s := fakeStore()
 	createFakeStorageClasses(k)
 
-	scn, err := s.GetStorageClassNames()
+	scn, err := s.GetStorageClassNames(context.TODO())
 	if err != nil {
 		t.Fatal(err)
 	}
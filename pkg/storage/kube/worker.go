@@ -5,30 +5,35 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"math"
 
 	v1 "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage"
 )
 
 // GetWorker returns the worker description.
 //
 // This will return an error if no worker is found for the build, which can
 // happen when a build is scheduled, but not yet started.
-func (s *store) GetWorker(buildID string) (*brigade.Worker, error) {
+func (s *store) GetWorker(ctx context.Context, buildID string) (*brigade.Worker, error) {
 	labels := labels.Set{"heritage": "brigade", "component": "build", "build": buildID}
-	listOption := meta.ListOptions{LabelSelector: labels.AsSelector().String()}
-	pods, err := s.client.CoreV1().Pods(s.namespace).List(context.TODO(), listOption)
-	if err != nil {
-		return nil, err
+	pods, err := s.apiCache.GetPodsFilteredBy(labels)
+	if len(pods) < 1 || err != nil {
+		// The worker may have been started after the cache was synced.
+		listOption := meta.ListOptions{LabelSelector: labels.AsSelector().String()}
+		list, err := s.client.CoreV1().Pods(s.namespace).List(ctx, listOption)
+		if err != nil {
+			return nil, err
+		}
+		pods = list.Items
 	}
-	if len(pods.Items) < 1 {
+	if len(pods) < 1 {
 		return nil, fmt.Errorf("could not find worker for build %s: no pod exists with label %s", buildID, labels.AsSelector().String())
 	}
-	return NewWorkerFromPod(pods.Items[0]), nil
+	return NewWorkerFromPod(pods[0]), nil
 }
 
 // NewWorkerFromPod creates a new *Worker from a pod definition.
@@ -41,7 +46,8 @@ func NewWorkerFromPod(pod v1.Pod) *brigade.Worker {
 		Status:    brigade.JobStatus(pod.Status.Phase),
 	}
 
-	if (worker.Status != brigade.JobPending) && (worker.Status != brigade.JobUnknown) {
+	// Pods that failed before they started, like evicted ones, have no start time.
+	if (worker.Status != brigade.JobPending) && (worker.Status != brigade.JobUnknown) && pod.Status.StartTime != nil {
 		worker.StartTime = pod.Status.StartTime.Time
 	}
 
@@ -55,17 +61,21 @@ func NewWorkerFromPod(pod v1.Pod) *brigade.Worker {
 
 	return worker
 }
-func (s *store) GetWorkerLogStream(worker *brigade.Worker) (io.ReadCloser, error) {
-	return s.getWorkerLogStream(false, worker)
+func (s *store) GetWorkerLogStream(ctx context.Context, worker *brigade.Worker) (io.ReadCloser, error) {
+	return s.podLogStream(ctx, worker.ID, storage.LogOptions{})
+}
+
+func (s *store) GetWorkerLogStreamFollow(ctx context.Context, worker *brigade.Worker) (io.ReadCloser, error) {
+	return s.podLogStream(ctx, worker.ID, storage.LogOptions{Follow: true})
 }
 
-func (s *store) GetWorkerLogStreamFollow(worker *brigade.Worker) (io.ReadCloser, error) {
-	return s.getWorkerLogStream(true, worker)
+func (s *store) GetWorkerLogStreamWithOptions(ctx context.Context, worker *brigade.Worker, opts storage.LogOptions) (io.ReadCloser, error) {
+	return s.podLogStream(ctx, worker.ID, opts)
 }
 
-func (s *store) GetWorkerLog(worker *brigade.Worker) (string, error) {
+func (s *store) GetWorkerLog(ctx context.Context, worker *brigade.Worker) (string, error) {
 	buf := new(bytes.Buffer)
-	r, err := s.GetWorkerLogStream(worker)
+	r, err := s.GetWorkerLogStream(ctx, worker)
 	if err != nil {
 		return "", err
 	}
@@ -74,9 +84,9 @@ func (s *store) GetWorkerLog(worker *brigade.Worker) (string, error) {
 	return buf.String(), nil
 }
 
-func (s *store) GetWorkerInitLog(worker *brigade.Worker) (string, error) {
+func (s *store) GetWorkerInitLog(ctx context.Context, worker *brigade.Worker) (string, error) {
 	buf := new(bytes.Buffer)
-	r, err := s.getWorkerLogStream(false, worker, "vcs-sidecar")
+	r, err := s.podLogStream(ctx, worker.ID, storage.LogOptions{Container: "vcs-sidecar"})
 	if err != nil {
 		return "", err
 	}
@@ -84,21 +94,3 @@ func (s *store) GetWorkerInitLog(worker *brigade.Worker) (string, error) {
 	io.Copy(buf, r)
 	return buf.String(), nil
 }
-
-func (s *store) getWorkerLogStream(follow bool, worker *brigade.Worker, container ...string) (io.ReadCloser, error) {
-	tailAllLines := int64(math.MaxInt64)
-	opts := &v1.PodLogOptions{
-		Follow:    follow,
-		TailLines: &tailAllLines,
-	}
-	if len(container) > 0 {
-		opts.Container = container[0]
-	}
-	req := s.client.CoreV1().Pods(s.namespace).GetLogs(worker.ID, opts)
-
-	readCloser, err := req.Stream(context.TODO())
-	if err != nil {
-		return nil, err
-	}
-	return readCloser, nil
-}
@@ -1,6 +1,7 @@
 package kube
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
@@ -61,7 +62,7 @@ func TestNewWorkerFromPod(t *testing.T) {
 func TestGetWorker(t *testing.T) {
 	k, s := fakeStore()
 	createFakeWorker(k, stubWorkerPod)
-	worker, err := s.GetWorker(stubBuildID)
+	worker, err := s.GetWorker(context.TODO(), stubBuildID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LogOptions selects the part of a log to read. The zero value selects the
+// whole log of the main container.
+type LogOptions struct {
+	// Container is the container to read the log of, the main container if
+	// empty.
+	Container string
+	// TailLines is the number of lines to read from the end of the log, all
+	// of them if 0.
+	TailLines int64
+	// SinceTime only keeps the lines logged after that time.
+	SinceTime time.Time
+	// SinceSeconds only keeps the lines logged in the last seconds.
+	SinceSeconds int64
+	// Timestamps prefixes every line with the RFC3339 time it was logged at.
+	Timestamps bool
+	// Follow keeps reading the log until the container exits.
+	Follow bool
+	// Grep only keeps the lines matching this regular expression. It applies
+	// to the lines selected by the other options.
+	Grep string
+}
+
+// Validate checks that the options are consistent.
+func (o LogOptions) Validate() error {
+	if o.TailLines < 0 {
+		return errors.New("tail must not be negative")
+	}
+	if o.SinceSeconds < 0 {
+		return errors.New("since seconds must not be negative")
+	}
+	if o.SinceSeconds > 0 && !o.SinceTime.IsZero() {
+		return errors.New("only one of since seconds and since time may be set")
+	}
+	if _, err := regexp.Compile(o.Grep); err != nil {
+		return fmt.Errorf("invalid grep expression: %s", err)
+	}
+	return nil
+}
+
+// HasTimeWindow returns true if the options need the time of every line.
+func (o LogOptions) HasTimeWindow() bool {
+	return o.Timestamps || o.SinceSeconds > 0 || !o.SinceTime.IsZero()
+}
+
+// GrepLog returns a stream of the lines of r that match the regular
+// expression. It returns r if the expression is empty.
+func GrepLog(r io.ReadCloser, expr string) (io.ReadCloser, error) {
+	if expr == "" {
+		return r, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("invalid grep expression: %s", err)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 && re.Match(line) {
+				if _, werr := pw.Write(line); werr != nil {
+					// The reader was closed.
+					break
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				pw.CloseWithError(err)
+				break
+			}
+		}
+		r.Close()
+	}()
+	return &grepReader{PipeReader: pr, src: r}, nil
+}
+
+// grepReader closes the filtered stream along with the pipe, so that the
+// filtering goroutine stops.
+type grepReader struct {
+	*io.PipeReader
+	src io.Closer
+}
+
+func (g *grepReader) Close() error {
+	g.PipeReader.Close()
+	return g.src.Close()
+}
+
+// TailLog returns the last lines of r. It reads r to the end, so it is meant
+// for logs that cannot be tailed at their source.
+func TailLog(r io.ReadCloser, lines int64) (io.ReadCloser, error) {
+	if lines <= 0 {
+		return r, nil
+	}
+	defer r.Close()
+	tail := make([]string, 0, lines)
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			if int64(len(tail)) == lines {
+				tail = tail[1:]
+			}
+			tail = append(tail, line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ioutil.NopCloser(strings.NewReader(strings.Join(tail, ""))), nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLog = "starting\nerror: no space left\nretrying\nerror: timeout\ndone"
+
+func TestGrepLog(t *testing.T) {
+	r, err := GrepLog(ioutil.NopCloser(strings.NewReader(testLog)), "^error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "error: no space left\nerror: timeout\n"; string(data) != expect {
+		t.Errorf("expected %q, got %q", expect, data)
+	}
+
+	if _, err := GrepLog(ioutil.NopCloser(strings.NewReader(testLog)), "("); err == nil {
+		t.Error("expected an error for an invalid expression")
+	}
+}
+
+func TestGrepLog_Close(t *testing.T) {
+	pr, pw := io.Pipe()
+	r, err := GrepLog(pr, "error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Closing the filtered log closes the source, even if it never ends.
+	r.Close()
+	if _, err := pw.Write([]byte("error\n")); err == nil {
+		t.Error("expected the source to be closed")
+	}
+}
+
+func TestTailLog(t *testing.T) {
+	for lines, expect := range map[int64]string{
+		0:  testLog,
+		2:  "error: timeout\ndone",
+		10: testLog,
+	} {
+		r, err := TailLog(ioutil.NopCloser(strings.NewReader(testLog)), lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(r)
+		r.Close()
+		if string(data) != expect {
+			t.Errorf("%d lines: expected %q, got %q", lines, expect, data)
+		}
+	}
+}
+
+func TestLogOptionsValidate(t *testing.T) {
+	for _, tt := range []struct {
+		opts  LogOptions
+		valid bool
+	}{
+		{LogOptions{}, true},
+		{LogOptions{TailLines: 10, SinceSeconds: 60, Grep: "error|panic"}, true},
+		{LogOptions{TailLines: -1}, false},
+		{LogOptions{SinceSeconds: -1}, false},
+		{LogOptions{SinceSeconds: 60, SinceTime: time.Now()}, false},
+		{LogOptions{Grep: "["}, false},
+	} {
+		if err := tt.opts.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: expected valid %t, got %v", tt.opts, tt.valid, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -78,6 +79,16 @@ var (
 		ExitCode:     0,
 		Status:       brigade.JobSucceeded,
 	}
+	// StubBuildEvent is a stub BuildEvent.
+	StubBuildEvent = &brigade.BuildEvent{
+		Type:      "Normal",
+		Reason:    "WorkerCreated",
+		Message:   "Created worker pod worker-id1",
+		Object:    "Pod/worker-id1",
+		Count:     1,
+		FirstTime: Now,
+		LastTime:  Now,
+	}
 	// StubLogData is string data representing a log.
 	StubLogData = "Hello World"
 )
@@ -89,6 +100,7 @@ func New() *Store {
 		Workers:     []*brigade.Worker{StubWorker1, StubWorker2},
 		Builds:      []*brigade.Build{StubBuild1, StubBuild2},
 		Job:         StubJob,
+		Events:      []*brigade.BuildEvent{StubBuildEvent},
 		LogData:     StubLogData,
 	}
 }
@@ -99,6 +111,8 @@ type Store struct {
 	Builds []*brigade.Build
 	// Job is the job you want returned.
 	Job *brigade.Job
+	// Events is a slice of build events.
+	Events []*brigade.BuildEvent
 	// Workers is a slice of workers.
 	Workers []*brigade.Worker
 	// LogData is the log data you want returned.
@@ -108,18 +122,18 @@ type Store struct {
 }
 
 // GetProjects gets the mock project wrapped as a slice of projects.
-func (s *Store) GetProjects() ([]*brigade.Project, error) {
+func (s *Store) GetProjects(ctx context.Context) ([]*brigade.Project, error) {
 	return s.ProjectList, nil
 }
 
 // CreateProject adds a project to the internal mock
-func (s *Store) CreateProject(p *brigade.Project) error {
+func (s *Store) CreateProject(ctx context.Context, p *brigade.Project) error {
 	s.ProjectList = append(s.ProjectList, p)
 	return nil
 }
 
 // ReplaceProject replaces a project in the internal mock
-func (s *Store) ReplaceProject(p *brigade.Project) error {
+func (s *Store) ReplaceProject(ctx context.Context, p *brigade.Project) error {
 	found := false
 	for _, pr := range s.ProjectList {
 		if pr.Name == p.Name {
@@ -137,7 +151,7 @@ func (s *Store) ReplaceProject(p *brigade.Project) error {
 }
 
 // DeleteProject deletes a project from the internal mock
-func (s *Store) DeleteProject(id string) error {
+func (s *Store) DeleteProject(ctx context.Context, id string) error {
 	tmp := []*brigade.Project{}
 	for _, p := range s.ProjectList {
 		if p.ID == id {
@@ -149,7 +163,7 @@ func (s *Store) DeleteProject(id string) error {
 }
 
 // GetProject returns the Project
-func (s *Store) GetProject(id string) (*brigade.Project, error) {
+func (s *Store) GetProject(ctx context.Context, id string) (*brigade.Project, error) {
 	for _, proj := range s.ProjectList {
 		if proj.ID == id {
 			return proj, nil
@@ -159,83 +173,111 @@ func (s *Store) GetProject(id string) (*brigade.Project, error) {
 }
 
 // GetProjectBuilds returns the mock Build wrapped in a slice.
-func (s *Store) GetProjectBuilds(p *brigade.Project) ([]*brigade.Build, error) {
-	return s.GetBuilds()
+func (s *Store) GetProjectBuilds(ctx context.Context, p *brigade.Project) ([]*brigade.Build, error) {
+	return s.GetBuilds(ctx)
 }
 
 // GetBuilds returns the mock build wrapped in a slice.
-func (s *Store) GetBuilds() ([]*brigade.Build, error) {
+func (s *Store) GetBuilds(ctx context.Context) ([]*brigade.Build, error) {
 	return s.Builds, nil
 }
 
 // GetBuild gets the first mock Build.
-func (s *Store) GetBuild(id string) (*brigade.Build, error) {
+func (s *Store) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
 	return s.Builds[0], nil
 }
 
+// GetBuildEvents gets the mock build events.
+func (s *Store) GetBuildEvents(ctx context.Context, id string) ([]*brigade.BuildEvent, error) {
+	return s.Events, nil
+}
+
 // GetBuildJobs gets the mock job wrapped in a slice.
-func (s *Store) GetBuildJobs(b *brigade.Build) ([]*brigade.Job, error) {
+func (s *Store) GetBuildJobs(ctx context.Context, b *brigade.Build) ([]*brigade.Job, error) {
 	return []*brigade.Job{s.Job}, nil
 }
 
 // GetWorker gets the first mock worker.
-func (s *Store) GetWorker(bid string) (*brigade.Worker, error) {
+func (s *Store) GetWorker(ctx context.Context, bid string) (*brigade.Worker, error) {
 	return s.Workers[0], nil
 }
 
 // GetJob gets the mock job.
-func (s *Store) GetJob(id string) (*brigade.Job, error) {
+func (s *Store) GetJob(ctx context.Context, id string) (*brigade.Job, error) {
 	return s.Job, nil
 }
 
 // GetJobLog gets the mock log data.
-func (s *Store) GetJobLog(j *brigade.Job) (string, error) {
+func (s *Store) GetJobLog(ctx context.Context, j *brigade.Job) (string, error) {
 	return s.LogData, nil
 }
 
 // GetJobLogStream gets the mock log data as a readcloser.
-func (s *Store) GetJobLogStream(j *brigade.Job) (io.ReadCloser, error) {
+func (s *Store) GetJobLogStream(ctx context.Context, j *brigade.Job) (io.ReadCloser, error) {
 	return rc(s.LogData), nil
 }
 
 // GetJobLogStreamFollow gets the mock log data as a readcloser.
-func (s *Store) GetJobLogStreamFollow(j *brigade.Job) (io.ReadCloser, error) {
-	return s.GetJobLogStream(j)
+func (s *Store) GetJobLogStreamFollow(ctx context.Context, j *brigade.Job) (io.ReadCloser, error) {
+	return s.GetJobLogStream(ctx, j)
+}
+
+// GetJobLogStreamWithOptions gets the lines of the mock log data selected by
+// the tail and grep options.
+func (s *Store) GetJobLogStreamWithOptions(ctx context.Context, j *brigade.Job, opts storage.LogOptions) (io.ReadCloser, error) {
+	return s.filteredLog(opts)
 }
 
 // GetWorkerLog gets the mock log data.
-func (s *Store) GetWorkerLog(w *brigade.Worker) (string, error) {
+func (s *Store) GetWorkerLog(ctx context.Context, w *brigade.Worker) (string, error) {
 	return s.LogData, nil
 }
 
 // GetWorkerInitLog gets the mock log data.
-func (s *Store) GetWorkerInitLog(w *brigade.Worker) (string, error) {
+func (s *Store) GetWorkerInitLog(ctx context.Context, w *brigade.Worker) (string, error) {
 	return s.LogData, nil
 }
 
 // GetWorkerLogStream gets a readcloser of the mock log data.
-func (s *Store) GetWorkerLogStream(w *brigade.Worker) (io.ReadCloser, error) {
+func (s *Store) GetWorkerLogStream(ctx context.Context, w *brigade.Worker) (io.ReadCloser, error) {
 	return rc(s.LogData), nil
 }
 
+// GetWorkerLogStreamWithOptions gets the lines of the mock log data selected
+// by the tail and grep options.
+func (s *Store) GetWorkerLogStreamWithOptions(ctx context.Context, w *brigade.Worker, opts storage.LogOptions) (io.ReadCloser, error) {
+	return s.filteredLog(opts)
+}
+
+func (s *Store) filteredLog(opts storage.LogOptions) (io.ReadCloser, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	r, err := storage.TailLog(rc(s.LogData), opts.TailLines)
+	if err != nil {
+		return nil, err
+	}
+	return storage.GrepLog(r, opts.Grep)
+}
+
 // GetWorkerLogStreamFollow gets a readcloser of the mock log data.
-func (s *Store) GetWorkerLogStreamFollow(w *brigade.Worker) (io.ReadCloser, error) {
-	return s.GetWorkerLogStream(w)
+func (s *Store) GetWorkerLogStreamFollow(ctx context.Context, w *brigade.Worker) (io.ReadCloser, error) {
+	return s.GetWorkerLogStream(ctx, w)
 }
 
 // CreateBuild fakes a new build.
-func (s *Store) CreateBuild(b *brigade.Build) error {
+func (s *Store) CreateBuild(ctx context.Context, b *brigade.Build) error {
 	s.Builds = append(s.Builds, b)
 	return nil
 }
 
 // GetStorageClassNames returns the names of the StorageClass instances in the cluster
-func (s *Store) GetStorageClassNames() ([]string, error) {
+func (s *Store) GetStorageClassNames(ctx context.Context) ([]string, error) {
 	return []string{}, nil
 }
 
 // DeleteBuild fakes a build deletion.
-func (s *Store) DeleteBuild(bid string, options storage.DeleteBuildOptions) error {
+func (s *Store) DeleteBuild(ctx context.Context, bid string, options storage.DeleteBuildOptions) error {
 	return nil
 }
 
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
 
@@ -13,7 +14,7 @@ func TestStore(t *testing.T) {
 	m := New()
 
 	// Make sure we implement the interface.
-	var _ storage.Store = m
+	var _ storage.ContextStore = m
 
 	assertSame := func(label string, a, b interface{}) {
 		if !reflect.DeepEqual(a, b) {
@@ -27,52 +28,52 @@ func TestStore(t *testing.T) {
 	assertSame("log data", StubLogData, m.LogData)
 
 	// Exercise the methods, too.
-	p, _ := m.GetProjects()
+	p, _ := m.GetProjects(context.TODO())
 	assertSame("GetProjects", []*brigade.Project{StubProject}, p)
 
-	extraProj, _ := m.GetProject(StubProject.ID)
+	extraProj, _ := m.GetProject(context.TODO(), StubProject.ID)
 	assertSame("GetProject", StubProject, extraProj)
 
-	b1, _ := m.GetProjectBuilds(StubProject)
+	b1, _ := m.GetProjectBuilds(context.TODO(), StubProject)
 	assertSame("GetProjectBuilds", StubBuild1, b1[0])
 
-	b2, _ := m.GetBuilds()
+	b2, _ := m.GetBuilds(context.TODO())
 	assertSame("GetBuilds", StubBuild1, b2[0])
 
-	b3, _ := m.GetBuild(StubBuild1.ID)
+	b3, _ := m.GetBuild(context.TODO(), StubBuild1.ID)
 	assertSame("GetBuild", StubBuild1, b3)
 
-	j1, _ := m.GetBuildJobs(StubBuild1)
+	j1, _ := m.GetBuildJobs(context.TODO(), StubBuild1)
 	assertSame("GetBuildJobs", StubJob, j1[0])
 
-	j2, _ := m.GetJob(StubJob.ID)
+	j2, _ := m.GetJob(context.TODO(), StubJob.ID)
 	assertSame("GetJob", StubJob, j2)
 
-	w1, _ := m.GetWorker(StubBuild1.ID)
+	w1, _ := m.GetWorker(context.TODO(), StubBuild1.ID)
 	assertSame("GetWorker", StubWorker1, w1)
 
-	jl, _ := m.GetJobLog(StubJob)
+	jl, _ := m.GetJobLog(context.TODO(), StubJob)
 	assertSame("GetJobLog", StubLogData, jl)
 
-	jls, _ := m.GetJobLogStream(StubJob)
+	jls, _ := m.GetJobLogStream(context.TODO(), StubJob)
 	bjls := new(bytes.Buffer)
 	bjls.ReadFrom(jls)
 	assertSame("GetJobLogStream", StubLogData, bjls.String())
 
-	jlsf, _ := m.GetJobLogStreamFollow(StubJob)
+	jlsf, _ := m.GetJobLogStreamFollow(context.TODO(), StubJob)
 	bjlsf := new(bytes.Buffer)
 	bjlsf.ReadFrom(jlsf)
 	assertSame("GetJobLogStreamFollow", StubLogData, bjlsf.String())
 
-	wl, _ := m.GetWorkerLog(StubWorker1)
+	wl, _ := m.GetWorkerLog(context.TODO(), StubWorker1)
 	assertSame("GetWorkerLog", StubLogData, wl)
 
-	wls, _ := m.GetWorkerLogStream(StubWorker1)
+	wls, _ := m.GetWorkerLogStream(context.TODO(), StubWorker1)
 	bwls := new(bytes.Buffer)
 	bwls.ReadFrom(wls)
 	assertSame("GetWorkerLogStream", StubLogData, bwls.String())
 
-	wlsf, _ := m.GetWorkerLogStreamFollow(StubWorker1)
+	wlsf, _ := m.GetWorkerLogStreamFollow(context.TODO(), StubWorker1)
 	bwlsf := new(bytes.Buffer)
 	bwlsf.ReadFrom(wlsf)
 	assertSame("GetWorkerLogStreamFollow", StubLogData, bwlsf.String())
@@ -82,13 +83,13 @@ func TestStore(t *testing.T) {
 		ID:      "extra",
 		Secrets: map[string]interface{}{},
 	}
-	if err := m.CreateProject(extraProj); err != nil {
+	if err := m.CreateProject(context.TODO(), extraProj); err != nil {
 		t.Fatal(err)
 	}
 	if len(m.ProjectList) != 2 {
 		t.Fatal("project was not saved by CreateProject")
 	}
-	if err := m.DeleteProject("extra"); err != nil {
+	if err := m.DeleteProject(context.TODO(), "extra"); err != nil {
 		t.Fatal(err)
 	}
 	if len(m.ProjectList) != 1 {
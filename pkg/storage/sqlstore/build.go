@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+const buildColumns = `id, project_id, type, provider, short_title, long_title, clone_url,
+	commit_sha, ref, log_level, attempt, retry_of, retried_by, trigger_chain, payload, script, config,
+	worker_id, worker_start_time, worker_end_time, worker_exit_code, worker_status`
+
+// RecordBuild creates or updates a build in the history. The worker of the
+// build is only updated if it is set.
+func (s *Store) RecordBuild(ctx context.Context, build *brigade.Build) error {
+	rev := build.Revision
+	if rev == nil {
+		rev = &brigade.Revision{}
+	}
+	var chain []byte
+	if len(build.TriggerChain) > 0 {
+		var err error
+		if chain, err = json.Marshal(build.TriggerChain); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UnixNano()
+	_, err := s.exec(ctx, `INSERT INTO builds (id, project_id, type, provider, short_title, long_title, clone_url,
+		commit_sha, ref, log_level, attempt, retry_of, retried_by, trigger_chain, payload, script, config, recorded_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+		project_id = excluded.project_id, type = excluded.type, provider = excluded.provider,
+		short_title = excluded.short_title, long_title = excluded.long_title, clone_url = excluded.clone_url,
+		commit_sha = excluded.commit_sha, ref = excluded.ref, log_level = excluded.log_level,
+		attempt = excluded.attempt, retry_of = excluded.retry_of, retried_by = excluded.retried_by,
+		trigger_chain = excluded.trigger_chain,
+		payload = excluded.payload, script = excluded.script, config = excluded.config,
+		updated_at = excluded.updated_at`,
+		build.ID, build.ProjectID, build.Type, build.Provider, build.ShortTitle, build.LongTitle, build.CloneURL,
+		rev.Commit, rev.Ref, build.LogLevel, build.Attempt, build.RetryOf, build.RetriedBy, string(chain),
+		build.Payload, build.Script, build.Config, now, now)
+	if err != nil || build.Worker == nil {
+		return err
+	}
+	w := build.Worker
+	_, err = s.exec(ctx, `UPDATE builds SET worker_id = ?, worker_start_time = ?, worker_end_time = ?,
+		worker_exit_code = ?, worker_status = ? WHERE id = ?`,
+		w.ID, toNanos(w.StartTime), toNanos(w.EndTime), w.ExitCode, string(w.Status), build.ID)
+	return err
+}
+
+// GetBuilds returns all the builds of the history, oldest first.
+func (s *Store) GetBuilds(ctx context.Context) ([]*brigade.Build, error) {
+	return s.queryBuilds(ctx, `SELECT `+buildColumns+` FROM builds ORDER BY recorded_at`)
+}
+
+// GetProjectBuilds returns the builds of a project, oldest first.
+func (s *Store) GetProjectBuilds(ctx context.Context, proj *brigade.Project) ([]*brigade.Build, error) {
+	return s.queryBuilds(ctx, `SELECT `+buildColumns+` FROM builds WHERE project_id = ? ORDER BY recorded_at`, proj.ID)
+}
+
+// GetBuild returns a build of the history.
+func (s *Store) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
+	builds, err := s.queryBuilds(ctx, `SELECT `+buildColumns+` FROM builds WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(builds) == 0 {
+		return nil, ErrNotFound
+	}
+	return builds[0], nil
+}
+
+// GetWorker returns the worker of a build of the history.
+func (s *Store) GetWorker(ctx context.Context, buildID string) (*brigade.Worker, error) {
+	build, err := s.GetBuild(ctx, buildID)
+	if err != nil {
+		return nil, err
+	}
+	if build.Worker == nil {
+		return nil, ErrNotFound
+	}
+	return build.Worker, nil
+}
+
+func (s *Store) queryBuilds(ctx context.Context, query string, args ...interface{}) ([]*brigade.Build, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	builds := []*brigade.Build{}
+	for rows.Next() {
+		b, err := scanBuild(rows)
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, b)
+	}
+	return builds, rows.Err()
+}
+
+func scanBuild(rows *sql.Rows) (*brigade.Build, error) {
+	b := &brigade.Build{Revision: &brigade.Revision{}}
+	w := &brigade.Worker{}
+	var start, end int64
+	var status, chain string
+	err := rows.Scan(&b.ID, &b.ProjectID, &b.Type, &b.Provider, &b.ShortTitle, &b.LongTitle, &b.CloneURL,
+		&b.Revision.Commit, &b.Revision.Ref, &b.LogLevel, &b.Attempt, &b.RetryOf, &b.RetriedBy, &chain,
+		&b.Payload, &b.Script, &b.Config,
+		&w.ID, &start, &end, &w.ExitCode, &status)
+	if err != nil {
+		return nil, err
+	}
+	if chain != "" {
+		if err := json.Unmarshal([]byte(chain), &b.TriggerChain); err != nil {
+			return nil, err
+		}
+	}
+	if w.ID != "" {
+		w.BuildID = b.ID
+		w.ProjectID = b.ProjectID
+		w.StartTime = fromNanos(start)
+		w.EndTime = fromNanos(end)
+		w.Status = brigade.JobStatus(status)
+		b.Worker = w
+	}
+	return b, nil
+}
+
+// toNanos converts a time to the integer stored in the database, 0 for the
+// zero time.
+func toNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromNanos converts an integer stored in the database to a time.
+func fromNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
@@ -0,0 +1,104 @@
+package sqlstore
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+func TestRecordBuild(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	build := &brigade.Build{
+		ID:        "ahab",
+		ProjectID: "brigade-moby-dick",
+		Type:      "push",
+		Provider:  "github",
+		Revision:  &brigade.Revision{Commit: "c0ffee", Ref: "refs/heads/master"},
+		Payload:   []byte("payload"),
+		Script:    []byte("script"),
+		Attempt:   2,
+		RetryOf:   "ishmael",
+
+		TriggerChain: []brigade.TriggerLink{{ProjectID: "brigade-harpoons", BuildID: "queequeg"}},
+	}
+	if err := s.RecordBuild(context.TODO(), build); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetBuild(context.TODO(), "ahab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, build) {
+		t.Errorf("expected %#v, got %#v", build, got)
+	}
+	if _, err := s.GetWorker(context.TODO(), "ahab"); err != ErrNotFound {
+		t.Errorf("expected no worker, got %v", err)
+	}
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	build.Worker = &brigade.Worker{
+		ID:        "brigade-worker-ahab",
+		BuildID:   "ahab",
+		ProjectID: "brigade-moby-dick",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+		ExitCode:  1,
+		Status:    brigade.JobFailed,
+	}
+	if err := s.RecordBuild(context.TODO(), build); err != nil {
+		t.Fatal(err)
+	}
+	// The worker is kept when the build is recorded without it.
+	if err := s.RecordBuild(context.TODO(), &brigade.Build{ID: "ahab", ProjectID: "brigade-moby-dick", RetriedBy: "starbuck"}); err != nil {
+		t.Fatal(err)
+	}
+	worker, err := s.GetWorker(context.TODO(), "ahab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(worker, build.Worker) {
+		t.Errorf("expected worker %#v, got %#v", build.Worker, worker)
+	}
+	if got, _ := s.GetBuild(context.TODO(), "ahab"); got.RetriedBy != "starbuck" {
+		t.Errorf("expected the build to be updated, got %#v", got)
+	}
+
+	if _, err := s.GetBuild(context.TODO(), "queequeg"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetProjectBuilds(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	for _, b := range []*brigade.Build{
+		{ID: "ahab", ProjectID: "brigade-moby-dick"},
+		{ID: "nemo", ProjectID: "brigade-nautilus"},
+		{ID: "ishmael", ProjectID: "brigade-moby-dick"},
+	} {
+		if err := s.RecordBuild(context.TODO(), b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	builds, err := s.GetProjectBuilds(context.TODO(), &brigade.Project{ID: "brigade-moby-dick"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 2 || builds[0].ID != "ahab" || builds[1].ID != "ishmael" {
+		t.Errorf("unexpected builds %v", builds)
+	}
+	all, err := s.GetBuilds(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 builds, got %d", len(all))
+	}
+}
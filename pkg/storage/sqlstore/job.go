@@ -0,0 +1,61 @@
+package sqlstore
+
+import (
+	"context"
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+const jobColumns = `id, name, image, creation_time, start_time, end_time, exit_code, status`
+
+// RecordJob creates or updates a job of a build in the history.
+func (s *Store) RecordJob(ctx context.Context, buildID string, job *brigade.Job) error {
+	_, err := s.exec(ctx, `INSERT INTO jobs (id, build_id, name, image, creation_time, start_time, end_time, exit_code, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+		build_id = excluded.build_id, name = excluded.name, image = excluded.image,
+		creation_time = excluded.creation_time, start_time = excluded.start_time, end_time = excluded.end_time,
+		exit_code = excluded.exit_code, status = excluded.status`,
+		job.ID, buildID, job.Name, job.Image,
+		toNanos(job.CreationTime), toNanos(job.StartTime), toNanos(job.EndTime), job.ExitCode, string(job.Status))
+	return err
+}
+
+// GetBuildJobs returns the jobs of a build of the history, in creation order.
+func (s *Store) GetBuildJobs(ctx context.Context, build *brigade.Build) ([]*brigade.Job, error) {
+	return s.queryJobs(ctx, `SELECT `+jobColumns+` FROM jobs WHERE build_id = ? ORDER BY creation_time, id`, build.ID)
+}
+
+// GetJob returns a job of the history.
+func (s *Store) GetJob(ctx context.Context, id string) (*brigade.Job, error) {
+	jobs, err := s.queryJobs(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, ErrNotFound
+	}
+	return jobs[0], nil
+}
+
+func (s *Store) queryJobs(ctx context.Context, query string, args ...interface{}) ([]*brigade.Job, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	jobs := []*brigade.Job{}
+	for rows.Next() {
+		j := &brigade.Job{}
+		var created, start, end int64
+		var status string
+		if err := rows.Scan(&j.ID, &j.Name, &j.Image, &created, &start, &end, &j.ExitCode, &status); err != nil {
+			return nil, err
+		}
+		j.CreationTime = fromNanos(created)
+		j.StartTime = fromNanos(start)
+		j.EndTime = fromNanos(end)
+		j.Status = brigade.JobStatus(status)
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
@@ -0,0 +1,55 @@
+package sqlstore
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+)
+
+func TestRecordJob(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	jobs := []*brigade.Job{
+		{ID: "test-ahab", Name: "test", Image: "alpine", CreationTime: created, Status: brigade.JobRunning},
+		{ID: "build-ahab", Name: "build", Image: "golang", CreationTime: created.Add(-time.Minute), Status: brigade.JobPending},
+	}
+	for _, j := range jobs {
+		if err := s.RecordJob(context.TODO(), "ahab", j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs[0].StartTime = created.Add(time.Second)
+	jobs[0].EndTime = created.Add(time.Minute)
+	jobs[0].ExitCode = 2
+	jobs[0].Status = brigade.JobFailed
+	if err := s.RecordJob(context.TODO(), "ahab", jobs[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := s.GetJob(context.TODO(), "test-ahab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(job, jobs[0]) {
+		t.Errorf("expected %#v, got %#v", jobs[0], job)
+	}
+
+	got, err := s.GetBuildJobs(context.TODO(), &brigade.Build{ID: "ahab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != "build-ahab" || got[1].ID != "test-ahab" {
+		t.Errorf("expected the jobs in creation order, got %v", got)
+	}
+	if got, _ := s.GetBuildJobs(context.TODO(), &brigade.Build{ID: "ishmael"}); len(got) != 0 {
+		t.Errorf("expected no jobs, got %v", got)
+	}
+	if _, err := s.GetJob(context.TODO(), "missing"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
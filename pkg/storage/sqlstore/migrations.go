@@ -0,0 +1,111 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// migrations are the changes to the schema, in order. The version of the
+// schema is the number of migrations applied. Released migrations must not be
+// changed, add a new one instead.
+//
+// {{blob}} is replaced by the binary type of the driver.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS builds (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		type TEXT NOT NULL DEFAULT '',
+		provider TEXT NOT NULL DEFAULT '',
+		short_title TEXT NOT NULL DEFAULT '',
+		long_title TEXT NOT NULL DEFAULT '',
+		clone_url TEXT NOT NULL DEFAULT '',
+		commit_sha TEXT NOT NULL DEFAULT '',
+		ref TEXT NOT NULL DEFAULT '',
+		log_level TEXT NOT NULL DEFAULT '',
+		attempt INTEGER NOT NULL DEFAULT 0,
+		retry_of TEXT NOT NULL DEFAULT '',
+		retried_by TEXT NOT NULL DEFAULT '',
+		payload {{blob}},
+		script {{blob}},
+		config {{blob}},
+		recorded_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		worker_id TEXT NOT NULL DEFAULT '',
+		worker_start_time BIGINT NOT NULL DEFAULT 0,
+		worker_end_time BIGINT NOT NULL DEFAULT 0,
+		worker_exit_code INTEGER NOT NULL DEFAULT 0,
+		worker_status TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS builds_project_id ON builds (project_id, recorded_at)`,
+	`CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		build_id TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		image TEXT NOT NULL DEFAULT '',
+		creation_time BIGINT NOT NULL DEFAULT 0,
+		start_time BIGINT NOT NULL DEFAULT 0,
+		end_time BIGINT NOT NULL DEFAULT 0,
+		exit_code INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS jobs_build_id ON jobs (build_id)`,
+	`ALTER TABLE builds ADD COLUMN trigger_chain TEXT NOT NULL DEFAULT ''`,
+}
+
+// Migrate applies the migrations that were not applied to the database yet.
+//
+// Several components can migrate the same database when they start: each
+// migration is claimed by recording its version in the same transaction,
+// before it is applied, so that only one of them applies it. The others wait
+// for that transaction and skip the migration once it is committed.
+func (s *Store) Migrate(ctx context.Context) error {
+	if _, err := s.exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("failed to create the migrations table: %s", err)
+	}
+	version, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+	blob := "BLOB"
+	if s.driver == DriverPostgres {
+		blob = "BYTEA"
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := s.migrate(ctx, tx, i+1, strings.Replace(migrations[i], "{{blob}}", blob, -1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate the database to version %d: %s", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) migrate(ctx context.Context, tx *sql.Tx, version int, migration string) error {
+	res, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version) VALUES (?) ON CONFLICT (version) DO NOTHING`), version)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		// Another component applied the migration since the version was read.
+		return err
+	}
+	_, err = tx.ExecContext(ctx, migration)
+	return err
+}
+
+// Version returns the version of the schema of the database.
+func (s *Store) Version(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	if err := s.queryRow(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
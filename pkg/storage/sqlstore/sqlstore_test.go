@@ -0,0 +1,87 @@
+package sqlstore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openTestStore opens a store in a new SQLite database.
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestOpenURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brigade-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := OpenURL("sqlite3://" + filepath.Join(dir, "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := s.Version(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("expected version %d, got %d", len(migrations), version)
+	}
+	s.Close()
+
+	// Reopening the database does not migrate it again.
+	s, err = OpenURL("sqlite://" + filepath.Join(dir, "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.Migrate(context.TODO()); err != nil {
+		t.Errorf("expected migrations to be idempotent, got %s", err)
+	}
+
+	for _, u := range []string{"history.db", "mysql://localhost/history"} {
+		if _, err := OpenURL(u); err == nil {
+			t.Errorf("expected an error for URL %q", u)
+		}
+	}
+}
+
+func TestMigrate_AlreadyApplied(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	// Another component applied the last migration after this one read the
+	// version of the database.
+	tx, err := s.db.BeginTx(context.TODO(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err := s.migrate(context.TODO(), tx, len(migrations), migrations[len(migrations)-1]); err != nil {
+		t.Errorf("expected a migration that was already applied to be skipped, got %s", err)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	for _, tt := range []struct {
+		driver   string
+		expected string
+	}{
+		{DriverSQLite, "SELECT id FROM builds WHERE id = ? AND project_id = ?"},
+		{DriverPostgres, "SELECT id FROM builds WHERE id = $1 AND project_id = $2"},
+	} {
+		s := &Store{driver: tt.driver}
+		if q := s.rebind("SELECT id FROM builds WHERE id = ? AND project_id = ?"); q != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.driver, tt.expected, q)
+		}
+	}
+}
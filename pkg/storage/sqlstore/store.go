@@ -0,0 +1,108 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage"
+)
+
+// store reads builds and jobs from the history once they are gone from the
+// underlying store.
+type store struct {
+	storage.ContextStore
+	history storage.HistoryStore
+}
+
+// WithHistory wraps a store so that the builds and jobs of the history remain
+// readable after they were deleted. Build lists include the builds of the
+// history.
+func WithHistory(s storage.ContextStore, history storage.HistoryStore) storage.ContextStore {
+	return &store{ContextStore: s, history: history}
+}
+
+func (s *store) GetBuilds(ctx context.Context) ([]*brigade.Build, error) {
+	builds, err := s.ContextStore.GetBuilds(ctx)
+	if err != nil {
+		return builds, err
+	}
+	past, err := s.history.GetBuilds(ctx)
+	if err != nil {
+		logging.Errorf("failed to get builds from the history: %s", err)
+		return builds, nil
+	}
+	return mergeBuilds(builds, past), nil
+}
+
+func (s *store) GetProjectBuilds(ctx context.Context, proj *brigade.Project) ([]*brigade.Build, error) {
+	builds, err := s.ContextStore.GetProjectBuilds(ctx, proj)
+	if err != nil {
+		return builds, err
+	}
+	past, err := s.history.GetProjectBuilds(ctx, proj)
+	if err != nil {
+		logging.Errorf("failed to get builds of project %s from the history: %s", proj.ID, err)
+		return builds, nil
+	}
+	return mergeBuilds(builds, past), nil
+}
+
+func (s *store) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
+	build, err := s.ContextStore.GetBuild(ctx, id)
+	if err == nil {
+		return build, nil
+	}
+	if past, herr := s.history.GetBuild(ctx, id); herr == nil {
+		return past, nil
+	}
+	return build, err
+}
+
+func (s *store) GetWorker(ctx context.Context, buildID string) (*brigade.Worker, error) {
+	worker, err := s.ContextStore.GetWorker(ctx, buildID)
+	if err == nil {
+		return worker, nil
+	}
+	if past, herr := s.history.GetWorker(ctx, buildID); herr == nil {
+		return past, nil
+	}
+	return worker, err
+}
+
+func (s *store) GetBuildJobs(ctx context.Context, build *brigade.Build) ([]*brigade.Job, error) {
+	jobs, err := s.ContextStore.GetBuildJobs(ctx, build)
+	if err != nil || len(jobs) > 0 {
+		return jobs, err
+	}
+	if past, herr := s.history.GetBuildJobs(ctx, build); herr == nil {
+		return past, nil
+	}
+	return jobs, nil
+}
+
+func (s *store) GetJob(ctx context.Context, id string) (*brigade.Job, error) {
+	job, err := s.ContextStore.GetJob(ctx, id)
+	if err == nil {
+		return job, nil
+	}
+	if past, herr := s.history.GetJob(ctx, id); herr == nil {
+		return past, nil
+	}
+	return job, err
+}
+
+// mergeBuilds returns the current builds followed by the builds of the history
+// that are not current anymore.
+func mergeBuilds(current, past []*brigade.Build) []*brigade.Build {
+	ids := map[string]bool{}
+	for _, b := range current {
+		ids[b.ID] = true
+	}
+	for _, b := range past {
+		if !ids[b.ID] {
+			current = append(current, b)
+		}
+	}
+	return current
+}
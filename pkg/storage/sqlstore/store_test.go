@@ -0,0 +1,77 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/storage/mock"
+)
+
+// goneStore behaves like a store whose builds were deleted, except for the
+// builds of the mock.
+type goneStore struct {
+	*mock.Store
+}
+
+var errGone = errors.New("gone")
+
+func (s goneStore) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
+	return nil, errGone
+}
+func (s goneStore) GetWorker(ctx context.Context, id string) (*brigade.Worker, error) {
+	return nil, errGone
+}
+func (s goneStore) GetJob(ctx context.Context, id string) (*brigade.Job, error) { return nil, errGone }
+func (s goneStore) GetBuildJobs(ctx context.Context, b *brigade.Build) ([]*brigade.Job, error) {
+	return []*brigade.Job{}, nil
+}
+
+func TestWithHistory(t *testing.T) {
+	history := openTestStore(t)
+	defer history.Close()
+
+	// The history holds a current build, and a build that was deleted.
+	past := &brigade.Build{ID: "ahab", ProjectID: mock.StubProject.ID, Worker: &brigade.Worker{ID: "brigade-worker-ahab", Status: brigade.JobSucceeded}}
+	for _, b := range []*brigade.Build{mock.StubBuild1, past} {
+		if err := history.RecordBuild(context.TODO(), b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := history.RecordJob(context.TODO(), "ahab", &brigade.Job{ID: "test-ahab", Status: brigade.JobSucceeded}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := WithHistory(goneStore{mock.New()}, history)
+
+	builds, err := s.GetProjectBuilds(context.TODO(), mock.StubProject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 3 || builds[0] != mock.StubBuild1 || builds[2].ID != "ahab" {
+		t.Errorf("expected the current builds followed by the deleted build, got %v", builds)
+	}
+	if builds, _ := s.GetBuilds(context.TODO()); len(builds) != 3 {
+		t.Errorf("expected 3 builds, got %d", len(builds))
+	}
+
+	build, err := s.GetBuild(context.TODO(), "ahab")
+	if err != nil || build.ID != "ahab" {
+		t.Errorf("expected the build from the history, got %v: %v", build, err)
+	}
+	worker, err := s.GetWorker(context.TODO(), "ahab")
+	if err != nil || worker.ID != "brigade-worker-ahab" {
+		t.Errorf("expected the worker from the history, got %v: %v", worker, err)
+	}
+	jobs, err := s.GetBuildJobs(context.TODO(), build)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != "test-ahab" {
+		t.Errorf("expected the jobs from the history, got %v: %v", jobs, err)
+	}
+	if job, err := s.GetJob(context.TODO(), "test-ahab"); err != nil || job.ID != "test-ahab" {
+		t.Errorf("expected the job from the history, got %v: %v", job, err)
+	}
+	if _, err := s.GetBuild(context.TODO(), "missing"); err != errGone {
+		t.Errorf("expected the error of the underlying store, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"io"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
@@ -11,7 +12,86 @@ type DeleteBuildOptions struct {
 	SkipRunningBuilds bool
 }
 
+// ContextProjectStore represents storage for projects.
+type ContextProjectStore interface {
+	// GetProjects retrieves all projects from storage.
+	GetProjects(ctx context.Context) ([]*brigade.Project, error)
+	// GetProject retrieves the project from storage.
+	GetProject(ctx context.Context, id string) (*brigade.Project, error)
+	// GetProjectBuilds retrieves the project's builds from storage.
+	GetProjectBuilds(ctx context.Context, proj *brigade.Project) ([]*brigade.Build, error)
+	// CreateProject creates a new project record in storage.
+	CreateProject(ctx context.Context, proj *brigade.Project) error
+	// ReplaceProject replaces a project record in storage.
+	ReplaceProject(ctx context.Context, proj *brigade.Project) error
+	// DeleteProject deletes a project from storage.
+	DeleteProject(ctx context.Context, id string) error
+}
+
+// HistoryStore represents storage for the history of builds and jobs.
+type HistoryStore interface {
+	// GetBuilds retrieves all builds from storage.
+	GetBuilds(ctx context.Context) ([]*brigade.Build, error)
+	// GetBuild retrieves the build from storage.
+	GetBuild(ctx context.Context, id string) (*brigade.Build, error)
+	// GetProjectBuilds retrieves the project's builds from storage.
+	GetProjectBuilds(ctx context.Context, proj *brigade.Project) ([]*brigade.Build, error)
+	// GetBuildJobs retrieves all build jobs from storage.
+	GetBuildJobs(ctx context.Context, build *brigade.Build) ([]*brigade.Job, error)
+	// GetWorker returns the worker for a given build.
+	GetWorker(ctx context.Context, buildID string) (*brigade.Worker, error)
+	// GetJob retrieves the job from storage.
+	GetJob(ctx context.Context, id string) (*brigade.Job, error)
+}
+
+// HistoryRecorder records the state of builds and jobs in a history store.
+type HistoryRecorder interface {
+	// RecordBuild creates or updates the build, and its worker if set.
+	RecordBuild(ctx context.Context, build *brigade.Build) error
+	// RecordJob creates or updates a job of a build.
+	RecordJob(ctx context.Context, buildID string, job *brigade.Job) error
+}
+
+// ContextStore represents a storage engine for a brigade projects, builds, and
+// jobs.
+//
+// It is the context-aware variant of Store. Every method takes the context of
+// the request it serves, so that it stops when the request is cancelled or
+// times out. Log streams are closed when their context is done.
+type ContextStore interface {
+	ContextProjectStore
+	HistoryStore
+	// DeleteBuild deletes the build from storage.
+	DeleteBuild(ctx context.Context, id string, options DeleteBuildOptions) error
+	// CreateBuild creates a new job for the work queue.
+	CreateBuild(ctx context.Context, build *brigade.Build) error
+	// GetBuildEvents retrieves the events recorded about a build and its worker.
+	GetBuildEvents(ctx context.Context, id string) ([]*brigade.BuildEvent, error)
+	// GetJobLog retrieves all logs for a job from storage.
+	GetJobLog(ctx context.Context, job *brigade.Job) (string, error)
+	// GetJobLogStream retrieve a stream of all logs for a job from storage.
+	GetJobLogStream(ctx context.Context, job *brigade.Job) (io.ReadCloser, error)
+	// GetJobLogStreamFollow retrieve a follow stream of all logs for a job from storage.
+	GetJobLogStreamFollow(ctx context.Context, job *brigade.Job) (io.ReadCloser, error)
+	// GetJobLogStreamWithOptions retrieve a stream of the part of the logs for a job selected by the options.
+	GetJobLogStreamWithOptions(ctx context.Context, job *brigade.Job, opts LogOptions) (io.ReadCloser, error)
+	// GetWorkerInitLog retrieves all logs for a worker's init container from storage.
+	GetWorkerInitLog(ctx context.Context, job *brigade.Worker) (string, error)
+	// GetWorkerLog retrieves all logs for a worker from storage.
+	GetWorkerLog(ctx context.Context, job *brigade.Worker) (string, error)
+	// GetWorkerLogStream retrieve a stream of all logs for a worker from storage.
+	GetWorkerLogStream(ctx context.Context, job *brigade.Worker) (io.ReadCloser, error)
+	// GetWorkerLogStreamFollow retrieve a followed stream of all logs for a worker from storage.
+	GetWorkerLogStreamFollow(ctx context.Context, job *brigade.Worker) (io.ReadCloser, error)
+	// GetWorkerLogStreamWithOptions retrieve a stream of the part of the logs for a worker selected by the options.
+	GetWorkerLogStreamWithOptions(ctx context.Context, worker *brigade.Worker, opts LogOptions) (io.ReadCloser, error)
+	// GetStorageClassNames returns the names of the StorageClass instances in the cluster
+	GetStorageClassNames(ctx context.Context) ([]string, error)
+}
+
 // ProjectStore represents storage for projects.
+//
+// Deprecated: use ContextProjectStore.
 type ProjectStore interface {
 	// GetProjects retrieves all projects from storage.
 	GetProjects() ([]*brigade.Project, error)
@@ -28,6 +108,9 @@ type ProjectStore interface {
 }
 
 // Store represents a storage engine for a brigade projects, builds, and jobs.
+//
+// Deprecated: use ContextStore, and WithoutContext for the packages that still
+// need a Store.
 type Store interface {
 	ProjectStore
 	// GetBuilds retrieves all active builds from storage.
@@ -38,6 +121,8 @@ type Store interface {
 	DeleteBuild(id string, options DeleteBuildOptions) error
 	// CreateBuild creates a new job for the work queue.
 	CreateBuild(build *brigade.Build) error
+	// GetBuildEvents retrieves the events recorded about a build and its worker.
+	GetBuildEvents(id string) ([]*brigade.BuildEvent, error)
 	// GetBuildJobs retrieves all build jobs (pods) from storage.
 	GetBuildJobs(build *brigade.Build) ([]*brigade.Job, error)
 	// GetWorker returns the worker for a given build.
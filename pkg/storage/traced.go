@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+// Traced wraps a ContextStore so that every call is traced as a child span of
+// the span of its context. Log streams are traced until they are opened.
+func Traced(s ContextStore) ContextStore {
+	return tracedStore{s}
+}
+
+type tracedStore struct {
+	s ContextStore
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "storage."+name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends the span of a call, recording its error if any.
+func endSpan(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	return err
+}
+
+func projectAttr(id string) attribute.KeyValue {
+	return attribute.String("project_id", id)
+}
+
+func buildAttr(id string) attribute.KeyValue {
+	return attribute.String("build_id", id)
+}
+
+func jobAttr(id string) attribute.KeyValue {
+	return attribute.String("job_id", id)
+}
+
+func (t tracedStore) GetProjects(ctx context.Context) ([]*brigade.Project, error) {
+	ctx, span := startSpan(ctx, "GetProjects")
+	projects, err := t.s.GetProjects(ctx)
+	return projects, endSpan(span, err)
+}
+
+func (t tracedStore) GetProject(ctx context.Context, id string) (*brigade.Project, error) {
+	ctx, span := startSpan(ctx, "GetProject", projectAttr(id))
+	proj, err := t.s.GetProject(ctx, id)
+	return proj, endSpan(span, err)
+}
+
+func (t tracedStore) GetProjectBuilds(ctx context.Context, proj *brigade.Project) ([]*brigade.Build, error) {
+	ctx, span := startSpan(ctx, "GetProjectBuilds", projectAttr(proj.ID))
+	builds, err := t.s.GetProjectBuilds(ctx, proj)
+	return builds, endSpan(span, err)
+}
+
+func (t tracedStore) CreateProject(ctx context.Context, proj *brigade.Project) error {
+	ctx, span := startSpan(ctx, "CreateProject", projectAttr(proj.ID))
+	return endSpan(span, t.s.CreateProject(ctx, proj))
+}
+
+func (t tracedStore) ReplaceProject(ctx context.Context, proj *brigade.Project) error {
+	ctx, span := startSpan(ctx, "ReplaceProject", projectAttr(proj.ID))
+	return endSpan(span, t.s.ReplaceProject(ctx, proj))
+}
+
+func (t tracedStore) DeleteProject(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "DeleteProject", projectAttr(id))
+	return endSpan(span, t.s.DeleteProject(ctx, id))
+}
+
+func (t tracedStore) GetBuilds(ctx context.Context) ([]*brigade.Build, error) {
+	ctx, span := startSpan(ctx, "GetBuilds")
+	builds, err := t.s.GetBuilds(ctx)
+	return builds, endSpan(span, err)
+}
+
+func (t tracedStore) GetBuild(ctx context.Context, id string) (*brigade.Build, error) {
+	ctx, span := startSpan(ctx, "GetBuild", buildAttr(id))
+	build, err := t.s.GetBuild(ctx, id)
+	return build, endSpan(span, err)
+}
+
+func (t tracedStore) GetBuildJobs(ctx context.Context, build *brigade.Build) ([]*brigade.Job, error) {
+	ctx, span := startSpan(ctx, "GetBuildJobs", buildAttr(build.ID))
+	jobs, err := t.s.GetBuildJobs(ctx, build)
+	return jobs, endSpan(span, err)
+}
+
+func (t tracedStore) GetWorker(ctx context.Context, buildID string) (*brigade.Worker, error) {
+	ctx, span := startSpan(ctx, "GetWorker", buildAttr(buildID))
+	worker, err := t.s.GetWorker(ctx, buildID)
+	return worker, endSpan(span, err)
+}
+
+func (t tracedStore) GetJob(ctx context.Context, id string) (*brigade.Job, error) {
+	ctx, span := startSpan(ctx, "GetJob", jobAttr(id))
+	job, err := t.s.GetJob(ctx, id)
+	return job, endSpan(span, err)
+}
+
+func (t tracedStore) DeleteBuild(ctx context.Context, id string, options DeleteBuildOptions) error {
+	ctx, span := startSpan(ctx, "DeleteBuild", buildAttr(id))
+	return endSpan(span, t.s.DeleteBuild(ctx, id, options))
+}
+
+func (t tracedStore) CreateBuild(ctx context.Context, build *brigade.Build) error {
+	ctx, span := startSpan(ctx, "CreateBuild", projectAttr(build.ProjectID))
+	err := t.s.CreateBuild(ctx, build)
+	span.SetAttributes(buildAttr(build.ID))
+	return endSpan(span, err)
+}
+
+func (t tracedStore) GetBuildEvents(ctx context.Context, id string) ([]*brigade.BuildEvent, error) {
+	ctx, span := startSpan(ctx, "GetBuildEvents", buildAttr(id))
+	events, err := t.s.GetBuildEvents(ctx, id)
+	return events, endSpan(span, err)
+}
+
+func (t tracedStore) GetJobLog(ctx context.Context, job *brigade.Job) (string, error) {
+	ctx, span := startSpan(ctx, "GetJobLog", jobAttr(job.ID))
+	log, err := t.s.GetJobLog(ctx, job)
+	return log, endSpan(span, err)
+}
+
+func (t tracedStore) GetJobLogStream(ctx context.Context, job *brigade.Job) (io.ReadCloser, error) {
+	ctx, span := startSpan(ctx, "GetJobLogStream", jobAttr(job.ID))
+	r, err := t.s.GetJobLogStream(ctx, job)
+	return r, endSpan(span, err)
+}
+
+func (t tracedStore) GetJobLogStreamFollow(ctx context.Context, job *brigade.Job) (io.ReadCloser, error) {
+	ctx, span := startSpan(ctx, "GetJobLogStreamFollow", jobAttr(job.ID))
+	r, err := t.s.GetJobLogStreamFollow(ctx, job)
+	return r, endSpan(span, err)
+}
+
+func (t tracedStore) GetJobLogStreamWithOptions(ctx context.Context, job *brigade.Job, opts LogOptions) (io.ReadCloser, error) {
+	ctx, span := startSpan(ctx, "GetJobLogStreamWithOptions", jobAttr(job.ID))
+	r, err := t.s.GetJobLogStreamWithOptions(ctx, job, opts)
+	return r, endSpan(span, err)
+}
+
+func (t tracedStore) GetWorkerInitLog(ctx context.Context, worker *brigade.Worker) (string, error) {
+	ctx, span := startSpan(ctx, "GetWorkerInitLog", buildAttr(worker.BuildID))
+	log, err := t.s.GetWorkerInitLog(ctx, worker)
+	return log, endSpan(span, err)
+}
+
+func (t tracedStore) GetWorkerLog(ctx context.Context, worker *brigade.Worker) (string, error) {
+	ctx, span := startSpan(ctx, "GetWorkerLog", buildAttr(worker.BuildID))
+	log, err := t.s.GetWorkerLog(ctx, worker)
+	return log, endSpan(span, err)
+}
+
+func (t tracedStore) GetWorkerLogStream(ctx context.Context, worker *brigade.Worker) (io.ReadCloser, error) {
+	ctx, span := startSpan(ctx, "GetWorkerLogStream", buildAttr(worker.BuildID))
+	r, err := t.s.GetWorkerLogStream(ctx, worker)
+	return r, endSpan(span, err)
+}
+
+func (t tracedStore) GetWorkerLogStreamFollow(ctx context.Context, worker *brigade.Worker) (io.ReadCloser, error) {
+	ctx, span := startSpan(ctx, "GetWorkerLogStreamFollow", buildAttr(worker.BuildID))
+	r, err := t.s.GetWorkerLogStreamFollow(ctx, worker)
+	return r, endSpan(span, err)
+}
+
+func (t tracedStore) GetWorkerLogStreamWithOptions(ctx context.Context, worker *brigade.Worker, opts LogOptions) (io.ReadCloser, error) {
+	ctx, span := startSpan(ctx, "GetWorkerLogStreamWithOptions", buildAttr(worker.BuildID))
+	r, err := t.s.GetWorkerLogStreamWithOptions(ctx, worker, opts)
+	return r, endSpan(span, err)
+}
+
+func (t tracedStore) GetStorageClassNames(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetStorageClassNames")
+	names, err := t.s.GetStorageClassNames(ctx)
+	return names, endSpan(span, err)
+}
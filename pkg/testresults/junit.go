@@ -0,0 +1,101 @@
+package testresults
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name string `xml:"name,attr"`
+	// Suites are nested suites, which some runners write.
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+	SystemErr string        `xml:"system-err"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parseJUnit reads the test cases of a JUnit XML report, whose root is either
+// <testsuites> or <testsuite>.
+func parseJUnit(data []byte) ([]Case, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "testsuites":
+			var suites junitSuites
+			if err := d.DecodeElement(&suites, &start); err != nil {
+				return nil, err
+			}
+			var cases []Case
+			for _, s := range suites.Suites {
+				cases = s.appendCases(cases)
+			}
+			return cases, nil
+		case "testsuite":
+			var suite junitSuite
+			if err := d.DecodeElement(&suite, &start); err != nil {
+				return nil, err
+			}
+			return suite.appendCases(nil), nil
+		default:
+			return nil, errors.New("the root element is neither <testsuites> nor <testsuite>")
+		}
+	}
+}
+
+func (s junitSuite) appendCases(cases []Case) []Case {
+	for _, c := range s.Cases {
+		result := Case{Suite: c.Classname, Name: c.Name, Status: StatusPassed}
+		if result.Suite == "" {
+			result.Suite = s.Name
+		}
+		// Some runners write times with thousands separators.
+		result.Time, _ = strconv.ParseFloat(strings.Replace(c.Time, ",", "", -1), 64)
+		failure := c.Failure
+		if failure == nil {
+			failure = c.Error
+		}
+		switch {
+		case failure != nil:
+			result.Status = StatusFailed
+			result.Message = failure.Message
+			result.Output = strings.TrimSpace(strings.Join([]string{failure.Text, c.SystemOut, c.SystemErr}, "\n"))
+		case c.Skipped != nil:
+			result.Status = StatusSkipped
+			result.Message = c.Skipped.Message
+		}
+		cases = append(cases, result)
+	}
+	for _, nested := range s.Suites {
+		cases = nested.appendCases(cases)
+	}
+	return cases
+}
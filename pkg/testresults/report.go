@@ -0,0 +1,131 @@
+package testresults
+
+import (
+	"sort"
+)
+
+// Report summarizes the test results of a build.
+type Report struct {
+	BuildID string `json:"build_id"`
+	Counts
+	Jobs []JobReport `json:"jobs"`
+}
+
+// JobReport summarizes the test results of a job.
+type JobReport struct {
+	Job    string `json:"job"`
+	Format string `json:"format"`
+	Counts
+	// Failures are the failed test cases.
+	Failures []Case `json:"failures"`
+}
+
+// NewReport summarizes the test results of the jobs of a build.
+func NewReport(buildID string, results []*Results) *Report {
+	report := &Report{BuildID: buildID, Jobs: []JobReport{}}
+	for _, r := range results {
+		job := JobReport{Job: r.Job, Format: r.Format, Counts: r.Counts, Failures: []Case{}}
+		for _, c := range r.Cases {
+			if c.Status == StatusFailed {
+				job.Failures = append(job.Failures, c)
+			}
+		}
+		report.Total += r.Total
+		report.Passed += r.Passed
+		report.Failed += r.Failed
+		report.Skipped += r.Skipped
+		report.Jobs = append(report.Jobs, job)
+	}
+	return report
+}
+
+// MinFlips is the number of times a test must change from passing to failing
+// or back to be flaky, if it never passed and failed on the same commit.
+var MinFlips = 3
+
+// BuildResults are the test results of a build.
+type BuildResults struct {
+	BuildID string
+	// Commit is the commit of the build, if any.
+	Commit  string
+	Results []*Results
+}
+
+// FlakyTest is a test whose result changes across builds without a reason.
+type FlakyTest struct {
+	Job    string `json:"job"`
+	Suite  string `json:"suite"`
+	Name   string `json:"name"`
+	Passed int    `json:"passed"`
+	Failed int    `json:"failed"`
+	// Flips is the number of times the test changed from passing to failing
+	// or back.
+	Flips int `json:"flips"`
+	// Commits are the commits the test both passed and failed on.
+	Commits []string `json:"commits,omitempty"`
+	// LastFailure is the ID of the last build the test failed in.
+	LastFailure string `json:"last_failure"`
+}
+
+// FindFlaky returns the tests that both passed and failed on the same commit,
+// or that flipped at least MinFlips times, in the given builds of a project,
+// the oldest first. The flakiest tests come first.
+func FindFlaky(builds []BuildResults) []FlakyTest {
+	type key struct{ job, suite, name string }
+	type history struct {
+		FlakyTest
+		last    string
+		commits map[string]string
+	}
+	tests := map[key]*history{}
+	var order []key
+	for _, b := range builds {
+		for _, r := range b.Results {
+			for _, c := range r.Cases {
+				if c.Status == StatusSkipped {
+					continue
+				}
+				k := key{r.Job, c.Suite, c.Name}
+				h := tests[k]
+				if h == nil {
+					h = &history{FlakyTest: FlakyTest{Job: r.Job, Suite: c.Suite, Name: c.Name}, commits: map[string]string{}}
+					tests[k] = h
+					order = append(order, k)
+				}
+				if h.last != "" && h.last != c.Status {
+					h.Flips++
+				}
+				h.last = c.Status
+				if c.Status == StatusFailed {
+					h.Failed++
+					h.LastFailure = b.BuildID
+				} else {
+					h.Passed++
+				}
+				if b.Commit == "" {
+					continue
+				}
+				if prev, ok := h.commits[b.Commit]; ok && prev != c.Status && prev != "both" {
+					h.Commits = append(h.Commits, b.Commit)
+					h.commits[b.Commit] = "both"
+				} else if !ok {
+					h.commits[b.Commit] = c.Status
+				}
+			}
+		}
+	}
+
+	flaky := []FlakyTest{}
+	for _, k := range order {
+		if h := tests[k]; len(h.Commits) > 0 || h.Flips >= MinFlips {
+			flaky = append(flaky, h.FlakyTest)
+		}
+	}
+	sort.SliceStable(flaky, func(i, j int) bool {
+		if len(flaky[i].Commits) != len(flaky[j].Commits) {
+			return len(flaky[i].Commits) > len(flaky[j].Commits)
+		}
+		return flaky[i].Flips > flaky[j].Flips
+	})
+	return flaky
+}
@@ -0,0 +1,63 @@
+package testresults
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// test2jsonEvent is a line of the output of `go test -json`.
+type test2jsonEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// parseTest2JSON reads the test cases of the output of `go test -json`.
+//
+// Lines that are not test events, e.g. the output of the build, are ignored.
+// A package that failed without a failed test case, e.g. because it does not
+// build, is reported as a failed test case without a name.
+func parseTest2JSON(data []byte) ([]Case, error) {
+	type key struct{ pkg, test string }
+	output := map[key]*strings.Builder{}
+	failedTests := map[string]bool{}
+	var cases []Case
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 64<<10), 16<<20)
+	for s.Scan() {
+		var e test2jsonEvent
+		if json.Unmarshal(s.Bytes(), &e) != nil || e.Action == "" {
+			continue
+		}
+		k := key{e.Package, e.Test}
+		switch e.Action {
+		case "output":
+			if output[k] == nil {
+				output[k] = &strings.Builder{}
+			}
+			output[k].WriteString(e.Output)
+		case "pass", "fail", "skip":
+			status := map[string]string{"pass": StatusPassed, "fail": StatusFailed, "skip": StatusSkipped}[e.Action]
+			c := Case{Suite: e.Package, Name: e.Test, Status: status, Time: e.Elapsed}
+			if e.Test == "" {
+				// The result of the package.
+				if status != StatusFailed || failedTests[e.Package] {
+					continue
+				}
+			} else if status == StatusFailed {
+				failedTests[e.Package] = true
+			}
+			if status == StatusFailed && output[k] != nil {
+				c.Output = output[k].String()
+			}
+			delete(output, k)
+			cases = append(cases, c)
+		}
+	}
+	return cases, s.Err()
+}
@@ -0,0 +1,125 @@
+// Package testresults parses the test reports jobs publish, and summarizes
+// them per build and across the builds of a project.
+package testresults
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// These are the statuses of a test case.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// These are the formats of the test reports.
+const (
+	// FormatJUnit is the JUnit XML format most test runners can write.
+	FormatJUnit = "junit"
+	// FormatTest2JSON is the output of `go test -json`.
+	FormatTest2JSON = "test2json"
+)
+
+// Formats are all the formats of the test reports.
+var Formats = []string{FormatJUnit, FormatTest2JSON}
+
+// ArtifactDir is the directory of the artifacts of a build that holds the test
+// results of its jobs, as <job>.json.
+const ArtifactDir = ".tests"
+
+// MaxOutput is the maximum length of the output kept for a failed test case.
+var MaxOutput = 64 << 10
+
+// Case is the result of a test case.
+type Case struct {
+	// Suite is the test suite, class or Go package of the test case.
+	Suite string `json:"suite"`
+	// Name is the name of the test case. It is empty for the failures of a
+	// suite that are not the failure of a test case, e.g. a build failure.
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Time is the duration of the test case, in seconds.
+	Time float64 `json:"time"`
+	// Message is the failure or skip message.
+	Message string `json:"message,omitempty"`
+	// Output is the output of a failed test case.
+	Output string `json:"output,omitempty"`
+}
+
+// Counts are the number of test cases by status.
+type Counts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// Add counts a test case of the given status.
+func (c *Counts) Add(status string) {
+	c.Total++
+	switch status {
+	case StatusPassed:
+		c.Passed++
+	case StatusFailed:
+		c.Failed++
+	case StatusSkipped:
+		c.Skipped++
+	}
+}
+
+// Results are the test results a job published.
+type Results struct {
+	Job    string `json:"job"`
+	Format string `json:"format"`
+	Counts
+	Cases []Case `json:"cases"`
+}
+
+// Parse reads a test report of the given format. The format is detected from
+// the report if it is empty.
+func Parse(r io.Reader, format string) (*Results, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = detectFormat(data)
+	}
+	var cases []Case
+	switch format {
+	case FormatJUnit:
+		cases, err = parseJUnit(data)
+	case FormatTest2JSON:
+		cases, err = parseTest2JSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported test report format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s test report: %s", format, err)
+	}
+	if len(cases) == 0 {
+		return nil, errors.New("the test report has no test cases")
+	}
+	results := &Results{Format: format, Cases: cases}
+	for i := range cases {
+		results.Add(cases[i].Status)
+		if len(cases[i].Output) > MaxOutput {
+			cases[i].Output = cases[i].Output[len(cases[i].Output)-MaxOutput:]
+		}
+	}
+	return results, nil
+}
+
+// detectFormat guesses the format of a report from its first character. The
+// output of `go test -json` may start with lines of the build.
+func detectFormat(data []byte) string {
+	if t := bytes.TrimSpace(data); len(t) > 0 && t[0] == '<' {
+		return FormatJUnit
+	}
+	return FormatTest2JSON
+}
@@ -0,0 +1,143 @@
+package testresults
+
+import (
+	"strings"
+	"testing"
+)
+
+const junitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="api" tests="4">
+    <testcase classname="api.BuildTest" name="get" time="0.25"/>
+    <testcase classname="api.BuildTest" name="logs" time="1,200.5">
+      <failure message="expected 200, got 404">AssertionError at BuildTest.java:42</failure>
+      <system-out>GET /v1/build/b1/logs</system-out>
+    </testcase>
+    <testcase name="jobs"><skipped message="flaky"/></testcase>
+    <testsuite name="nested">
+      <testcase classname="api.JobTest" name="get"><error message="NullPointerException"/></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+const test2jsonReport = `go: downloading github.com/robfig/cron/v3 v3.0.1
+{"Action":"run","Package":"example.com/pequod","Test":"TestSail"}
+{"Action":"output","Package":"example.com/pequod","Test":"TestSail","Output":"=== RUN   TestSail\n"}
+{"Action":"pass","Package":"example.com/pequod","Test":"TestSail","Elapsed":0.5}
+{"Action":"run","Package":"example.com/pequod","Test":"TestHunt"}
+{"Action":"output","Package":"example.com/pequod","Test":"TestHunt","Output":"    hunt_test.go:12: the whale got away\n"}
+{"Action":"fail","Package":"example.com/pequod","Test":"TestHunt","Elapsed":1.5}
+{"Action":"skip","Package":"example.com/pequod","Test":"TestRest","Elapsed":0}
+{"Action":"fail","Package":"example.com/pequod","Elapsed":2.1}
+{"Action":"output","Package":"example.com/rachel","Output":"rachel.go:3:1: syntax error\n"}
+{"Action":"fail","Package":"example.com/rachel","Elapsed":0}
+{"Action":"pass","Package":"example.com/stubb","Elapsed":0.1}
+`
+
+func TestParse_JUnit(t *testing.T) {
+	results, err := Parse(strings.NewReader(junitReport), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Format != FormatJUnit {
+		t.Errorf("expected format %s, got %s", FormatJUnit, results.Format)
+	}
+	if results.Counts != (Counts{Total: 4, Passed: 1, Failed: 2, Skipped: 1}) {
+		t.Errorf("unexpected counts %+v", results.Counts)
+	}
+	logs := results.Cases[1]
+	if logs.Suite != "api.BuildTest" || logs.Time != 1200.5 || logs.Message != "expected 200, got 404" ||
+		logs.Output != "AssertionError at BuildTest.java:42\nGET /v1/build/b1/logs" {
+		t.Errorf("unexpected failed case %+v", logs)
+	}
+	if jobs := results.Cases[2]; jobs.Suite != "api" || jobs.Status != StatusSkipped {
+		t.Errorf("unexpected skipped case %+v", jobs)
+	}
+
+	single, err := Parse(strings.NewReader(`<testsuite name="api"><testcase name="get"/></testsuite>`), FormatJUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if single.Total != 1 || single.Cases[0].Suite != "api" {
+		t.Errorf("unexpected results %+v", single)
+	}
+}
+
+func TestParse_Test2JSON(t *testing.T) {
+	results, err := Parse(strings.NewReader(test2jsonReport), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Format != FormatTest2JSON {
+		t.Errorf("expected format %s, got %s", FormatTest2JSON, results.Format)
+	}
+	if results.Counts != (Counts{Total: 4, Passed: 1, Failed: 2, Skipped: 1}) {
+		t.Errorf("unexpected counts %+v", results.Counts)
+	}
+	if hunt := results.Cases[1]; hunt.Name != "TestHunt" || hunt.Output != "    hunt_test.go:12: the whale got away\n" {
+		t.Errorf("unexpected failed case %+v", hunt)
+	}
+	if rachel := results.Cases[3]; rachel.Suite != "example.com/rachel" || rachel.Name != "" || rachel.Status != StatusFailed {
+		t.Errorf("expected the failed package, got %+v", rachel)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, tt := range []struct{ report, format string }{
+		{"<html></html>", ""},
+		{"<testsuite>", FormatJUnit},
+		{"<testsuite name=\"empty\"/>", ""},
+		{"{}", ""},
+		{"report", "tap"},
+	} {
+		if _, err := Parse(strings.NewReader(tt.report), tt.format); err == nil {
+			t.Errorf("%q: expected an error", tt.report)
+		}
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	results, err := Parse(strings.NewReader(junitReport), FormatJUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results.Job = "test"
+	report := NewReport("b1", []*Results{results, results})
+	if report.Counts != (Counts{Total: 8, Passed: 2, Failed: 4, Skipped: 2}) {
+		t.Errorf("unexpected counts %+v", report.Counts)
+	}
+	if len(report.Jobs) != 2 || len(report.Jobs[0].Failures) != 2 || report.Jobs[0].Failures[0].Name != "logs" {
+		t.Errorf("unexpected jobs %+v", report.Jobs)
+	}
+}
+
+func TestFindFlaky(t *testing.T) {
+	build := func(id, commit string, statuses map[string]string) BuildResults {
+		r := &Results{Job: "test"}
+		for _, name := range []string{"TestSail", "TestHunt", "TestRest"} {
+			if s, ok := statuses[name]; ok {
+				r.Cases = append(r.Cases, Case{Suite: "pequod", Name: name, Status: s})
+			}
+		}
+		return BuildResults{BuildID: id, Commit: commit, Results: []*Results{r}}
+	}
+	p, f := StatusPassed, StatusFailed
+	flaky := FindFlaky([]BuildResults{
+		// TestSail passed and failed on commit c1, TestRest keeps flipping and
+		// TestHunt broke and was fixed.
+		build("b1", "c1", map[string]string{"TestSail": p, "TestHunt": p, "TestRest": p}),
+		build("b2", "c1", map[string]string{"TestSail": f, "TestHunt": p}),
+		build("b3", "c2", map[string]string{"TestSail": p, "TestHunt": f, "TestRest": f}),
+		build("b4", "c3", map[string]string{"TestSail": p, "TestHunt": p, "TestRest": p}),
+		build("b5", "c4", map[string]string{"TestSail": p, "TestHunt": p, "TestRest": f}),
+	})
+	if len(flaky) != 2 {
+		t.Fatalf("expected 2 flaky tests, got %+v", flaky)
+	}
+	if sail := flaky[0]; sail.Name != "TestSail" || sail.Passed != 4 || sail.Failed != 1 || sail.LastFailure != "b2" || len(sail.Commits) != 1 {
+		t.Errorf("unexpected flaky test %+v", sail)
+	}
+	if rest := flaky[1]; rest.Name != "TestRest" || rest.Flips != 3 || rest.LastFailure != "b5" {
+		t.Errorf("unexpected flaky test %+v", rest)
+	}
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartRequest starts the server span of a request, continuing the trace of
+// its traceparent header if any. The span is named after the method and the
+// given path, which must not contain secrets.
+func StartRequest(r *http.Request, path string) (context.Context, trace.Span) {
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return Start(ctx, r.Method+" "+path,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPMethodKey.String(r.Method)),
+	)
+}
+
+// EndRequest records the status of the response of a request and ends its span.
+func EndRequest(span trace.Span, status int) {
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(status))
+	span.End()
+}
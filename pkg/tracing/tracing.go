@@ -0,0 +1,163 @@
+// Package tracing traces a build from the request of the gateway that creates
+// it to the worker that runs it, with OpenTelemetry.
+//
+// Tracing is disabled until Configure is given an OTLP endpoint: the spans are
+// not recorded, but the trace context of the incoming requests is still passed
+// on to the build secret and the worker.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer of every span of Brigade.
+const instrumentationName = "github.com/brigadecore/brigade"
+
+// AnnotationPrefix prefixes the keys of the trace context in the annotations
+// of a build secret, e.g. brigade.sh/traceparent.
+const AnnotationPrefix = "brigade.sh/"
+
+// propagator reads and writes the trace context in the W3C format, in the
+// headers of the requests, the annotations of the build secrets and the
+// environment of the workers.
+var propagator = propagation.TraceContext{}
+
+// Configure exports the spans to the OTLP gRPC endpoint, e.g.
+// http://otel-collector:4317, on behalf of the given service. Spans are not
+// recorded when the endpoint is empty.
+//
+// The returned function flushes the spans that were not exported yet.
+func Configure(service, endpoint string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts, err := exporterOptions(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlptracegrpc.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the OTLP exporter: %s", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(service))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// exporterOptions returns the options of the exporter to the endpoint. The
+// connection is only secured when the scheme of the endpoint is https.
+func exporterOptions(endpoint string) ([]otlptracegrpc.Option, error) {
+	if !strings.Contains(endpoint, "://") {
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure()}, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %s", endpoint, err)
+	}
+	switch u.Scheme {
+	case "http":
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host), otlptracegrpc.WithInsecure()}, nil
+	case "https":
+		return []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host)}, nil
+	}
+	return nil, fmt.Errorf("invalid OTLP endpoint %q: the scheme must be http or https", endpoint)
+}
+
+// DefaultEndpoint returns the OTLP endpoint of $OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// or $OTEL_EXPORTER_OTLP_ENDPOINT, empty by default.
+func DefaultEndpoint() string {
+	if v, ok := os.LookupEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); ok {
+		return v
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// Tracer returns the tracer of Brigade.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span, child of the span of the context if any.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// Detach returns a background context carrying the span of ctx, for the work
+// that outlives the request of ctx, such as the creation of a build after the
+// gateway responded.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// carrier carries the trace context in a map.
+type carrier map[string]string
+
+func (c carrier) Get(key string) string {
+	return c[key]
+}
+
+func (c carrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c carrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Annotations returns the annotations of a build secret carrying the trace
+// context of ctx. It is empty when ctx is not traced.
+func Annotations(ctx context.Context) map[string]string {
+	c := carrier{}
+	propagator.Inject(ctx, c)
+	annotations := map[string]string{}
+	for k, v := range c {
+		annotations[AnnotationPrefix+k] = v
+	}
+	return annotations
+}
+
+// FromAnnotations returns a context continuing the trace of the annotations of
+// a build secret, if any.
+func FromAnnotations(ctx context.Context, annotations map[string]string) context.Context {
+	c := carrier{}
+	for k, v := range annotations {
+		if strings.HasPrefix(k, AnnotationPrefix) {
+			c[strings.TrimPrefix(k, AnnotationPrefix)] = v
+		}
+	}
+	return propagator.Extract(ctx, c)
+}
+
+// Env returns the environment variables carrying the trace context of ctx to a
+// worker: TRACEPARENT and, if set, TRACESTATE. It is empty when ctx is not
+// traced.
+func Env(ctx context.Context) map[string]string {
+	c := carrier{}
+	propagator.Inject(ctx, c)
+	env := map[string]string{}
+	for k, v := range c {
+		env[strings.ToUpper(k)] = v
+	}
+	return env
+}
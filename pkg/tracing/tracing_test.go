@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testTraceparent = "00-" + testTraceID + "-00f067aa0ba902b7-01"
+)
+
+func TestAnnotations(t *testing.T) {
+	if a := Annotations(context.Background()); len(a) != 0 {
+		t.Errorf("expected no annotations without a trace, got %v", a)
+	}
+
+	ctx := FromAnnotations(context.Background(), map[string]string{
+		"brigade.sh/traceparent": testTraceparent,
+		"projectName":            "ahab",
+	})
+	if a := Annotations(ctx); a["brigade.sh/traceparent"] != testTraceparent || len(a) != 1 {
+		t.Errorf("expected the traceparent annotation, got %v", a)
+	}
+	if env := Env(ctx); env["TRACEPARENT"] != testTraceparent {
+		t.Errorf("expected TRACEPARENT %q, got %v", testTraceparent, env)
+	}
+}
+
+func TestStartRequest(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	r := httptest.NewRequest("POST", "/simpleevents/v1/brigade-83f1/REDACTED", nil)
+	r.Header.Set("traceparent", testTraceparent)
+	ctx, span := StartRequest(r, r.URL.Path)
+	_, child := Start(ctx, "CreateBuild")
+	child.End()
+	EndRequest(span, 500)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	req := spans[1]
+	if req.Name() != "POST /simpleevents/v1/brigade-83f1/REDACTED" {
+		t.Errorf("unexpected span name %q", req.Name())
+	}
+	if req.SpanContext().TraceID().String() != testTraceID || spans[0].SpanContext().TraceID().String() != testTraceID {
+		t.Error("expected the spans to continue the trace of the request")
+	}
+	if spans[0].Parent().SpanID() != req.SpanContext().SpanID() {
+		t.Error("expected the span of the build to be a child of the span of the request")
+	}
+	if req.Status().Code.String() != "Error" {
+		t.Errorf("expected an error status, got %s", req.Status().Code)
+	}
+	if env := Env(ctx); !strings.Contains(env["TRACEPARENT"], req.SpanContext().SpanID().String()) {
+		t.Errorf("expected TRACEPARENT to refer to the span of the request, got %v", env)
+	}
+}
+
+func TestExporterOptions(t *testing.T) {
+	for _, tt := range []struct {
+		endpoint string
+		options  int
+		ok       bool
+	}{
+		{"otel-collector:4317", 2, true},
+		{"http://otel-collector:4317", 2, true},
+		{"https://otel-collector:4317", 1, true},
+		{"ftp://otel-collector:4317", 0, false},
+	} {
+		opts, err := exporterOptions(tt.endpoint)
+		if (err == nil) != tt.ok || len(opts) != tt.options {
+			t.Errorf("%s: expected %d options (ok %t), got %d (%v)", tt.endpoint, tt.options, tt.ok, len(opts), err)
+		}
+	}
+}
@@ -1,23 +1,25 @@
 package webhook
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/tracing"
 
 	gin "gopkg.in/gin-gonic/gin.v1"
 )
 
 type dockerPushHook struct {
-	store storage.Store
+	store storage.ContextStore
 }
 
 // NewDockerPushHook creates a new Docker Push handler for webhooks.
-func NewDockerPushHook(s storage.Store) gin.HandlerFunc {
+func NewDockerPushHook(s storage.ContextStore) gin.HandlerFunc {
 	h := &dockerPushHook{store: s}
 	return h.Handle
 }
@@ -27,7 +29,6 @@ func (s *dockerPushHook) Handle(c *gin.Context) {
 	var pname, commitish string
 	orgName := c.Param("org")
 	projName := c.Param("repo")
-	log.Println(projName)
 	if projName != "" {
 		pname = fmt.Sprintf("%s/%s", orgName, projName)
 	} else {
@@ -36,42 +37,45 @@ func (s *dockerPushHook) Handle(c *gin.Context) {
 	if commitish = c.Query("commit"); commitish == "" {
 		commitish = c.Param("commit")
 	}
-	log.Printf("Fetching commit %s for %s", commitish, pname)
+	logger := logging.FromContext(c.Request.Context()).With(logging.ProjectIDKey, brigade.ProjectID(pname))
+	logger.Infof("Fetching commit %s for %s", commitish, pname)
 
 	body, err := ioutil.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Printf("Failed to read body: %s", err)
+		logger.Errorf("Failed to read body: %s", err)
 		c.JSON(http.StatusBadRequest, gin.H{"status": "Malformed body"})
 		return
 	}
 	defer c.Request.Body.Close()
 
-	proj, err := s.store.GetProject(pname)
+	proj, err := s.store.GetProject(c.Request.Context(), pname)
 	if err != nil {
-		log.Printf("Project %q not found. No secret loaded. %s", pname, err)
+		logger.Errorf("Project %q not found. No secret loaded. %s", pname, err)
 		c.JSON(http.StatusBadRequest, gin.H{"status": "project not found"})
 		return
 	}
 
 	// Guard to make sure empty URL isn't sent to GitHub
 	if proj.Repo.Name == "" {
-		log.Printf("No Repo.Name on project")
+		logger.Errorf("No Repo.Name on project")
 		c.JSON(http.StatusBadRequest, gin.H{"status": "brigadejs not found"})
 		return
 	}
 
-	go s.notifyDockerImagePush(proj, commitish, body)
+	go s.notifyDockerImagePush(tracing.Detach(c.Request.Context()), logger, proj, commitish, body)
 	c.JSON(200, gin.H{"status": "Success"})
 }
 
-func (s *dockerPushHook) notifyDockerImagePush(proj *brigade.Project, commitish string, payload []byte) {
-	if err := s.doDockerImagePush(proj, commitish, payload); err != nil {
-		log.Printf("failed dockerimagepush event: %s", err)
+func (s *dockerPushHook) notifyDockerImagePush(parent context.Context, logger *logging.Logger, proj *brigade.Project, commitish string, payload []byte) {
+	ctx, cancel := context.WithTimeout(logging.NewContext(parent, logger), CreateBuildTimeout)
+	defer cancel()
+	if err := s.doDockerImagePush(ctx, proj, commitish, payload); err != nil {
+		logger.Errorf("failed dockerimagepush event: %s", err)
 	}
 
 }
 
-func (s *dockerPushHook) doDockerImagePush(proj *brigade.Project, commitish string, payload []byte) error {
+func (s *dockerPushHook) doDockerImagePush(ctx context.Context, proj *brigade.Project, commitish string, payload []byte) error {
 	b := &brigade.Build{
 		ProjectID: proj.ID,
 		Type:      "image_push",
@@ -84,5 +88,5 @@ func (s *dockerPushHook) doDockerImagePush(proj *brigade.Project, commitish stri
 	if proj.DefaultScript != "" {
 		b.Script = []byte(proj.DefaultScript)
 	}
-	return s.store.CreateBuild(b)
+	return createBuild(ctx, s.store, b)
 }
@@ -1,6 +1,7 @@
 package webhook
 
 import (
+	"context"
 	"testing"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
@@ -34,7 +35,7 @@ func TestDoDockerImagePush(t *testing.T) {
 		store: store,
 	}
 
-	if err := hook.doDockerImagePush(proj, commit, []byte(exampleWebhook)); err != nil {
+	if err := hook.doDockerImagePush(context.TODO(), proj, commit, []byte(exampleWebhook)); err != nil {
 		t.Errorf("failed docker image push: %s", err)
 	}
 	script := string(store.builds[0].Script)
@@ -51,7 +52,7 @@ func TestDoDockerImagePush_WithDefaultScript(t *testing.T) {
 	store := &testStore{}
 	hook := &dockerPushHook{store: store}
 
-	if err := hook.doDockerImagePush(proj, commit, []byte(exampleWebhook)); err != nil {
+	if err := hook.doDockerImagePush(context.TODO(), proj, commit, []byte(exampleWebhook)); err != nil {
 		t.Errorf("failed docker image push: %s", err)
 	}
 	script := string(store.builds[0].Script)
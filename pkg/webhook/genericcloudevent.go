@@ -1,13 +1,15 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
-	"log"
 	"net/http"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/tracing"
 
 	gin "gopkg.in/gin-gonic/gin.v1"
 
@@ -15,11 +17,11 @@ import (
 )
 
 type genericWebhookCloudEvent struct {
-	store storage.Store
+	store storage.ContextStore
 }
 
 // NewGenericWebhookCloudEvent creates a go-restful handler for generic Gateway that will handle CloudEvents.
-func NewGenericWebhookCloudEvent(s storage.Store) gin.HandlerFunc {
+func NewGenericWebhookCloudEvent(s storage.ContextStore) gin.HandlerFunc {
 	h := &genericWebhookCloudEvent{store: s}
 	return h.Handle
 }
@@ -28,11 +30,12 @@ func NewGenericWebhookCloudEvent(s storage.Store) gin.HandlerFunc {
 func (g *genericWebhookCloudEvent) Handle(c *gin.Context) {
 	projectID := c.Param("projectID")
 	secret := c.Param("secret")
+	logger := logging.FromContext(c.Request.Context()).With(logging.ProjectIDKey, projectID)
 
-	proj, err := g.store.GetProject(projectID)
+	proj, err := g.store.GetProject(c.Request.Context(), projectID)
 
 	if err != nil {
-		log.Printf("Project %q not found. No secret loaded. %s", projectID, err)
+		logger.Errorf("Project %q not found. No secret loaded. %s", projectID, err)
 		c.JSON(http.StatusBadRequest, gin.H{"status": "project not found"})
 		return
 	}
@@ -45,7 +48,7 @@ func (g *genericWebhookCloudEvent) Handle(c *gin.Context) {
 
 	payload, err := ioutil.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Printf("Failed to read body: %s", err)
+		logger.Errorf("Failed to read body: %s", err)
 		c.JSON(http.StatusBadRequest, gin.H{"status": "Malformed body"})
 		return
 	}
@@ -55,7 +58,7 @@ func (g *genericWebhookCloudEvent) Handle(c *gin.Context) {
 
 	err = json.Unmarshal(payload, &event)
 	if err != nil {
-		log.Printf("Failed to convert POST data into JSON: %s", err)
+		logger.Errorf("Failed to convert POST data into JSON: %s", err)
 		c.JSON(http.StatusBadRequest, gin.H{"status": "Malformed POST data - Invalid JSON"})
 		return
 	}
@@ -73,17 +76,19 @@ func (g *genericWebhookCloudEvent) Handle(c *gin.Context) {
 		return
 	}
 
-	go g.notifyGenericWebhookCloudEvent(proj, payload, event)
+	go g.notifyGenericWebhookCloudEvent(tracing.Detach(c.Request.Context()), logger, proj, payload, event)
 	c.JSON(200, gin.H{"status": "Success"})
 }
 
-func (g *genericWebhookCloudEvent) notifyGenericWebhookCloudEvent(proj *brigade.Project, payload []byte, event *cloudevents.Event) {
-	if err := g.genericWebhookCloudEvent(proj, payload, event); err != nil {
-		log.Printf("failed genericWebhook Cloud Event: %s", err)
+func (g *genericWebhookCloudEvent) notifyGenericWebhookCloudEvent(parent context.Context, logger *logging.Logger, proj *brigade.Project, payload []byte, event *cloudevents.Event) {
+	ctx, cancel := context.WithTimeout(logging.NewContext(parent, logger), CreateBuildTimeout)
+	defer cancel()
+	if err := g.genericWebhookCloudEvent(ctx, proj, payload, event); err != nil {
+		logger.Errorf("failed genericWebhook Cloud Event: %s", err)
 	}
 }
 
-func (g *genericWebhookCloudEvent) genericWebhookCloudEvent(proj *brigade.Project, payload []byte, event *cloudevents.Event) error {
+func (g *genericWebhookCloudEvent) genericWebhookCloudEvent(ctx context.Context, proj *brigade.Project, payload []byte, event *cloudevents.Event) error {
 	var revision brigade.Revision
 	if event.Data != nil {
 		data := event.Data.(map[string]interface{})
@@ -111,5 +116,5 @@ func (g *genericWebhookCloudEvent) genericWebhookCloudEvent(proj *brigade.Projec
 		Revision:  &revision,
 	}
 
-	return g.store.CreateBuild(b)
+	return createBuild(ctx, g.store, b)
 }
@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -16,7 +17,7 @@ import (
 	cloudevents "github.com/cloudevents/sdk-go/v02"
 )
 
-func newTestGenericWebhookHandlerCloudEvent(store storage.Store) *genericWebhookCloudEvent {
+func newTestGenericWebhookHandlerCloudEvent(store storage.ContextStore) *genericWebhookCloudEvent {
 	return &genericWebhookCloudEvent{store}
 }
 
@@ -31,7 +32,7 @@ func TestGenericWebhookCloudEventHandler(t *testing.T) {
 		ID:     "ea35b24ede421",
 	}
 
-	if err := h.genericWebhookCloudEvent(proj, []byte(exampleCloudEvent), event); err != nil {
+	if err := h.genericWebhookCloudEvent(context.TODO(), proj, []byte(exampleCloudEvent), event); err != nil {
 		t.Errorf("failed generic gateway cloud event: %s", err)
 	}
 
@@ -303,14 +304,14 @@ const exampleCloudEvent = `
 }
 `
 
-func newMockRouterCloudEvent(store storage.Store) *gin.Engine {
+func newMockRouterCloudEvent(store storage.ContextStore) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Recovery())
 
 	handler := NewGenericWebhookCloudEvent(store)
 
 	events := router.Group("/cloudevents/v02")
-	events.Use(gin.Logger())
+	events.Use(Logger())
 	events.POST("/:projectID/:secret", handler)
 
 	return router
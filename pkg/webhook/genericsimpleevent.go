@@ -1,24 +1,26 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 
 	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
 	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/tracing"
 
 	gin "gopkg.in/gin-gonic/gin.v1"
 )
 
 type genericWebhookSimpleEvent struct {
-	store storage.Store
+	store storage.ContextStore
 }
 
 // NewGenericWebhookSimpleEvent creates a go-restful handler for generic Gateway.
-func NewGenericWebhookSimpleEvent(s storage.Store) gin.HandlerFunc {
+func NewGenericWebhookSimpleEvent(s storage.ContextStore) gin.HandlerFunc {
 	h := &genericWebhookSimpleEvent{store: s}
 	return h.Handle
 }
@@ -27,11 +29,12 @@ func NewGenericWebhookSimpleEvent(s storage.Store) gin.HandlerFunc {
 func (g *genericWebhookSimpleEvent) Handle(c *gin.Context) {
 	projectID := c.Param("projectID")
 	secret := c.Param("secret")
+	logger := logging.FromContext(c.Request.Context()).With(logging.ProjectIDKey, projectID)
 
-	proj, err := g.store.GetProject(projectID)
+	proj, err := g.store.GetProject(c.Request.Context(), projectID)
 
 	if err != nil {
-		log.Printf("Project %q not found. No secret loaded. %s", projectID, err)
+		logger.Errorf("Project %q not found. No secret loaded. %s", projectID, err)
 		c.JSON(http.StatusBadRequest, gin.H{"status": "project not found"})
 		return
 	}
@@ -44,7 +47,7 @@ func (g *genericWebhookSimpleEvent) Handle(c *gin.Context) {
 
 	payload, err := ioutil.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Printf("Failed to read body: %s", err)
+		logger.Errorf("Failed to read body: %s", err)
 		c.JSON(http.StatusBadRequest, gin.H{"status": "Malformed body"})
 		return
 	}
@@ -56,23 +59,25 @@ func (g *genericWebhookSimpleEvent) Handle(c *gin.Context) {
 	if string(payload) != "" {
 		err = json.Unmarshal(payload, &revision)
 		if err != nil {
-			log.Printf("Failed to convert POST data into JSON: %s", err)
+			logger.Errorf("Failed to convert POST data into JSON: %s", err)
 			c.JSON(http.StatusBadRequest, gin.H{"status": "Malformed POST data - Invalid JSON"})
 			return
 		}
 	}
 
-	go g.notifyGenericWebhookSimpleEvent(proj, payload, revision)
+	go g.notifyGenericWebhookSimpleEvent(tracing.Detach(c.Request.Context()), logger, proj, payload, revision)
 	c.JSON(200, gin.H{"status": "Success. Build created"})
 }
 
-func (g *genericWebhookSimpleEvent) notifyGenericWebhookSimpleEvent(proj *brigade.Project, payload []byte, revision *brigade.Revision) {
-	if err := g.genericWebhookSimpleEvent(proj, payload, revision); err != nil {
-		log.Printf("failed genericWebhook SimpleEvent: %s", err)
+func (g *genericWebhookSimpleEvent) notifyGenericWebhookSimpleEvent(parent context.Context, logger *logging.Logger, proj *brigade.Project, payload []byte, revision *brigade.Revision) {
+	ctx, cancel := context.WithTimeout(logging.NewContext(parent, logger), CreateBuildTimeout)
+	defer cancel()
+	if err := g.genericWebhookSimpleEvent(ctx, proj, payload, revision); err != nil {
+		logger.Errorf("failed genericWebhook SimpleEvent: %s", err)
 	}
 }
 
-func (g *genericWebhookSimpleEvent) genericWebhookSimpleEvent(proj *brigade.Project, payload []byte, revision *brigade.Revision) error {
+func (g *genericWebhookSimpleEvent) genericWebhookSimpleEvent(ctx context.Context, proj *brigade.Project, payload []byte, revision *brigade.Revision) error {
 	b := &brigade.Build{
 		ProjectID: proj.ID,
 		Type:      "simpleevent",
@@ -88,7 +93,7 @@ func (g *genericWebhookSimpleEvent) genericWebhookSimpleEvent(proj *brigade.Proj
 		b.Revision = &brigade.Revision{Ref: "master"}
 	}
 
-	return g.store.CreateBuild(b)
+	return createBuild(ctx, g.store, b)
 }
 
 // validateGenericGatewaySecret will return an error if given Project does not have a GenericGatewaySecret or if the provided secret is wrong
@@ -97,13 +102,13 @@ func validateGenericGatewaySecret(proj *brigade.Project, secret string) error {
 	// if the secret is "" (probably i) due to a Brigade upgrade or ii) user did not create a Generic Gateway secret during `brig project create`)
 	// refuse to serve it, so Brigade admin will be forced to update the project with a non-empty secret
 	if proj.GenericGatewaySecret == "" {
-		log.Printf("Secret for project %s is empty, please update it and try again", proj.ID)
+		logging.With(logging.ProjectIDKey, proj.ID).Warnf("Secret for project %s is empty, please update it and try again", proj.ID)
 		return fmt.Errorf("secret for this Brigade Project is empty, refusing to serve, please inform your Brigade admin")
 	}
 
 	// compare secrets
 	if secret != proj.GenericGatewaySecret {
-		log.Printf("Secret %s for project %s is wrong", secret, proj.ID)
+		logging.With(logging.ProjectIDKey, proj.ID).Warnf("Secret %s for project %s is wrong", secret, proj.ID)
 		return fmt.Errorf("secret is wrong")
 	}
 
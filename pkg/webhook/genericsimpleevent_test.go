@@ -2,6 +2,7 @@ package webhook
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,7 +15,7 @@ import (
 	gin "gopkg.in/gin-gonic/gin.v1"
 )
 
-func newTestGenericWebhookSimpleEventHandler(store storage.Store) *genericWebhookSimpleEvent {
+func newTestGenericWebhookSimpleEventHandler(store storage.ContextStore) *genericWebhookSimpleEvent {
 	return &genericWebhookSimpleEvent{store}
 }
 
@@ -50,7 +51,7 @@ func TestGenericWebhookSimpleEventHandler(t *testing.T) {
 		Commit: "63c09efb6eb544f41a48901a6d0cc6ddfa4adb28",
 	}
 
-	if err := h.genericWebhookSimpleEvent(proj, []byte(exampleSimpleEvent), revision); err != nil {
+	if err := h.genericWebhookSimpleEvent(context.TODO(), proj, []byte(exampleSimpleEvent), revision); err != nil {
 		t.Errorf("failed generic gateway event: %s", err)
 	}
 
@@ -225,14 +226,14 @@ const exampleSimpleEvent = `
 }
 `
 
-func newMockRouterSimpleEvent(store storage.Store) *gin.Engine {
+func newMockRouterSimpleEvent(store storage.ContextStore) *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Recovery())
 
 	handler := NewGenericWebhookSimpleEvent(store)
 
 	events := router.Group("/simpleevents/v1")
-	events.Use(gin.Logger())
+	events.Use(Logger())
 	events.POST("/:projectID/:secret", handler)
 
 	return router
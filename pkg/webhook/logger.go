@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+
+	gin "gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+)
+
+// Logger returns a middleware that gives every request an ID, returned in the
+// X-Request-Id header, and a logger carrying it in the context of the request.
+// Every request is logged with its ID once it is handled, without the secrets
+// of its path.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := logging.RequestID(c.Request)
+		logger := logging.Default().WithRequestID(id)
+		c.Header(logging.RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+
+		start := time.Now()
+		c.Next()
+
+		path := redactedPath(c)
+		logger.With("method", c.Request.Method).
+			With("path", path).
+			With("status", c.Writer.Status()).
+			With("duration", time.Since(start).String()).
+			With("remote", c.ClientIP()).
+			Infof("%s %s %d", c.Request.Method, path, c.Writer.Status())
+	}
+}
+
+// redactedPath returns the path of the request without its secret.
+func redactedPath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	if secret := c.Param("secret"); secret != "" {
+		path = strings.Replace(path, secret, "REDACTED", -1)
+	}
+	return path
+}
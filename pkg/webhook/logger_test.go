@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	gin "gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/brigadecore/brigade/pkg/logging"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logging.SetOutput(&buf)
+	defer logging.SetOutput(os.Stderr)
+
+	var requestLogger *logging.Logger
+	router := gin.New()
+	router.Use(Logger())
+	router.POST("/simpleevents/v1/:projectID/:secret", func(c *gin.Context) {
+		requestLogger = logging.FromContext(c.Request.Context())
+		c.Status(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest("POST", "/simpleevents/v1/brigade-1234/s3cr3t", nil)
+	req.Header.Set(logging.RequestIDHeader, "42")
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, req)
+
+	if id := rw.Header().Get(logging.RequestIDHeader); id != "42" {
+		t.Errorf("expected the request ID in the response, got %q", id)
+	}
+	if requestLogger == logging.Default() {
+		t.Error("expected a logger with the request ID in the context of the request")
+	}
+	line := buf.String()
+	for _, expect := range []string{"request_id=42", "path=/simpleevents/v1/brigade-1234/REDACTED", "status=202"} {
+		if !strings.Contains(line, expect) {
+			t.Errorf("expected %q in %q", expect, line)
+		}
+	}
+	if strings.Contains(line, "s3cr3t") {
+		t.Errorf("expected the secret to be redacted, got %q", line)
+	}
+}
@@ -1,6 +1,7 @@
 package webhook
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -14,14 +15,14 @@ type testStore struct {
 	proj   *brigade.Project
 	builds []*brigade.Build
 	err    error
-	storage.Store
+	storage.ContextStore
 }
 
-func (s *testStore) GetProject(name string) (*brigade.Project, error) {
+func (s *testStore) GetProject(ctx context.Context, name string) (*brigade.Project, error) {
 	return s.proj, s.err
 }
 
-func (s *testStore) CreateBuild(build *brigade.Build) error {
+func (s *testStore) CreateBuild(ctx context.Context, build *brigade.Build) error {
 	s.builds = append(s.builds, build)
 	return s.err
 }
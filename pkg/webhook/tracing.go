@@ -0,0 +1,19 @@
+package webhook
+
+import (
+	gin "gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+// Tracing returns a middleware that starts a span for every request,
+// continuing the trace of its traceparent header if any. The span is named
+// after the path of the request, without its secret.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, span := tracing.StartRequest(c.Request, redactedPath(c))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		tracing.EndRequest(span, c.Writer.Status())
+	}
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	gin "gopkg.in/gin-gonic/gin.v1"
+
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	var annotations map[string]string
+	router := gin.New()
+	router.Use(Tracing())
+	router.POST("/simpleevents/v1/:projectID/:secret", func(c *gin.Context) {
+		annotations = tracing.Annotations(c.Request.Context())
+		c.Status(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest("POST", "/simpleevents/v1/brigade-1234/s3cr3t", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected a span for the request, got %d", len(spans))
+	}
+	if name := spans[0].Name(); name != "POST /simpleevents/v1/brigade-1234/REDACTED" {
+		t.Errorf("expected the secret to be redacted from the span name, got %q", name)
+	}
+	if spans[0].SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Error("expected the span to continue the trace of the request")
+	}
+	expect := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + spans[0].SpanContext().SpanID().String() + "-01"
+	if annotations[tracing.AnnotationPrefix+"traceparent"] != expect {
+		t.Errorf("expected the build annotations to carry the span of the request, got %v", annotations)
+	}
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/brigadecore/brigade/pkg/brigade"
+	"github.com/brigadecore/brigade/pkg/logging"
+	"github.com/brigadecore/brigade/pkg/storage"
+	"github.com/brigadecore/brigade/pkg/tracing"
+)
+
+// CreateBuildTimeout is the maximum duration of the creation of a build by a
+// webhook. Builds are created after the webhook responded, so the request does
+// not bound it.
+var CreateBuildTimeout = 30 * time.Second
+
+// createBuild creates a build and logs it with the logger of the context. The
+// build secret carries the trace context of the span of its creation.
+func createBuild(ctx context.Context, s storage.ContextStore, b *brigade.Build) error {
+	ctx, span := tracing.Start(ctx, "CreateBuild", trace.WithAttributes(
+		attribute.String(logging.ProjectIDKey, b.ProjectID),
+		attribute.String(logging.EventTypeKey, b.Type),
+		attribute.String(logging.ProviderKey, b.Provider),
+	))
+	defer span.End()
+	if err := s.CreateBuild(ctx, b); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetAttributes(attribute.String(logging.BuildIDKey, b.ID))
+	logging.FromContext(ctx).WithBuild(b).Infof("Created build %s", b.ID)
+	return nil
+}
@@ -4,6 +4,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -74,7 +75,7 @@ func generate() (payload []byte, hmac string) {
 		namespace = v1.NamespaceDefault
 	}
 
-	proj, err := kube.New(clientset, namespace).GetProject(repo)
+	proj, err := kube.New(clientset, namespace).GetProject(context.TODO(), repo)
 	if err != nil {
 		panic(err)
 	}